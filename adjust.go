@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+// Adjuster changes the severity or priority of findings after a scan. AI
+// triage is one implementation; pathAdjuster applies deterministic rules
+// without an LLM.
+type Adjuster interface {
+	// Name identifies the adjuster in adjusted_by metadata and diagnostics.
+	Name() string
+	// Adjust modifies findings in place. An error is reported as a warning
+	// diagnostic and does not stop later adjusters.
+	Adjust(ctx context.Context, findings []*pluginv1.Finding) error
+}
+
+// registeredAdjusters run on every scan, before adjusters configured through
+// tool input.
+var registeredAdjusters []Adjuster
+
+// RegisterAdjuster adds an adjuster that runs on every scan. Call it from an
+// init function.
+func RegisterAdjuster(a Adjuster) {
+	registeredAdjusters = append(registeredAdjusters, a)
+}
+
+// runAdjusters applies each adjuster in order, recording failures as warning
+// diagnostics on resp.
+func runAdjusters(ctx context.Context, resp *pluginv1.InvokeToolResponse, adjusters []Adjuster) {
+	for _, a := range adjusters {
+		if err := a.Adjust(ctx, resp.GetFindings()); err != nil {
+			addDiagnostic(resp, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+				fmt.Sprintf("adjuster %s: %v", a.Name(), err))
+		}
+	}
+}
+
+// adjustFinding sets a finding's severity and priority on behalf of an
+// adjuster, keeping the previous values in original_severity and
+// original_priority. Empty severity or priority leaves that field unchanged.
+func adjustFinding(f *pluginv1.Finding, by, severity, priority, reason string) {
+	if f.Metadata == nil {
+		f.Metadata = make(map[string]string)
+	}
+	f.Metadata["adjusted_by"] = by
+	if reason != "" {
+		f.Metadata["adjusted_reason"] = reason
+	}
+	if sev := parseSeverity(severity); sev != pluginv1.Severity(0) {
+		f.Metadata["original_severity"] = severityLabel(f)
+		f.Severity = sev
+		setCustomSeverity(f, severity)
+	}
+	if priority != "" {
+		f.Metadata["original_priority"] = f.Metadata["priority"]
+		f.Metadata["priority"] = priority
+	}
+}
+
+// llmAdjuster runs AI triage as a pipeline stage.
+type llmAdjuster struct {
+	provider plannerllm.Provider
+	model    string
+	cfg      *triageConfig
+	// filter limits the findings sent to the LLM; nil sends them all.
+	filter *triageFilter
+	// stats holds the summary of the last Adjust call.
+	stats triageStats
+}
+
+func (a *llmAdjuster) Name() string { return "ai_triage" }
+
+// Adjust sends the findings accepted by the filter to the LLM, skipping those
+// with a verdict reused from the baseline. Provider failures are recorded per
+// finding as ai_triage_error metadata, so it never returns an error.
+func (a *llmAdjuster) Adjust(ctx context.Context, findings []*pluginv1.Finding) error {
+	a.stats = aiTriageFindings(ctx, a.provider, a.model, a.filter.apply(uncachedFindings(findings)), a.cfg)
+	return nil
+}
+
+// pathAdjustment is one rule of the built-in path adjuster.
+type pathAdjustment struct {
+	// Path is a slash-separated glob matched against the workspace-relative
+	// file path. A trailing "/" or "/**" matches everything below it.
+	Path string
+	// Rule limits the adjustment to one rule ID; empty matches every rule.
+	Rule     string
+	Severity string
+	// Delta shifts the severity by that many levels instead of setting it;
+	// positive is more severe. It is only used when Severity is empty.
+	Delta    int
+	Priority string
+	Reason   string
+}
+
+// pathAdjuster changes findings whose file and rule match a configured
+// pathAdjustment. The first matching adjustment wins.
+type pathAdjuster struct {
+	name        string
+	root        string
+	adjustments []pathAdjustment
+}
+
+func (a *pathAdjuster) Name() string { return a.name }
+
+func (a *pathAdjuster) Adjust(_ context.Context, findings []*pluginv1.Finding) error {
+	for _, f := range findings {
+		file, _ := findingLocation(f)
+		rel := relativeFindingPath(a.root, file)
+		for _, adj := range a.adjustments {
+			if adj.Rule != "" && !strings.EqualFold(adj.Rule, f.GetRuleId()) {
+				continue
+			}
+			if !matchPathGlob(adj.Path, rel) {
+				continue
+			}
+			severity := adj.Severity
+			if severity == "" && adj.Delta != 0 {
+				severity = severityName(shiftSeverity(f.GetSeverity(), adj.Delta))
+			}
+			adjustFinding(f, a.Name(), severity, adj.Priority, adj.Reason)
+			break
+		}
+	}
+	return nil
+}
+
+// relativeFindingPath returns a finding's file path relative to root, using
+// forward slashes. Paths that are already relative are returned as is.
+func relativeFindingPath(root, file string) string {
+	if filepath.IsAbs(file) && root != "" {
+		if rel, err := filepath.Rel(root, file); err == nil {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+// matchPathGlob reports whether rel matches pattern. Patterns ending in "/"
+// or "/**" match the directory and everything below it; other patterns use
+// path.Match.
+func matchPathGlob(pattern, rel string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		pattern = dir + "/"
+	}
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(rel, pattern)
+	}
+	ok, _ := path.Match(pattern, rel)
+	return ok
+}
+
+// parsePathAdjustments reads the severity_adjustments input: a list of
+// objects with path, and optionally rule, severity, priority, and reason.
+func parsePathAdjustments(input map[string]any) ([]pathAdjustment, error) {
+	raw, _ := input["severity_adjustments"].([]any)
+	adjustments := make([]pathAdjustment, 0, len(raw))
+	for i, r := range raw {
+		m, ok := r.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("severity_adjustments[%d] must be an object", i)
+		}
+		adj := pathAdjustment{
+			Path:     inputString(m, "path"),
+			Rule:     inputString(m, "rule"),
+			Severity: inputString(m, "severity"),
+			Priority: inputString(m, "priority"),
+			Reason:   inputString(m, "reason"),
+		}
+		if adj.Path == "" {
+			return nil, fmt.Errorf("severity_adjustments[%d]: path is required", i)
+		}
+		if _, err := path.Match(adj.Path, ""); err != nil {
+			return nil, fmt.Errorf("severity_adjustments[%d]: %v", i, err)
+		}
+		if adj.Severity != "" && parseSeverity(adj.Severity) == pluginv1.Severity(0) {
+			return nil, fmt.Errorf("severity_adjustments[%d]: unknown severity %q", i, adj.Severity)
+		}
+		if adj.Severity == "" && adj.Priority == "" {
+			return nil, fmt.Errorf("severity_adjustments[%d]: severity or priority is required", i)
+		}
+		adjustments = append(adjustments, adj)
+	}
+	return adjustments, nil
+}
+
+// parsePathSeverityRules reads the path_severity_rules input: a list of
+// objects with a path glob and a severity that is either a level such as
+// "high" or a signed delta such as "+1" or "-2".
+func parsePathSeverityRules(input map[string]any) ([]pathAdjustment, error) {
+	raw, _ := input["path_severity_rules"].([]any)
+	adjustments := make([]pathAdjustment, 0, len(raw))
+	for i, r := range raw {
+		m, ok := r.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("path_severity_rules[%d] must be an object", i)
+		}
+		adj := pathAdjustment{Path: inputString(m, "path"), Reason: inputString(m, "reason")}
+		if adj.Path == "" {
+			return nil, fmt.Errorf("path_severity_rules[%d]: path is required", i)
+		}
+		if _, err := path.Match(adj.Path, ""); err != nil {
+			return nil, fmt.Errorf("path_severity_rules[%d]: %v", i, err)
+		}
+		severity := inputString(m, "severity")
+		if n, ok := m["severity"].(float64); ok {
+			// YAML and JSON read "+1" and "-2" as numbers.
+			severity = fmt.Sprintf("%+d", int(n))
+		}
+		switch {
+		case strings.HasPrefix(severity, "+") || strings.HasPrefix(severity, "-"):
+			delta, err := strconv.Atoi(severity)
+			if err != nil || delta == 0 {
+				return nil, fmt.Errorf("path_severity_rules[%d]: invalid severity delta %q", i, severity)
+			}
+			adj.Delta = delta
+		case parseSeverity(severity) != pluginv1.Severity(0):
+			adj.Severity = severity
+		default:
+			return nil, fmt.Errorf("path_severity_rules[%d]: unknown severity %q", i, severity)
+		}
+		adjustments = append(adjustments, adj)
+	}
+	return adjustments, nil
+}
+
+// shiftSeverity moves s by delta levels, positive towards critical, clamped
+// to the standard range. An unspecified severity is returned unchanged.
+func shiftSeverity(s pluginv1.Severity, delta int) pluginv1.Severity {
+	if s == pluginv1.Severity_SEVERITY_UNSPECIFIED {
+		return s
+	}
+	shifted := int(s) - delta
+	shifted = max(shifted, int(pluginv1.Severity_SEVERITY_CRITICAL))
+	shifted = min(shifted, int(pluginv1.Severity_SEVERITY_INFO))
+	return pluginv1.Severity(shifted)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"internal/", "internal/api/handler.go", true},
+		{"internal/**", "internal/api/handler.go", true},
+		{"internal/**", "cmd/internal.go", false},
+		{"*.py", "app.py", true},
+		{"*.py", "pkg/app.py", false},
+		{"pkg/*_test.go", "pkg/a_test.go", true},
+	}
+	for _, tt := range tests {
+		if got := matchPathGlob(tt.pattern, tt.rel); got != tt.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestPathAdjuster(t *testing.T) {
+	root := "/repo"
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "/repo/internal/api.go"}, Metadata: map[string]string{"priority": "scheduled"}},
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "/repo/internal/api.go"}},
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "/repo/cmd/main.go"}},
+	}
+	a := &pathAdjuster{name: "path", root: root, adjustments: []pathAdjustment{{
+		Path:     "internal/",
+		Rule:     "TRIAGE-002",
+		Severity: "low",
+		Priority: "backlog",
+		Reason:   "internal-only endpoint",
+	}}}
+	if err := a.Adjust(context.Background(), findings); err != nil {
+		t.Fatal(err)
+	}
+
+	f := findings[0]
+	if f.GetSeverity() != sdk.SeverityLow || f.Metadata["priority"] != "backlog" {
+		t.Errorf("expected low/backlog, got %v/%s", f.GetSeverity(), f.Metadata["priority"])
+	}
+	if f.Metadata["adjusted_by"] != "path" || f.Metadata["adjusted_reason"] != "internal-only endpoint" {
+		t.Errorf("unexpected adjustment metadata: %v", f.Metadata)
+	}
+	if f.Metadata["original_severity"] != "SEVERITY_MEDIUM" || f.Metadata["original_priority"] != "scheduled" {
+		t.Errorf("expected original values to be kept, got %v", f.Metadata)
+	}
+	if findings[1].GetSeverity() != sdk.SeverityHigh {
+		t.Error("finding for another rule should not be adjusted")
+	}
+	if findings[2].GetSeverity() != sdk.SeverityMedium {
+		t.Error("finding outside internal/ should not be adjusted")
+	}
+}
+
+func TestParsePathAdjustmentsInvalid(t *testing.T) {
+	tests := []map[string]any{
+		{"severity_adjustments": []any{"internal/"}},
+		{"severity_adjustments": []any{map[string]any{"severity": "low"}}},
+		{"severity_adjustments": []any{map[string]any{"path": "x/", "severity": "urgent"}}},
+		{"severity_adjustments": []any{map[string]any{"path": "x/"}}},
+		{"severity_adjustments": []any{map[string]any{"path": "[", "severity": "low"}}},
+	}
+	for _, input := range tests {
+		if _, err := parsePathAdjustments(input); err == nil {
+			t.Errorf("expected an error for %v", input)
+		}
+	}
+}
+
+type failingAdjuster struct{}
+
+func (failingAdjuster) Name() string { return "failing" }
+
+func (failingAdjuster) Adjust(context.Context, []*pluginv1.Finding) error {
+	return errors.New("boom")
+}
+
+func TestRunAdjustersReportsErrors(t *testing.T) {
+	resp := &pluginv1.InvokeToolResponse{}
+	runAdjusters(context.Background(), resp, []Adjuster{failingAdjuster{}})
+	if len(resp.GetDiagnostics()) != 1 || resp.GetDiagnostics()[0].GetMessage() != "adjuster failing: boom" {
+		t.Errorf("expected a warning diagnostic, got %v", resp.GetDiagnostics())
+	}
+}
+
+func TestScanSeverityAdjustments(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "internal", "api.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "app.py"), "eval(x)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"severity_adjustments": []any{map[string]any{
+			"path":     "internal/**",
+			"severity": "info",
+		}},
+	})
+
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-001") {
+		internal := filepath.Base(filepath.Dir(f.GetLocation().GetFilePath())) == "internal"
+		if internal != (f.GetSeverity() == sdk.SeverityInfo) {
+			t.Errorf("%s: severity %v", f.GetLocation().GetFilePath(), f.GetSeverity())
+		}
+	}
+}
+
+func TestShiftSeverity(t *testing.T) {
+	tests := []struct {
+		in    pluginv1.Severity
+		delta int
+		want  pluginv1.Severity
+	}{
+		{sdk.SeverityMedium, 1, sdk.SeverityHigh},
+		{sdk.SeverityMedium, -2, sdk.SeverityInfo},
+		{sdk.SeverityHigh, 5, sdk.SeverityCritical},
+		{sdk.SeverityLow, -5, sdk.SeverityInfo},
+		{pluginv1.Severity_SEVERITY_UNSPECIFIED, 1, pluginv1.Severity_SEVERITY_UNSPECIFIED},
+	}
+	for _, tt := range tests {
+		if got := shiftSeverity(tt.in, tt.delta); got != tt.want {
+			t.Errorf("shiftSeverity(%v, %d) = %v, want %v", tt.in, tt.delta, got, tt.want)
+		}
+	}
+}
+
+func TestParsePathSeverityRules(t *testing.T) {
+	rules, err := parsePathSeverityRules(map[string]any{"path_severity_rules": []any{
+		map[string]any{"path": "handlers/", "severity": "+1"},
+		map[string]any{"path": "internal/", "severity": float64(-1)},
+		map[string]any{"path": "api/", "severity": "critical"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Delta != 1 || rules[1].Delta != -1 || rules[2].Severity != "critical" {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	for _, sev := range []any{"+0", "+x", "urgent", nil} {
+		input := map[string]any{"path_severity_rules": []any{map[string]any{"path": "a/", "severity": sev}}}
+		if _, err := parsePathSeverityRules(input); err == nil {
+			t.Errorf("expected severity %v to be rejected", sev)
+		}
+	}
+}
+
+func TestScanPathSeverityRules(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "handlers", "login.py"), "name = request.args[\"n\"]\n")
+	writeFile(t, filepath.Join(root, "internal", "util.py"), "name = request.args[\"n\"]\n")
+	writeFile(t, filepath.Join(root, "app.py"), "name = request.args[\"n\"]\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"path_severity_rules": []any{
+			map[string]any{"path": "handlers/**", "severity": "+1"},
+			map[string]any{"path": "internal/**", "severity": "-1"},
+		},
+	})
+
+	want := map[string]pluginv1.Severity{
+		"handlers":          sdk.SeverityHigh,
+		"internal":          sdk.SeverityLow,
+		filepath.Base(root): sdk.SeverityMedium,
+	}
+	found := findByRule(resp.GetFindings(), "TRIAGE-002")
+	if len(found) != 3 {
+		t.Fatalf("expected 3 TRIAGE-002 findings, got %d", len(found))
+	}
+	for _, f := range found {
+		dir := filepath.Base(filepath.Dir(f.GetLocation().GetFilePath()))
+		if f.GetSeverity() != want[dir] {
+			t.Errorf("%s: severity %v, want %v", f.GetLocation().GetFilePath(), f.GetSeverity(), want[dir])
+		}
+	}
+}
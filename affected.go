@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// affectedFile summarizes the findings in one file.
+type affectedFile struct {
+	Path        string
+	Count       int
+	MaxSeverity pluginv1.Severity
+}
+
+// String renders the entry as reported in the affected-files diagnostics.
+func (a affectedFile) String() string {
+	return fmt.Sprintf("affected: %s (%d finding(s), max %s)", a.Path, a.Count, severityName(a.MaxSeverity))
+}
+
+// affectedFiles returns one entry per file with findings, with paths relative
+// to root, ranked by most severe finding, then finding count, then path.
+func affectedFiles(root string, findings []*pluginv1.Finding) []affectedFile {
+	index := make(map[string]int)
+	var files []affectedFile
+	for _, f := range findings {
+		file, _ := findingLocation(f)
+		path := relativeFindingPath(root, file)
+		i, ok := index[path]
+		if !ok {
+			i = len(files)
+			index[path] = i
+			files = append(files, affectedFile{Path: path})
+		}
+		files[i].Count++
+		if severityMoreSevere(f.GetSeverity(), files[i].MaxSeverity) {
+			files[i].MaxSeverity = f.GetSeverity()
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.MaxSeverity != b.MaxSeverity {
+			return severityMoreSevere(a.MaxSeverity, b.MaxSeverity)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Path < b.Path
+	})
+	return files
+}
+
+// severityName returns the lower-case name of a standard severity, such as
+// "high".
+func severityName(s pluginv1.Severity) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "SEVERITY_"))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+func TestAffectedFilesRanking(t *testing.T) {
+	finding := func(file string, sev pluginv1.Severity) *pluginv1.Finding {
+		return &pluginv1.Finding{Severity: sev, Location: &pluginv1.Location{FilePath: "/repo/" + file}}
+	}
+	findings := []*pluginv1.Finding{
+		finding("low.py", sdk.SeverityLow),
+		finding("low.py", sdk.SeverityLow),
+		finding("low.py", sdk.SeverityLow),
+		finding("b.py", sdk.SeverityHigh),
+		finding("a.py", sdk.SeverityMedium),
+		finding("a.py", sdk.SeverityHigh),
+		finding("c.py", sdk.SeverityHigh),
+	}
+
+	got := affectedFiles("/repo", findings)
+	want := []affectedFile{
+		{"a.py", 2, sdk.SeverityHigh},
+		{"b.py", 1, sdk.SeverityHigh},
+		{"c.py", 1, sdk.SeverityHigh},
+		{"low.py", 3, sdk.SeverityLow},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d files, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rank %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if s := got[0].String(); s != "affected: a.py (2 finding(s), max high)" {
+		t.Errorf("unexpected rendering %q", s)
+	}
+}
+
+func TestScanAffectedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "b.py"), "h = hashlib.sha256()\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"affected_files": true,
+	})
+
+	var entries []string
+	for _, d := range resp.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "affected: ") {
+			entries = append(entries, d.GetMessage())
+		}
+	}
+	if len(entries) != 2 || !strings.HasPrefix(entries[0], "affected: a.py ") {
+		t.Errorf("expected a.py ranked first of 2 files, got %v", entries)
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"slices"
 	"strings"
+	"time"
 
 	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
 	"github.com/nox-hq/nox/sdk"
 	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const triageSystemPrompt = `You are a security triage assistant. You analyze code security findings and provide contextual severity adjustments.
@@ -24,7 +28,7 @@ Respond ONLY with a JSON array. Each element must have these fields:
 - "rule_id": string (the original rule ID)
 - "file": string (the file path)
 - "line": integer (the line number)
-- "adjusted_severity": string (one of: "critical", "high", "medium", "low", "info")
+- "adjusted_severity": string (one of: "blocker", "critical", "high", "medium", "low", "info", "trivial")
 - "adjusted_priority": string (one of: "immediate", "scheduled", "backlog", "informational")
 - "classification": string (one of: "true_positive", "false_positive", "needs_review")
 - "reason": string (brief explanation)
@@ -40,51 +44,347 @@ type triageAdjustment struct {
 	AdjustedPriority string `json:"adjusted_priority"`
 	Classification   string `json:"classification"`
 	Reason           string `json:"reason"`
+
+	// Conflict lists the differing suggestions when the LLM returned more
+	// than one adjustment for the same finding. See dedupeAdjustments.
+	Conflict string `json:"-"`
+}
+
+// adjustmentKey identifies the finding an adjustment applies to.
+type adjustmentKey struct {
+	ruleID string
+	file   string
+	line   int32
+}
+
+func (a triageAdjustment) key() adjustmentKey {
+	return adjustmentKey{a.RuleID, a.File, int32(a.Line)}
+}
+
+// sameAs reports whether a and b suggest the same outcome. The reason text
+// is not compared.
+func (a triageAdjustment) sameAs(b triageAdjustment) bool {
+	return strings.EqualFold(a.AdjustedSeverity, b.AdjustedSeverity) &&
+		strings.EqualFold(a.AdjustedPriority, b.AdjustedPriority) &&
+		strings.EqualFold(a.Classification, b.Classification)
+}
+
+// suggestion summarizes the adjustment for ai_triage_conflict metadata.
+func (a triageAdjustment) suggestion() string {
+	return fmt.Sprintf("%s/%s/%s", a.AdjustedSeverity, a.AdjustedPriority, a.Classification)
+}
+
+// defaultTriageBatchSize is the number of findings sent to the LLM per request
+// when NOX_AI_BATCH_SIZE is not set.
+const defaultTriageBatchSize = 50
+
+// triageConfig holds the settings for one AI triage run.
+type triageConfig struct {
+	BatchSize int
+	Timeout   time.Duration
+	// Stream applies adjustments as the response streams in, for providers
+	// that implement streamingProvider.
+	Stream bool
+	// Grouping decides which findings share a batch; see triageBatches.
+	Grouping string
+	// ClassifyOnly records the model's classification without applying its
+	// severity and priority changes; see applyAdjustments.
+	ClassifyOnly bool
+	// AllowedSeverities lists the lower-case severities the model may set,
+	// from NOX_AI_ALLOWED_SEVERITIES; nil allows all.
+	AllowedSeverities map[string]bool
+	// Audit records each LLM call when NOX_AI_AUDIT_DIR is set.
+	Audit *auditLog
+	// Progress reports each completed batch; nil reports nothing.
+	Progress *progressReporter
+	// AnonymizePaths sends opaque tokens instead of file paths; see
+	// pathAliases.
+	AnonymizePaths bool
+	// PriorityLevels are the priorities the model may set, most urgent
+	// first; nil means defaultPriorityLevels.
+	PriorityLevels priorityLevels
+	// ContextLines is how many source lines either side of each finding
+	// are sent with it; 0 sends none. SharedContext sends each file's
+	// lines once per batch instead of once per finding. Relative paths are
+	// read from SourceRoot. See sourceContext.
+	ContextLines  int
+	SharedContext bool
+	SourceRoot    string
+	// JSONMode asks the provider to constrain the model to JSON output;
+	// jsonModeOff relies on the prompt alone. See triageJSONMode.
+	JSONMode jsonMode
+	// Examples are few-shot examples from NOX_AI_EXAMPLES_FILE, appended
+	// to the system prompt.
+	Examples []triageExample
+}
+
+// Batch grouping strategies for NOX_AI_GROUPING.
+const (
+	groupByCount = "count"
+	groupByFile  = "file"
+	groupByRule  = "rule"
+)
+
+// newTriageConfig reads the triage settings, falling back to built-in
+// defaults for anything unset.
+func newTriageConfig(s settings) *triageConfig {
+	return &triageConfig{
+		BatchSize:         s.getInt("NOX_AI_BATCH_SIZE", defaultTriageBatchSize),
+		Timeout:           s.getDuration("NOX_AI_TIMEOUT"),
+		Stream:            s.getBool("NOX_AI_STREAM"),
+		Grouping:          triageGrouping(s.get("NOX_AI_GROUPING")),
+		AllowedSeverities: allowedSeverities(s.get("NOX_AI_ALLOWED_SEVERITIES")),
+		AnonymizePaths:    s.getBool("NOX_AI_ANONYMIZE_PATHS"),
+		ContextLines:      s.getInt("NOX_AI_CONTEXT_LINES", 0),
+		SharedContext:     s.getBool("NOX_AI_SHARED_CONTEXT"),
+		JSONMode:          triageJSONMode(s),
+	}
+}
+
+// allowedSeverities parses a comma-separated NOX_AI_ALLOWED_SEVERITIES value.
+// Unknown names are logged and ignored; an empty value allows all.
+func allowedSeverities(v string) map[string]bool {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	allowed := make(map[string]bool)
+	for _, name := range strings.Split(v, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if parseSeverity(name) == pluginv1.Severity(0) {
+			log.Printf("ai_triage: unknown severity %q in NOX_AI_ALLOWED_SEVERITIES", name)
+			continue
+		}
+		allowed[name] = true
+	}
+	return allowed
+}
+
+// systemPrompt returns the triage system prompt, listing the configured
+// priority levels in place of the defaults and followed by any few-shot
+// examples. OpenAI's JSON mode only admits an object, so with it the model
+// is asked to wrap the array in one.
+func (c *triageConfig) systemPrompt() string {
+	prompt := triageSystemPrompt
+	if c == nil {
+		return prompt
+	}
+	if c.PriorityLevels != nil {
+		prompt = strings.Replace(prompt, defaultPriorityLevels.quoted(), c.PriorityLevels.quoted(), 1)
+	}
+	if c.JSONMode == jsonModeOpenAI {
+		prompt = strings.NewReplacer(
+			"Respond ONLY with a JSON array. Each element must",
+			`Respond ONLY with a JSON object of the form {"adjustments": [...]}. Each element of the "adjustments" array must`,
+			"outside the JSON array.", "outside the JSON object.",
+		).Replace(prompt)
+	}
+	return prompt + examplesPrompt(c.Examples)
+}
+
+// priorityLevels returns the configured priority levels, or nil for the
+// defaults.
+func (c *triageConfig) priorityLevels() priorityLevels {
+	if c == nil {
+		return nil
+	}
+	return c.PriorityLevels
+}
+
+// severityAllowed reports whether the model may set the severity label:
+// either the label or the standard severity it maps to must be listed.
+func (c *triageConfig) severityAllowed(label string) bool {
+	if c == nil || c.AllowedSeverities == nil {
+		return true
+	}
+	return c.AllowedSeverities[strings.ToLower(label)] || c.AllowedSeverities[severityName(parseSeverity(label))]
+}
+
+// triageGrouping validates a NOX_AI_GROUPING value, falling back to
+// grouping by count.
+func triageGrouping(v string) string {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "", groupByCount:
+		return groupByCount
+	case groupByFile, groupByRule:
+		return v
+	default:
+		log.Printf("ai_triage: unknown NOX_AI_GROUPING %q; grouping by count", v)
+		return groupByCount
+	}
 }
 
 // aiTriageFindings sends findings to an LLM for contextual severity adjustment.
-// On any error, findings are returned unchanged with ai_triage_error metadata.
-func aiTriageFindings(ctx context.Context, provider plannerllm.Provider, model string, findings []*pluginv1.Finding) {
+// Findings are sent in batches and each batch's adjustments are applied as soon
+// as it completes, so a deadline hit mid-run only leaves the unfinished tail
+// un-triaged. On any error, the affected findings are returned unchanged with
+// ai_triage_error metadata. A nil cfg uses settings from the environment. The
+// returned statistics summarize what the model changed and are also logged.
+func aiTriageFindings(ctx context.Context, provider plannerllm.Provider, model string, findings []*pluginv1.Finding, cfg *triageConfig) triageStats {
 	if len(findings) == 0 {
-		return
+		return triageStats{}
+	}
+	if cfg == nil {
+		cfg = newTriageConfig(nil)
+	}
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	before := make([]pluginv1.Severity, len(findings))
+	for i, f := range findings {
+		before[i] = f.GetSeverity()
+	}
+
+	done, unmatched := 0, 0
+	batches := triageBatches(findings, cfg.BatchSize, cfg.Grouping)
+	for i, batch := range batches {
+		if err := ctx.Err(); err != nil {
+			log.Printf("ai_triage: stopping after %d of %d findings: %v", done, len(findings), err)
+			for _, rest := range batches[i:] {
+				markTriageError(rest, fmt.Sprintf("not triaged: %v", err))
+			}
+			break
+		}
+		unmatched += triageBatch(ctx, provider, model, batch, cfg)
+		done += len(batch)
+		cfg.Progress.batchTriaged(i+1, len(batches), done)
+	}
+
+	stats := summarizeTriage(findings, before, unmatched)
+	log.Print(stats)
+	return stats
+}
+
+// triageBatches splits findings into batches of at most batchSize. Grouping
+// by count takes findings in order; grouping by file or rule keeps findings
+// that share a file or rule in the same batch where they fit, so the model
+// can judge them together. Groups larger than batchSize are split.
+func triageBatches(findings []*pluginv1.Finding, batchSize int, grouping string) [][]*pluginv1.Finding {
+	if batchSize <= 0 {
+		batchSize = defaultTriageBatchSize
+	}
+
+	var groups [][]*pluginv1.Finding
+	switch grouping {
+	case groupByFile, groupByRule:
+		index := make(map[string]int)
+		for _, f := range findings {
+			k := f.GetRuleId()
+			if grouping == groupByFile {
+				k, _ = findingLocation(f)
+			}
+			i, ok := index[k]
+			if !ok {
+				i = len(groups)
+				index[k] = i
+				groups = append(groups, nil)
+			}
+			groups[i] = append(groups[i], f)
+		}
+	default:
+		groups = [][]*pluginv1.Finding{findings}
 	}
 
-	userMsg := buildTriagePrompt(findings)
+	var batches [][]*pluginv1.Finding
+	var current []*pluginv1.Finding
+	for _, g := range groups {
+		// Start a new batch rather than split a group that would fit whole.
+		if len(current) > 0 && len(current)+len(g) > batchSize && len(g) <= batchSize {
+			batches = append(batches, current)
+			current = nil
+		}
+		for len(g) > 0 {
+			n := min(batchSize-len(current), len(g))
+			current = append(current, g[:n]...)
+			g = g[n:]
+			if len(current) == batchSize {
+				batches = append(batches, current)
+				current = nil
+			}
+		}
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// triageBatch sends a single batch of findings to the LLM and applies the
+// returned adjustments in place. With stream set, providers that support it
+// are streamed; others fall back to a blocking call. It returns the number of
+// adjustments that matched no finding in the batch.
+func triageBatch(ctx context.Context, provider plannerllm.Provider, model string, findings []*pluginv1.Finding, cfg *triageConfig) int {
+	var aliases *pathAliases
+	if cfg.AnonymizePaths {
+		aliases = newPathAliases(findings)
+	}
+	userMsg := buildTriagePrompt(findings, aliases, newSourceContext(cfg))
 
-	resp, err := provider.Complete(ctx, plannerllm.CompletionRequest{
+	req := plannerllm.CompletionRequest{
 		Model: model,
 		Messages: []plannerllm.Message{
-			{Role: "system", Content: triageSystemPrompt},
+			{Role: "system", Content: cfg.systemPrompt()},
 			{Role: "user", Content: userMsg},
 		},
 		Temperature: 0.2,
 		MaxTokens:   4096,
-	})
+	}
+	ctx, span := startSpan(ctx, "llm.call",
+		attribute.String("llm.provider", provider.Name()),
+		attribute.String("llm.model", model),
+		attribute.Int("findings", len(findings)),
+		attribute.Bool("llm.stream", cfg.Stream))
+	start := time.Now()
+	defer func() {
+		span.SetAttributes(attribute.Int64("llm.latency_ms", time.Since(start).Milliseconds()))
+		span.End()
+	}()
+	if cfg.Stream {
+		if sp, ok := provider.(streamingProvider); ok {
+			return streamBatch(ctx, sp, req, findings, cfg, aliases)
+		}
+		log.Printf("ai_triage: provider %s does not support streaming; waiting for the full response", provider.Name())
+	}
+
+	resp, err := provider.Complete(ctx, req)
+	cfg.Audit.record(provider.Name(), req, resp.Message.Content, err)
 	if err != nil {
+		recordSpanError(ctx, err)
 		log.Printf("ai_triage: LLM call failed: %v", err)
 		markTriageError(findings, fmt.Sprintf("LLM call failed: %v", err))
-		return
+		return 0
 	}
 
 	adjustments, err := parseTriageResponse(resp.Message.Content)
 	if err != nil {
+		recordSpanError(ctx, err)
 		log.Printf("ai_triage: failed to parse LLM response: %v", err)
 		markTriageError(findings, fmt.Sprintf("failed to parse LLM response: %v", err))
-		return
+		return 0
 	}
+	aliases.restore(adjustments)
 
-	applyAdjustments(findings, adjustments)
+	applyAdjustments(findings, adjustments, cfg)
+	return unmatchedAdjustments(findings, adjustments)
 }
 
 // buildTriagePrompt serializes findings into a user message for the LLM.
-func buildTriagePrompt(findings []*pluginv1.Finding) string {
+// With aliases, file paths are replaced by their tokens. With src, each
+// finding carries the source lines around it, or with src.shared, the
+// message ends with each file's lines once, covering all of its findings.
+func buildTriagePrompt(findings []*pluginv1.Finding, aliases *pathAliases, src *sourceContext) string {
 	type findingSummary struct {
-		RuleID   string `json:"rule_id"`
-		Severity string `json:"severity"`
-		File     string `json:"file"`
-		Line     int32  `json:"line"`
-		Message  string `json:"message"`
-		Priority string `json:"priority"`
+		RuleID    string `json:"rule_id"`
+		Severity  string `json:"severity"`
+		File      string `json:"file"`
+		Line      int32  `json:"line"`
+		Message   string `json:"message"`
+		Priority  string `json:"priority"`
+		Framework string `json:"framework,omitempty"`
+		Context   string `json:"context,omitempty"`
 	}
 
 	summaries := make([]findingSummary, len(findings))
@@ -100,21 +400,56 @@ func buildTriagePrompt(findings []*pluginv1.Finding) string {
 			priority = f.GetMetadata()["priority"]
 		}
 		summaries[i] = findingSummary{
-			RuleID:   f.GetRuleId(),
-			Severity: f.GetSeverity().String(),
-			File:     file,
-			Line:     line,
-			Message:  f.GetMessage(),
-			Priority: priority,
+			RuleID:    f.GetRuleId(),
+			Severity:  f.GetSeverity().String(),
+			File:      aliases.token(file),
+			Line:      line,
+			Message:   f.GetMessage(),
+			Priority:  priority,
+			Framework: f.GetMetadata()["framework"],
+		}
+		if src != nil && !src.shared {
+			summaries[i].Context = src.snippet(file, line)
+		}
+	}
+
+	msg := fmt.Sprintf("Please triage the following %d security findings:\n\n%s", len(findings), promptJSON(summaries))
+	if src != nil && src.shared {
+		if files := src.sharedContext(findings, aliases); len(files) > 0 {
+			msg += "\n\nSource context for these findings, each file once; lines marked > hold a finding:\n\n" + promptJSON(files)
 		}
 	}
+	return msg
+}
 
-	data, _ := json.MarshalIndent(summaries, "", "  ")
-	return fmt.Sprintf("Please triage the following %d security findings:\n\n%s", len(findings), string(data))
+// promptJSON encodes v as indented JSON for the prompt. Unlike
+// json.MarshalIndent it leaves <, >, and & unescaped, since code is full of
+// them and the escapes cost tokens.
+func promptJSON(v any) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+	return strings.TrimSuffix(b.String(), "\n")
 }
 
-// parseTriageResponse extracts triage adjustments from the LLM response content.
+// Limits on LLM responses. A well-formed reply is a flat array of small
+// objects, a few kilobytes per batch; anything far beyond that is a
+// misbehaving model and is rejected before it is decoded.
+const (
+	maxTriageResponseBytes = 1 << 20
+	maxTriageJSONDepth     = 8
+)
+
+// parseTriageResponse extracts triage adjustments from the LLM response
+// content: a JSON array, or an object holding it under "adjustments".
+// Responses over maxTriageResponseBytes or nested deeper than
+// maxTriageJSONDepth are rejected.
 func parseTriageResponse(content string) ([]triageAdjustment, error) {
+	if len(content) > maxTriageResponseBytes {
+		return nil, fmt.Errorf("response is %d bytes, limit is %d", len(content), maxTriageResponseBytes)
+	}
 	content = strings.TrimSpace(content)
 
 	// Strip markdown code fences if present.
@@ -129,26 +464,134 @@ func parseTriageResponse(content string) ([]triageAdjustment, error) {
 		content = strings.Join(lines, "\n")
 	}
 
+	if err := checkJSONDepth([]byte(content), maxTriageJSONDepth); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(content))
 	var adjustments []triageAdjustment
-	if err := json.Unmarshal([]byte(content), &adjustments); err != nil {
+	if strings.HasPrefix(content, "{") {
+		// The array wrapped in an object, as requested in OpenAI's JSON mode.
+		var wrapped struct {
+			Adjustments *[]triageAdjustment `json:"adjustments"`
+		}
+		if err := dec.Decode(&wrapped); err != nil {
+			return nil, fmt.Errorf("invalid JSON in LLM response: %w", err)
+		}
+		if wrapped.Adjustments == nil {
+			return nil, fmt.Errorf("invalid JSON in LLM response: object has no adjustments array")
+		}
+		adjustments = *wrapped.Adjustments
+	} else if err := dec.Decode(&adjustments); err != nil {
 		return nil, fmt.Errorf("invalid JSON in LLM response: %w", err)
 	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid JSON in LLM response: unexpected data after the array")
+	}
 	return adjustments, nil
 }
 
-// applyAdjustments modifies findings in-place based on LLM suggestions.
-func applyAdjustments(findings []*pluginv1.Finding, adjustments []triageAdjustment) {
-	// Build lookup: (rule_id, file, line) -> adjustment
-	type key struct {
-		ruleID string
-		file   string
-		line   int32
+// checkJSONDepth returns an error if data nests arrays and objects deeper
+// than limit. It only tracks brackets outside strings and does not otherwise
+// validate the JSON.
+func checkJSONDepth(data []byte, limit int) error {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '[' || c == '{':
+			if depth++; depth > limit {
+				return fmt.Errorf("response nests deeper than %d levels", limit)
+			}
+		case c == ']' || c == '}':
+			depth--
+		}
 	}
-	lookup := make(map[key]triageAdjustment, len(adjustments))
+	return nil
+}
+
+// dedupeAdjustments collapses adjustments for the same finding, keeping the
+// order of first occurrence. Identical suggestions are dropped. When they
+// conflict, the most severe suggestion wins, ties going to the earliest, and
+// every distinct suggestion is recorded in Conflict.
+func dedupeAdjustments(adjustments []triageAdjustment) []triageAdjustment {
+	index := make(map[adjustmentKey]int, len(adjustments))
+	var unique []triageAdjustment
+	var seen [][]triageAdjustment
 	for _, a := range adjustments {
-		lookup[key{a.RuleID, a.File, int32(a.Line)}] = a
+		i, ok := index[a.key()]
+		if !ok {
+			index[a.key()] = len(unique)
+			unique = append(unique, a)
+			seen = append(seen, []triageAdjustment{a})
+			continue
+		}
+		if slices.ContainsFunc(seen[i], a.sameAs) {
+			continue
+		}
+		seen[i] = append(seen[i], a)
+		if moreSevere(a.AdjustedSeverity, unique[i].AdjustedSeverity) {
+			unique[i] = a
+		}
+	}
+
+	for i, suggestions := range seen {
+		if len(suggestions) < 2 {
+			continue
+		}
+		parts := make([]string, len(suggestions))
+		for j, s := range suggestions {
+			parts[j] = s.suggestion()
+		}
+		unique[i].Conflict = strings.Join(parts, "; ")
 	}
+	return unique
+}
 
+// moreSevere reports whether severity a ranks above b. Unknown severities
+// rank lowest.
+func moreSevere(a, b string) bool {
+	rank := func(s string) int {
+		sev := parseSeverity(s)
+		if sev == pluginv1.Severity(0) {
+			return math.MaxInt
+		}
+		return int(sev)
+	}
+	return rank(a) < rank(b)
+}
+
+// applyAdjustments modifies findings in-place based on LLM suggestions and
+// returns the findings it changed. Duplicate suggestions for a finding are
+// resolved by dedupeAdjustments. With cfg.ClassifyOnly, severity and priority
+// are left alone and the suggested values are recorded as
+// ai_suggested_severity and ai_suggested_priority for a person to act on. A
+// severity outside cfg.AllowedSeverities is recorded the same way, with
+// ai_severity_rejected saying why, and so is a priority that is not one of
+// cfg.PriorityLevels, with ai_priority_rejected. A nil cfg applies every
+// suggestion that names a default priority level.
+//
+// Triage is additive: only ai_ keys are written, except that an applied
+// verdict changes priority, recording the previous value in
+// ai_original_priority, and custom_severity, which follows the new severity
+// while ai_original_severity records the previous one. Every other key is
+// left as it was, so metadata from custom rules and other enrichment
+// survives triage.
+func applyAdjustments(findings []*pluginv1.Finding, adjustments []triageAdjustment, cfg *triageConfig) []*pluginv1.Finding {
+	classifyOnly := cfg != nil && cfg.ClassifyOnly
+	levels := cfg.priorityLevels()
+	lookup := make(map[adjustmentKey]triageAdjustment, len(adjustments))
+	for _, a := range dedupeAdjustments(adjustments) {
+		lookup[a.key()] = a
+	}
+
+	var adjusted []*pluginv1.Finding
 	for _, f := range findings {
 		file := ""
 		var line int32
@@ -157,10 +600,11 @@ func applyAdjustments(findings []*pluginv1.Finding, adjustments []triageAdjustme
 			line = f.GetLocation().GetStartLine()
 		}
 
-		adj, ok := lookup[key{f.GetRuleId(), file, line}]
+		adj, ok := lookup[adjustmentKey{f.GetRuleId(), file, line}]
 		if !ok {
 			continue
 		}
+		adjusted = append(adjusted, f)
 
 		if f.Metadata == nil {
 			f.Metadata = make(map[string]string)
@@ -168,19 +612,60 @@ func applyAdjustments(findings []*pluginv1.Finding, adjustments []triageAdjustme
 		f.Metadata["ai_triaged"] = "true"
 		f.Metadata["ai_classification"] = adj.Classification
 		f.Metadata["ai_triage_reason"] = adj.Reason
+		if adj.Conflict != "" {
+			f.Metadata["ai_triage_conflict"] = adj.Conflict
+		}
 
-		if sev := parseSeverity(adj.AdjustedSeverity); sev != pluginv1.Severity(0) {
-			f.Metadata["ai_original_severity"] = f.GetSeverity().String()
+		if classifyOnly {
+			if parseSeverity(adj.AdjustedSeverity) != pluginv1.Severity(0) {
+				f.Metadata["ai_suggested_severity"] = adj.AdjustedSeverity
+			}
+			if adj.AdjustedPriority != "" {
+				f.Metadata["ai_suggested_priority"] = adj.AdjustedPriority
+			}
+			continue
+		}
+		sev := parseSeverity(adj.AdjustedSeverity)
+		if sev != pluginv1.Severity(0) && !cfg.severityAllowed(adj.AdjustedSeverity) {
+			f.Metadata["ai_suggested_severity"] = adj.AdjustedSeverity
+			f.Metadata["ai_severity_rejected"] = fmt.Sprintf("%s is not in NOX_AI_ALLOWED_SEVERITIES", strings.ToLower(adj.AdjustedSeverity))
+			sev = pluginv1.Severity(0)
+		}
+		if sev != pluginv1.Severity(0) {
+			f.Metadata["ai_original_severity"] = severityLabel(f)
 			f.Severity = sev
+			setCustomSeverity(f, adj.AdjustedSeverity)
 		}
-		if adj.AdjustedPriority != "" {
+		if adj.AdjustedPriority != "" && !levels.valid(adj.AdjustedPriority) {
+			f.Metadata["ai_suggested_priority"] = adj.AdjustedPriority
+			f.Metadata["ai_priority_rejected"] = fmt.Sprintf("%s is not a priority level (%s)", adj.AdjustedPriority, levels)
+		} else if adj.AdjustedPriority != "" {
 			f.Metadata["ai_original_priority"] = f.Metadata["priority"]
-			f.Metadata["priority"] = adj.AdjustedPriority
+			f.Metadata["priority"] = levels.canonical(adj.AdjustedPriority)
+		}
+	}
+	return adjusted
+}
+
+// unmatchedAdjustments counts the distinct adjustments that refer to no
+// finding in findings, which usually means the model invented a location.
+func unmatchedAdjustments(findings []*pluginv1.Finding, adjustments []triageAdjustment) int {
+	known := make(map[adjustmentKey]bool, len(findings))
+	for _, f := range findings {
+		file, line := findingLocation(f)
+		known[adjustmentKey{f.GetRuleId(), file, line}] = true
+	}
+	n := 0
+	for _, a := range dedupeAdjustments(adjustments) {
+		if !known[a.key()] {
+			n++
 		}
 	}
+	return n
 }
 
-// markTriageError adds ai_triage_error metadata to all findings when LLM triage fails.
+// markTriageError adds ai_triage_error metadata to all findings when LLM
+// triage fails, leaving their other metadata untouched.
 func markTriageError(findings []*pluginv1.Finding, errMsg string) {
 	for _, f := range findings {
 		if f.Metadata == nil {
@@ -191,6 +676,7 @@ func markTriageError(findings []*pluginv1.Finding, errMsg string) {
 }
 
 // parseSeverity converts a severity string to the protobuf enum value.
+// Custom severity labels map onto their nearest standard severity.
 func parseSeverity(s string) pluginv1.Severity {
 	switch strings.ToLower(s) {
 	case "critical":
@@ -204,6 +690,6 @@ func parseSeverity(s string) pluginv1.Severity {
 	case "info":
 		return sdk.SeverityInfo
 	default:
-		return pluginv1.Severity(0)
+		return customSeverities[strings.ToLower(s)]
 	}
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
+	"strings"
 	"testing"
 
 	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
@@ -33,6 +36,16 @@ func (m *mockProvider) Complete(_ context.Context, _ plannerllm.CompletionReques
 
 func (m *mockProvider) Name() string { return "mock" }
 
+// funcProvider adapts a function to plannerllm.Provider so tests can vary the
+// response per call.
+type funcProvider func(ctx context.Context, req plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error)
+
+func (p funcProvider) Complete(ctx context.Context, req plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+	return p(ctx, req)
+}
+
+func (p funcProvider) Name() string { return "func" }
+
 func TestAITriageAdjustsSeverity(t *testing.T) {
 	findings := []*pluginv1.Finding{
 		{
@@ -59,7 +72,7 @@ func TestAITriageAdjustsSeverity(t *testing.T) {
 	respJSON, _ := json.Marshal(adjustments)
 
 	provider := &mockProvider{response: string(respJSON)}
-	aiTriageFindings(context.Background(), provider, "mock-model", findings)
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, nil)
 
 	f := findings[0]
 	if f.GetSeverity() != sdk.SeverityCritical {
@@ -105,7 +118,7 @@ func TestAITriageLowersSeverity(t *testing.T) {
 	respJSON, _ := json.Marshal(adjustments)
 
 	provider := &mockProvider{response: string(respJSON)}
-	aiTriageFindings(context.Background(), provider, "mock-model", findings)
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, nil)
 
 	f := findings[0]
 	if f.GetSeverity() != sdk.SeverityLow {
@@ -130,7 +143,7 @@ func TestAITriageGracefulDegradation(t *testing.T) {
 	}
 
 	provider := &mockProvider{err: errors.New("connection refused")}
-	aiTriageFindings(context.Background(), provider, "mock-model", findings)
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, nil)
 
 	f := findings[0]
 	if f.GetSeverity() != sdk.SeverityHigh {
@@ -152,7 +165,7 @@ func TestAITriageMalformedResponse(t *testing.T) {
 	}
 
 	provider := &mockProvider{response: "this is not valid JSON"}
-	aiTriageFindings(context.Background(), provider, "mock-model", findings)
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, nil)
 
 	f := findings[0]
 	if f.GetSeverity() != sdk.SeverityHigh {
@@ -165,7 +178,7 @@ func TestAITriageMalformedResponse(t *testing.T) {
 
 func TestAITriageEmptyFindings(t *testing.T) {
 	provider := &mockProvider{err: errors.New("should not be called")}
-	aiTriageFindings(context.Background(), provider, "mock-model", nil)
+	aiTriageFindings(context.Background(), provider, "mock-model", nil, nil)
 	// Should return immediately without calling provider.
 }
 
@@ -195,7 +208,7 @@ func TestAITriageMarkdownCodeFences(t *testing.T) {
 	wrapped := "```json\n" + string(inner) + "\n```"
 
 	provider := &mockProvider{response: wrapped}
-	aiTriageFindings(context.Background(), provider, "mock-model", findings)
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, nil)
 
 	f := findings[0]
 	if f.GetSeverity() != sdk.SeverityInfo {
@@ -206,6 +219,106 @@ func TestAITriageMarkdownCodeFences(t *testing.T) {
 	}
 }
 
+func TestAITriageCustomSeverity(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{
+			RuleId:   "TRIAGE-001",
+			Severity: sdk.SeverityHigh,
+			Message:  "eval() with user input",
+			Location: &pluginv1.Location{FilePath: "app.py", StartLine: 7},
+			Metadata: map[string]string{"priority": "immediate"},
+		},
+	}
+
+	respJSON, _ := json.Marshal([]triageAdjustment{{
+		RuleID:           "TRIAGE-001",
+		File:             "app.py",
+		Line:             7,
+		AdjustedSeverity: "blocker",
+		Classification:   "true_positive",
+		Reason:           "remote code execution",
+	}})
+	aiTriageFindings(context.Background(), &mockProvider{response: string(respJSON)}, "mock-model", findings, nil)
+
+	f := findings[0]
+	if f.GetSeverity() != sdk.SeverityCritical {
+		t.Errorf("expected blocker to map to CRITICAL, got %v", f.GetSeverity())
+	}
+	if f.Metadata["custom_severity"] != "blocker" {
+		t.Errorf("expected custom_severity=blocker, got %q", f.Metadata["custom_severity"])
+	}
+
+	// A later standard adjustment clears the custom label and records it as
+	// the original severity.
+	respJSON, _ = json.Marshal([]triageAdjustment{{
+		RuleID:           "TRIAGE-001",
+		File:             "app.py",
+		Line:             7,
+		AdjustedSeverity: "medium",
+		Classification:   "needs_review",
+		Reason:           "input is partially sanitized",
+	}})
+	aiTriageFindings(context.Background(), &mockProvider{response: string(respJSON)}, "mock-model", findings, nil)
+
+	if _, ok := f.Metadata["custom_severity"]; ok {
+		t.Error("expected custom_severity to be cleared by a standard adjustment")
+	}
+	if f.Metadata["ai_original_severity"] != "blocker" {
+		t.Errorf("expected ai_original_severity=blocker, got %q", f.Metadata["ai_original_severity"])
+	}
+}
+
+func TestAITriagePartialResultsOnDeadline(t *testing.T) {
+	findings := make([]*pluginv1.Finding, 3)
+	for i := range findings {
+		findings[i] = &pluginv1.Finding{
+			RuleId:   "TRIAGE-002",
+			Severity: sdk.SeverityMedium,
+			Location: &pluginv1.Location{FilePath: "api.py", StartLine: int32(i + 1)},
+			Metadata: map[string]string{"priority": "scheduled"},
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	provider := funcProvider(func(ctx context.Context, _ plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+		calls++
+		if calls > 1 {
+			// Simulate the overall deadline hitting during the second batch.
+			cancel()
+			return plannerllm.CompletionResponse{}, ctx.Err()
+		}
+		data, _ := json.Marshal([]triageAdjustment{{
+			RuleID:           "TRIAGE-002",
+			File:             "api.py",
+			Line:             1,
+			AdjustedSeverity: "low",
+			Classification:   "false_positive",
+			Reason:           "validated upstream",
+		}})
+		return plannerllm.CompletionResponse{Message: plannerllm.Message{Content: string(data)}}, nil
+	})
+
+	aiTriageFindings(ctx, provider, "mock-model", findings, &triageConfig{BatchSize: 1})
+
+	if calls != 2 {
+		t.Errorf("expected 2 provider calls, got %d", calls)
+	}
+	if findings[0].Metadata["ai_triaged"] != "true" || findings[0].GetSeverity() != sdk.SeverityLow {
+		t.Error("expected the completed first batch to keep its adjustments")
+	}
+	for _, f := range findings[1:] {
+		if f.Metadata["ai_triage_error"] == "" {
+			t.Errorf("expected ai_triage_error on un-triaged finding at line %d", f.GetLocation().GetStartLine())
+		}
+		if f.GetSeverity() != sdk.SeverityMedium {
+			t.Errorf("un-triaged finding severity changed to %v", f.GetSeverity())
+		}
+	}
+}
+
 func TestParseTriageResponseValid(t *testing.T) {
 	input := `[{"rule_id":"TRIAGE-001","file":"a.py","line":1,"adjusted_severity":"high","adjusted_priority":"immediate","classification":"true_positive","reason":"test"}]`
 	adj, err := parseTriageResponse(input)
@@ -237,6 +350,8 @@ func TestParseSeverity(t *testing.T) {
 		{"MEDIUM", sdk.SeverityMedium},
 		{"Low", sdk.SeverityLow},
 		{"INFO", sdk.SeverityInfo},
+		{"blocker", sdk.SeverityCritical},
+		{"Trivial", sdk.SeverityInfo},
 		{"unknown", pluginv1.Severity(0)},
 	}
 	for _, tt := range tests {
@@ -246,3 +361,262 @@ func TestParseSeverity(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyAdjustmentsDuplicates(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1}},
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 2}},
+	}
+	adjustments := []triageAdjustment{
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 1, AdjustedSeverity: "low", Classification: "false_positive", Reason: "first"},
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 1, AdjustedSeverity: "LOW", Classification: "false_positive", Reason: "repeat"},
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 2, AdjustedSeverity: "low", Classification: "false_positive"},
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 2, AdjustedSeverity: "high", Classification: "true_positive"},
+	}
+
+	applyAdjustments(findings, adjustments, nil)
+
+	if findings[0].GetSeverity() != sdk.SeverityLow || findings[0].Metadata["ai_triage_reason"] != "first" {
+		t.Errorf("identical duplicates should keep the first, got %v", findings[0].Metadata)
+	}
+	if _, ok := findings[0].Metadata["ai_triage_conflict"]; ok {
+		t.Error("identical duplicates are not a conflict")
+	}
+	if findings[1].GetSeverity() != sdk.SeverityHigh {
+		t.Errorf("conflict should resolve to the most severe suggestion, got %v", findings[1].GetSeverity())
+	}
+	if got := findings[1].Metadata["ai_triage_conflict"]; got != "low//false_positive; high//true_positive" {
+		t.Errorf("unexpected ai_triage_conflict %q", got)
+	}
+}
+
+func TestApplyAdjustmentsClassifyOnly(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1},
+			Metadata: map[string]string{"priority": "scheduled"}},
+	}
+	adjustments := []triageAdjustment{
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 1, AdjustedSeverity: "low", AdjustedPriority: "backlog", Classification: "false_positive", Reason: "test code"},
+	}
+
+	applyAdjustments(findings, adjustments, &triageConfig{ClassifyOnly: true})
+
+	md := findings[0].GetMetadata()
+	if findings[0].GetSeverity() != sdk.SeverityMedium || md["priority"] != "scheduled" {
+		t.Errorf("classify_only should leave severity and priority alone, got %v %v", findings[0].GetSeverity(), md["priority"])
+	}
+	if md["ai_classification"] != "false_positive" || md["ai_triage_reason"] != "test code" {
+		t.Errorf("classification should be recorded, got %v", md)
+	}
+	if md["ai_suggested_severity"] != "low" || md["ai_suggested_priority"] != "backlog" {
+		t.Errorf("suggested changes should be recorded, got %v", md)
+	}
+	if _, ok := md["ai_original_severity"]; ok {
+		t.Error("nothing was changed, so there is no original severity")
+	}
+}
+
+// TestTriageMetadataIsAdditive pins the metadata contract of the triage
+// phase: whatever path a finding takes through it, keys outside ai_ are kept
+// as they were, except that priority and custom_severity may change once
+// their previous values are recorded in ai_original_priority and
+// ai_original_severity.
+func TestTriageMetadataIsAdditive(t *testing.T) {
+	enriched := func() *pluginv1.Finding {
+		return &pluginv1.Finding{
+			RuleId:      "TRIAGE-002",
+			Severity:    sdk.SeverityCritical,
+			Fingerprint: "fp1",
+			Location:    &pluginv1.Location{FilePath: "a.py", StartLine: 1},
+			Metadata: map[string]string{
+				"priority":        "scheduled",
+				"custom_severity": "blocker",
+				"language":        "python",
+				"scan_run_id":     "run-1",
+				"due_date":        "2026-03-08T12:00:00Z",
+				"adjusted_by":     "path",
+				"team":            "payments",
+				"ticket":          "SEC-42",
+			},
+		}
+	}
+	verdict := []triageAdjustment{
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 1, AdjustedSeverity: "low", AdjustedPriority: "backlog", Classification: "false_positive", Reason: "test code"},
+	}
+	cached := enriched()
+	cached.Severity = sdk.SeverityLow
+	cached.Metadata = map[string]string{
+		"priority": "backlog", "ai_triaged": "true", "ai_classification": "false_positive",
+		"ai_original_severity": "blocker", "ai_original_priority": "scheduled",
+	}
+	baseline := map[string]baselineEntry{"fp1": {Fingerprint: "fp1", Finding: cached}}
+
+	cases := []struct {
+		name    string
+		triage  func(f *pluginv1.Finding)
+		changes bool // whether priority and custom_severity change
+	}{
+		{"applied", func(f *pluginv1.Finding) {
+			applyAdjustments([]*pluginv1.Finding{f}, verdict, nil)
+		}, true},
+		{"classify_only", func(f *pluginv1.Finding) {
+			applyAdjustments([]*pluginv1.Finding{f}, verdict, &triageConfig{ClassifyOnly: true})
+		}, false},
+		{"rejected", func(f *pluginv1.Finding) {
+			cfg := newTriageConfig(settings{"NOX_AI_ALLOWED_SEVERITIES": "critical"})
+			cfg.PriorityLevels = priorityLevels{"p0", "p1"}
+			applyAdjustments([]*pluginv1.Finding{f}, verdict, cfg)
+		}, false},
+		{"error", func(f *pluginv1.Finding) {
+			markTriageError([]*pluginv1.Finding{f}, "provider unavailable")
+		}, false},
+		{"cached", func(f *pluginv1.Finding) {
+			reuseCachedVerdicts([]*pluginv1.Finding{f}, baseline, false)
+		}, true},
+	}
+	for _, c := range cases {
+		f := enriched()
+		before := maps.Clone(f.GetMetadata())
+		c.triage(f)
+		after := f.GetMetadata()
+
+		for key, was := range before {
+			switch now, ok := after[key]; {
+			case key == "priority" && c.changes:
+				if now != "backlog" || after["ai_original_priority"] != was {
+					t.Errorf("%s: priority %q -> %q, ai_original_priority %q", c.name, was, now, after["ai_original_priority"])
+				}
+			case key == "custom_severity" && c.changes:
+				if ok || after["ai_original_severity"] != was {
+					t.Errorf("%s: custom_severity %q -> %q, ai_original_severity %q", c.name, was, now, after["ai_original_severity"])
+				}
+			case !ok || now != was:
+				t.Errorf("%s: %s changed from %q to %q (present %v)", c.name, key, was, now, ok)
+			}
+		}
+		for key := range after {
+			if _, ok := before[key]; !ok && !strings.HasPrefix(key, "ai_") {
+				t.Errorf("%s: triage added non-ai key %s", c.name, key)
+			}
+		}
+	}
+}
+
+func TestApplyAdjustmentsAllowedSeverities(t *testing.T) {
+	cfg := newTriageConfig(settings{"NOX_AI_ALLOWED_SEVERITIES": "low, Medium, bogus"})
+	if len(cfg.AllowedSeverities) != 2 {
+		t.Fatalf("expected the two known severities, got %v", cfg.AllowedSeverities)
+	}
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1},
+			Metadata: map[string]string{"priority": "scheduled"}},
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 2}},
+	}
+	adjustments := []triageAdjustment{
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 1, AdjustedSeverity: "critical", AdjustedPriority: "immediate", Classification: "true_positive"},
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 2, AdjustedSeverity: "low", Classification: "false_positive"},
+	}
+
+	applyAdjustments(findings, adjustments, cfg)
+
+	md := findings[0].GetMetadata()
+	if findings[0].GetSeverity() != sdk.SeverityMedium {
+		t.Errorf("a disallowed severity should not be applied, got %v", findings[0].GetSeverity())
+	}
+	if md["ai_suggested_severity"] != "critical" || !strings.Contains(md["ai_severity_rejected"], "critical") {
+		t.Errorf("the rejected suggestion should be recorded, got %v", md)
+	}
+	if md["priority"] != "immediate" {
+		t.Errorf("the priority change is still applied, got %q", md["priority"])
+	}
+	if findings[1].GetSeverity() != sdk.SeverityLow {
+		t.Errorf("an allowed severity should be applied, got %v", findings[1].GetSeverity())
+	}
+}
+
+func TestDedupeAdjustmentsTieKeepsFirst(t *testing.T) {
+	got := dedupeAdjustments([]triageAdjustment{
+		{RuleID: "TRIAGE-001", File: "a.py", Line: 1, AdjustedSeverity: "critical", Classification: "true_positive"},
+		{RuleID: "TRIAGE-001", File: "a.py", Line: 1, AdjustedSeverity: "blocker", Classification: "true_positive"},
+	})
+	if len(got) != 1 || got[0].AdjustedSeverity != "critical" || got[0].Conflict == "" {
+		t.Errorf("expected the first of two equally severe suggestions with a conflict, got %+v", got)
+	}
+}
+
+func TestParseTriageResponseLimits(t *testing.T) {
+	tests := map[string]string{
+		"too large":     "[" + strings.Repeat(" ", maxTriageResponseBytes) + "]",
+		"too deep":      `[{"rule_id":"TRIAGE-001","extra":` + strings.Repeat("[", 20) + strings.Repeat("]", 20) + `}]`,
+		"trailing data": `[] {"rule_id":"TRIAGE-001"}`,
+	}
+	for name, content := range tests {
+		if _, err := parseTriageResponse(content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Brackets inside strings do not count towards the depth.
+	reason := strings.Repeat("[{", 20)
+	adj, err := parseTriageResponse(`[{"rule_id":"TRIAGE-001","reason":"` + reason + `\"]"}]`)
+	if err != nil || len(adj) != 1 || adj[0].Reason != reason+`"]` {
+		t.Errorf("unexpected result %+v, %v", adj, err)
+	}
+}
+
+func TestAITriageOversizedResponseMarksError(t *testing.T) {
+	findings := []*pluginv1.Finding{{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh}}
+	provider := &mockProvider{response: strings.Repeat("[", 100) + strings.Repeat("]", 100)}
+
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, nil)
+
+	if !strings.Contains(findings[0].GetMetadata()["ai_triage_error"], "deeper than") {
+		t.Errorf("expected a depth error, got %v", findings[0].GetMetadata())
+	}
+}
+
+func TestTriageBatchesGrouping(t *testing.T) {
+	var findings []*pluginv1.Finding
+	for i, file := range []string{"a.py", "b.py", "a.py", "c.py", "b.py", "a.py"} {
+		findings = append(findings, &pluginv1.Finding{
+			RuleId:   fmt.Sprintf("TRIAGE-00%d", i%2+1),
+			Location: &pluginv1.Location{FilePath: file, StartLine: int32(i + 1)},
+		})
+	}
+	files := func(batches [][]*pluginv1.Finding) string {
+		var parts []string
+		for _, b := range batches {
+			var names []string
+			for _, f := range b {
+				names = append(names, strings.TrimSuffix(f.GetLocation().GetFilePath(), ".py"))
+			}
+			parts = append(parts, strings.Join(names, ""))
+		}
+		return strings.Join(parts, "|")
+	}
+
+	tests := []struct {
+		grouping  string
+		batchSize int
+		want      string
+	}{
+		{groupByCount, 4, "abac|ba"},
+		{groupByFile, 3, "aaa|bbc"},
+		{groupByFile, 2, "aa|a|bb|c"},
+		{groupByRule, 3, "aab|bca"},
+	}
+	for _, tt := range tests {
+		if got := files(triageBatches(findings, tt.batchSize, tt.grouping)); got != tt.want {
+			t.Errorf("%s/%d: got %s, want %s", tt.grouping, tt.batchSize, got, tt.want)
+		}
+	}
+}
+
+func TestTriageGroupingFallback(t *testing.T) {
+	if got := newTriageConfig(settings{"NOX_AI_GROUPING": "File"}).Grouping; got != groupByFile {
+		t.Errorf("expected file grouping, got %q", got)
+	}
+	if got := newTriageConfig(settings{"NOX_AI_GROUPING": "random"}).Grouping; got != groupByCount {
+		t.Errorf("unknown grouping should fall back to count, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// pathAliases replaces file paths with opaque tokens (file1, file2, ...) in
+// the prompt sent to the LLM when NOX_AI_ANONYMIZE_PATHS is set, so paths
+// that name products or customers never leave the machine. A nil
+// pathAliases leaves paths as they are.
+type pathAliases struct {
+	tokens map[string]string // real path -> token
+	paths  map[string]string // token -> real path
+}
+
+// newPathAliases assigns a token to each distinct file path in findings, in
+// order of first appearance.
+func newPathAliases(findings []*pluginv1.Finding) *pathAliases {
+	a := &pathAliases{
+		tokens: make(map[string]string),
+		paths:  make(map[string]string),
+	}
+	for _, f := range findings {
+		file, _ := findingLocation(f)
+		if _, ok := a.tokens[file]; ok || file == "" {
+			continue
+		}
+		token := fmt.Sprintf("file%d", len(a.tokens)+1)
+		a.tokens[file] = token
+		a.paths[token] = file
+	}
+	return a
+}
+
+// token returns the alias sent to the LLM for path.
+func (a *pathAliases) token(path string) string {
+	if a == nil {
+		return path
+	}
+	if t, ok := a.tokens[path]; ok {
+		return t
+	}
+	return path
+}
+
+// restore rewrites the file of each adjustment from its token back to the
+// real path. Files that are not known tokens are left alone and will match
+// no finding.
+func (a *pathAliases) restore(adjustments []triageAdjustment) {
+	if a == nil {
+		return
+	}
+	for i := range adjustments {
+		adjustments[i] = a.restoreOne(adjustments[i])
+	}
+}
+
+// restoreOne is restore for a single adjustment.
+func (a *pathAliases) restoreOne(adj triageAdjustment) triageAdjustment {
+	if a == nil {
+		return adj
+	}
+	if p, ok := a.paths[adj.File]; ok {
+		adj.File = p
+	}
+	return adj
+}
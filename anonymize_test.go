@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+func TestAnonymizePaths(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{
+			RuleId:   "TRIAGE-001",
+			Severity: sdk.SeverityHigh,
+			Location: &pluginv1.Location{FilePath: "/src/acme-billing/customers/globex/app.py", StartLine: 7},
+		},
+		{
+			RuleId:   "TRIAGE-002",
+			Severity: sdk.SeverityMedium,
+			Location: &pluginv1.Location{FilePath: "/src/acme-billing/api.py", StartLine: 3},
+		},
+		{
+			RuleId:   "TRIAGE-003",
+			Severity: sdk.SeverityLow,
+			Location: &pluginv1.Location{FilePath: "/src/acme-billing/customers/globex/app.py", StartLine: 9},
+		},
+	}
+
+	var prompt string
+	provider := funcProvider(func(_ context.Context, req plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+		prompt = req.Messages[1].Content
+		resp, _ := json.Marshal([]triageAdjustment{
+			{RuleID: "TRIAGE-001", File: "file1", Line: 7, AdjustedSeverity: "critical", Classification: "true_positive"},
+			{RuleID: "TRIAGE-002", File: "file2", Line: 3, AdjustedSeverity: "low", Classification: "false_positive"},
+			{RuleID: "TRIAGE-003", File: "file3", Line: 9, AdjustedSeverity: "info", Classification: "false_positive"},
+		})
+		return plannerllm.CompletionResponse{Message: plannerllm.Message{Content: string(resp)}}, nil
+	})
+	stats := aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{BatchSize: 10, AnonymizePaths: true})
+
+	for _, leak := range []string{"acme", "globex", "customers", ".py"} {
+		if strings.Contains(prompt, leak) {
+			t.Errorf("prompt leaks %q:\n%s", leak, prompt)
+		}
+	}
+	if !strings.Contains(prompt, `"file": "file1"`) || !strings.Contains(prompt, `"file": "file2"`) {
+		t.Errorf("expected tokens in the prompt:\n%s", prompt)
+	}
+	if got := findings[0].GetSeverity(); got != sdk.SeverityCritical {
+		t.Errorf("expected the file1 adjustment to reach app.py, got %v", got)
+	}
+	if got := findings[1].GetSeverity(); got != sdk.SeverityLow {
+		t.Errorf("expected the file2 adjustment to reach api.py, got %v", got)
+	}
+	if got := findings[2].GetSeverity(); got != sdk.SeverityLow {
+		t.Errorf("file3 was never sent, so its adjustment should not apply; got %v", got)
+	}
+	if stats.Unmatched != 1 {
+		t.Errorf("expected 1 unmatched adjustment, got %d", stats.Unmatched)
+	}
+	if got := findings[0].GetLocation().GetFilePath(); got != "/src/acme-billing/customers/globex/app.py" {
+		t.Errorf("finding path should be unchanged, got %q", got)
+	}
+}
+
+func TestAnonymizePathsStreaming(t *testing.T) {
+	findings := streamFindings(2)
+	adj, _ := json.Marshal(triageAdjustment{RuleID: "TRIAGE-002", File: "file1", Line: 2, AdjustedSeverity: "low", Classification: "false_positive"})
+	provider := &streamProvider{chunks: []string{"[", string(adj), "]"}}
+
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{BatchSize: 10, Stream: true, AnonymizePaths: true})
+
+	if got := findings[1].GetSeverity(); got != sdk.SeverityLow {
+		t.Errorf("expected the streamed adjustment to be translated back to api.py, got %v", got)
+	}
+	if findings[0].GetMetadata()["ai_triaged"] == "true" {
+		t.Error("only the finding on line 2 should be triaged")
+	}
+}
+
+func TestNilPathAliases(t *testing.T) {
+	var a *pathAliases
+	if got := a.token("app.py"); got != "app.py" {
+		t.Errorf("nil aliases should leave paths alone, got %q", got)
+	}
+	adjustments := []triageAdjustment{{File: "file1"}}
+	a.restore(adjustments)
+	if adjustments[0].File != "file1" {
+		t.Errorf("nil aliases should not rewrite adjustments, got %q", adjustments[0].File)
+	}
+}
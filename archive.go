@@ -0,0 +1,127 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/sdk"
+)
+
+// archiveExtensions are the zip-based artifact formats scan_archives opens:
+// Java archives and Python wheels and eggs.
+var archiveExtensions = map[string]bool{
+	".jar": true,
+	".whl": true,
+	".egg": true,
+}
+
+// maxArchiveEntryBytes bounds the uncompressed size of an archive entry that
+// is scanned, so a small archive cannot expand into an unbounded read.
+const maxArchiveEntryBytes = 16 << 20
+
+// archiveSeparator joins an archive path and the path of an entry inside it
+// in finding locations, as in Java's jar: URLs.
+const archiveSeparator = "!/"
+
+// isArchive reports whether name has an extension scan_archives opens.
+func isArchive(name string) bool {
+	return archiveExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// archiveEntryLanguage returns the extension key of the pattern set for an
+// archive entry, or "" for class files, binaries, and other entries that are
+// not source. Entries cannot be sniffed by shebang, so only language_map and
+// the extension are consulted.
+func archiveEntryLanguage(rel string, mappings []languageMapping) string {
+	for _, m := range mappings {
+		if matchPathGlob(m.Pattern, rel) {
+			return m.Ext
+		}
+	}
+	if ext := path.Ext(rel); supportedExtensions[ext] || configExtensions[ext] {
+		return ext
+	}
+	if env := envFileExt(rel); env != "" {
+		return env
+	}
+	return ""
+}
+
+// scanArchive scans the source entries of a zip-based archive without
+// extracting it. Findings are reported at <archive>!/<entry>. Entries that
+// cannot be read are passed to skip and oversized entries are logged and
+// skipped; nested archives are not opened.
+func scanArchive(resp *sdk.ResponseBuilder, filePath string, opts *scanOptions, skip func(display string, err error) error) error {
+	relPath, err := filepath.Rel(opts.WorkspaceRoot, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+	relPath = filepath.ToSlash(relPath)
+	findingPath := filePath
+	if opts.RootName != "" {
+		relPath = path.Join(opts.RootName, relPath)
+		findingPath = relPath
+	}
+
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zr.Close() }()
+
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		entryRel := relPath + archiveSeparator + entry.Name
+		ext := opts.ForceLanguage
+		if ext == "" {
+			ext = archiveEntryLanguage(entryRel, opts.LanguageMap)
+		}
+		if ext == "" {
+			continue
+		}
+		if entry.UncompressedSize64 > maxArchiveEntryBytes {
+			log.Printf("triage: skipping %s: larger than %d bytes", entryRel, maxArchiveEntryBytes)
+			continue
+		}
+		if opts.AddedLines != nil && !opts.AddedLines.hasFile(entryRel) {
+			continue
+		}
+		if err := scanArchiveEntry(resp, entry, findingPath+archiveSeparator+entry.Name, entryRel, ext, opts); err != nil {
+			if err := skip(entryRel, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanArchiveEntry decodes and scans one archive entry.
+func scanArchiveEntry(resp *sdk.ResponseBuilder, entry *zip.File, findingPath, relPath, ext string, opts *scanOptions) error {
+	rc, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	// The size in the central directory is not trusted: the read stops one
+	// byte past the limit so a lying header is caught.
+	lr := &io.LimitedReader{R: rc, N: maxArchiveEntryBytes + 1}
+	src, err := decodeSource(lr, opts.Encoding)
+	if err != nil {
+		return err
+	}
+	if err := scanSource(resp, src, findingPath, relPath, ext, opts); err != nil {
+		return err
+	}
+	if lr.N == 0 {
+		return fmt.Errorf("entry exceeds %d bytes", maxArchiveEntryBytes)
+	}
+	return nil
+}
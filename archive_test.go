@@ -0,0 +1,102 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeZip creates a zip archive at name holding the given entries.
+func writeZip(t *testing.T, name string, entries map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for entry, content := range entries {
+		w, err := zw.Create(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanArchives(t *testing.T) {
+	root := t.TempDir()
+	writeZip(t, filepath.Join(root, "wheels", "tool-1.0-py3-none-any.whl"), map[string]string{
+		"tool/__init__.py":              "result = eval(user_input)\n",
+		"tool-1.0.dist-info/METADATA":   "eval(x)\n",
+		"tool/_speedups.cpython-312.so": "eval(x)\n",
+	})
+	writeZip(t, filepath.Join(root, "lib", "app.jar"), map[string]string{
+		"META-INF/MANIFEST.MF":  "Manifest-Version: 1.0\n",
+		"com/example/App.class": "eval(x)\n",
+		"static/app.js":         "eval(userInput)\n",
+	})
+	writeFile(t, filepath.Join(root, "lib", "notes.zip"), "eval(x)\n")
+
+	client := testClient(t)
+
+	resp := invokeScan(t, client, root)
+	if n := len(resp.GetFindings()); n != 0 {
+		t.Fatalf("archives should not be opened without scan_archives, got %d finding(s)", n)
+	}
+
+	resp = invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"scan_archives":  true,
+	})
+	files := make(map[string]int)
+	for _, f := range resp.GetFindings() {
+		rel, _ := filepath.Rel(root, f.GetLocation().GetFilePath())
+		files[filepath.ToSlash(rel)]++
+	}
+	want := []string{"wheels/tool-1.0-py3-none-any.whl!/tool/__init__.py", "lib/app.jar!/static/app.js"}
+	if len(files) != len(want) {
+		t.Errorf("expected findings only in source entries, got %v", files)
+	}
+	for _, name := range want {
+		if files[name] != 1 {
+			t.Errorf("expected 1 finding in %s, got %v", name, files)
+		}
+	}
+}
+
+func TestScanArchivesCorrupt(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "broken.egg"), "not a zip archive")
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"scan_archives":  true,
+	})
+	if n := len(resp.GetFindings()); n != 1 {
+		t.Errorf("expected the scan to continue past a corrupt archive, got %d finding(s)", n)
+	}
+	var warned bool
+	for _, d := range resp.GetDiagnostics() {
+		if strings.Contains(d.GetMessage(), "broken.egg") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Error("expected a diagnostic naming the corrupt archive")
+	}
+}
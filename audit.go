@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+// minAuditSecretLen is the shortest credential redacted from audit records;
+// shorter values would blank out ordinary text.
+const minAuditSecretLen = 8
+
+// auditLog records every LLM call made during AI triage to its own file in
+// <NOX_AI_AUDIT_DIR>/<scan run ID>/, as an audit trail of what was sent to
+// and received from the model. A nil auditLog records nothing.
+type auditLog struct {
+	dir     string
+	runID   string
+	secrets []string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// auditRecord is the JSON document written per LLM call.
+type auditRecord struct {
+	RunID        string `json:"run_id"`
+	Timestamp    string `json:"timestamp"`
+	Batch        int    `json:"batch"`
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	SystemPrompt string `json:"system_prompt"`
+	UserMessage  string `json:"user_message"`
+	Response     string `json:"response"`
+	Error        string `json:"error,omitempty"`
+}
+
+// newAuditLog returns an audit log writing below dir for the given run, or
+// nil when dir is empty. The provider credentials and custom header values
+// from s are redacted from everything written.
+func newAuditLog(dir, runID string, s settings) *auditLog {
+	if dir == "" {
+		return nil
+	}
+	a := &auditLog{dir: filepath.Join(dir, runID), runID: runID}
+	for _, name := range []string{"NOX_AI_API_KEY", "GITHUB_TOKEN"} {
+		a.addSecret(s.get(name))
+	}
+	if header, err := parseHeaders(s.get("NOX_AI_HEADERS")); err == nil {
+		for _, values := range header {
+			for _, v := range values {
+				a.addSecret(v)
+			}
+		}
+	}
+	return a
+}
+
+// addSecret adds a value to redact.
+func (a *auditLog) addSecret(v string) {
+	if len(v) >= minAuditSecretLen {
+		a.secrets = append(a.secrets, v)
+	}
+}
+
+// redact masks every credential in text.
+func (a *auditLog) redact(text string) string {
+	for _, secret := range a.secrets {
+		text = strings.ReplaceAll(text, secret, "[REDACTED]")
+	}
+	return text
+}
+
+// record writes one LLM call. Failures to write are logged and otherwise
+// ignored, so auditing never changes the triage result.
+func (a *auditLog) record(provider string, req plannerllm.CompletionRequest, response string, callErr error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.seq++
+	seq := a.seq
+	a.mu.Unlock()
+
+	now := time.Now().UTC()
+	rec := auditRecord{
+		RunID:     a.runID,
+		Timestamp: now.Format(time.RFC3339Nano),
+		Batch:     seq,
+		Provider:  provider,
+		Model:     req.Model,
+		Response:  a.redact(response),
+	}
+	for _, m := range req.Messages {
+		switch m.Role {
+		case "system":
+			rec.SystemPrompt = a.redact(m.Content)
+		case "user":
+			rec.UserMessage = a.redact(m.Content)
+		}
+	}
+	if callErr != nil {
+		rec.Error = a.redact(callErr.Error())
+	}
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err == nil {
+		// Records hold source excerpts, so they are readable by the owner only.
+		if err = os.MkdirAll(a.dir, 0o700); err == nil {
+			name := fmt.Sprintf("%s-batch-%03d.json", now.Format("20060102T150405Z"), seq)
+			err = os.WriteFile(filepath.Join(a.dir, name), data, 0o600)
+		}
+	}
+	if err != nil {
+		log.Printf("ai_triage: writing audit record: %v", err)
+	}
+}
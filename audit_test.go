@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+func TestAuditLogRecordsEachBatch(t *testing.T) {
+	dir := t.TempDir()
+	const key = "sk-test-0123456789"
+	audit := newAuditLog(dir, "run-1", settings{"NOX_AI_API_KEY": key, "NOX_AI_HEADERS": "X-Tenant=tenant-secret-42"})
+
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Message: "token " + key, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1}},
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Message: "tenant-secret-42", Location: &pluginv1.Location{FilePath: "b.py", StartLine: 1}},
+	}
+	provider := &mockProvider{response: `[{"rule_id":"TRIAGE-001","file":"a.py","line":1,"classification":"true_positive","reason":"uses ` + key + `"}]`}
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{BatchSize: 1, Audit: audit})
+
+	entries, err := os.ReadDir(filepath.Join(dir, "run-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected one record per batch, got %d", len(entries))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "run-1", entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), key) {
+		t.Errorf("API key should be redacted: %s", data)
+	}
+	var rec auditRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.RunID != "run-1" || rec.Batch != 1 || rec.Provider != "mock" || rec.Model != "mock-model" {
+		t.Errorf("unexpected record header %+v", rec)
+	}
+	if rec.SystemPrompt != triageSystemPrompt {
+		t.Error("expected the system prompt to be recorded")
+	}
+	if !strings.Contains(rec.UserMessage, "[REDACTED]") || !strings.Contains(rec.Response, "[REDACTED]") {
+		t.Errorf("expected redacted message and response, got %q / %q", rec.UserMessage, rec.Response)
+	}
+
+	data, _ = os.ReadFile(filepath.Join(dir, "run-1", entries[1].Name()))
+	if strings.Contains(string(data), "tenant-secret-42") {
+		t.Errorf("header values should be redacted: %s", data)
+	}
+}
+
+func TestAuditLogRecordsErrors(t *testing.T) {
+	dir := t.TempDir()
+	audit := newAuditLog(dir, "run-2", nil)
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1}},
+	}
+	provider := &mockProvider{err: errors.New("upstream unavailable")}
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{BatchSize: 1, Audit: audit})
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "run-2", "*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("expected a record for the failed call, got %v", matches)
+	}
+	data, _ := os.ReadFile(matches[0])
+	if !strings.Contains(string(data), "upstream unavailable") {
+		t.Errorf("expected the call error in the record: %s", data)
+	}
+}
+
+func TestNilAuditLogRecordsNothing(t *testing.T) {
+	if newAuditLog("", "run", nil) != nil {
+		t.Fatal("an empty directory should disable auditing")
+	}
+	var a *auditLog
+	a.record("mock", plannerllm.CompletionRequest{}, "", nil)
+}
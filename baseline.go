@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// Values of the baseline_status metadata key.
+const (
+	baselineNew      = "new"
+	baselineExisting = "existing"
+)
+
+// findingFingerprint identifies a finding independently of its line number,
+// so it survives unrelated edits that shift code up or down. It hashes the
+// rule ID, the workspace-relative path, and the trimmed source line.
+func findingFingerprint(ruleID, relPath, line string) string {
+	h := sha256.New()
+	h.Write([]byte(ruleID))
+	h.Write([]byte{0})
+	h.Write([]byte(filepath.ToSlash(relPath)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSpace(line)))
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// baselineEntry is one finding recorded in a baseline file.
+type baselineEntry struct {
+	Fingerprint string
+	RuleID      string
+	Location    string // "path:line", informational only
+	// Finding is the recorded finding; only JSON baselines have it.
+	Finding *pluginv1.Finding
+}
+
+// loadBaseline reads a baseline file. Two formats are accepted: a JSON array
+// of findings as returned by scan, or a text file with one fingerprint per
+// line, optionally followed by "# RULE-ID path:line". Blank lines and lines
+// starting with # are ignored.
+func loadBaseline(path string) (map[string]baselineEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]baselineEntry)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		findings, err := decodeFindings(string(trimmed))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range findings {
+			if f.GetFingerprint() == "" {
+				continue
+			}
+			file, line := findingLocation(f)
+			entries[f.GetFingerprint()] = baselineEntry{
+				Fingerprint: f.GetFingerprint(),
+				RuleID:      f.GetRuleId(),
+				Location:    fmt.Sprintf("%s:%d", file, line),
+				Finding:     f,
+			}
+		}
+		return entries, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fp, comment, _ := strings.Cut(line, "#")
+		entry := baselineEntry{Fingerprint: strings.TrimSpace(fp)}
+		if fields := strings.Fields(comment); len(fields) >= 2 {
+			entry.RuleID, entry.Location = fields[0], fields[1]
+		}
+		entries[entry.Fingerprint] = entry
+	}
+	return entries, scanner.Err()
+}
+
+// writeBaseline writes the fingerprints of findings to path in the text
+// format loadBaseline reads: one per line with a "# RULE-ID path:line"
+// comment so reviewers can see what is suppressed, sorted by location so
+// regenerated files diff cleanly. Paths are relative to root. Findings
+// without a fingerprint are skipped and repeated fingerprints written once.
+// It returns the number of entries written.
+func writeBaseline(path, root string, findings []*pluginv1.Finding) (int, error) {
+	type entry struct {
+		fingerprint, ruleID, file string
+		line                      int32
+	}
+	var entries []entry
+	seen := make(map[string]bool, len(findings))
+	for _, f := range findings {
+		fp := f.GetFingerprint()
+		if fp == "" || seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		file, line := findingLocation(f)
+		entries = append(entries, entry{fp, f.GetRuleId(), relativeFindingPath(root, file), line})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		if a.line != b.line {
+			return a.line < b.line
+		}
+		return a.ruleID < b.ruleID
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Baseline generated by nox-plugin-triage-agent %s: %d finding(s).\n", version, len(entries))
+	buf.WriteString("# Findings listed here are reported as existing; delete a line to report it as new again.\n")
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "%s # %s %s:%d\n", e.fingerprint, e.ruleID, e.file, e.line)
+	}
+	return len(entries), os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// compareBaseline tags each finding with baseline_status "new" or "existing"
+// and returns the baseline entries that no current finding matched, sorted by
+// fingerprint.
+func compareBaseline(findings []*pluginv1.Finding, baseline map[string]baselineEntry) []baselineEntry {
+	seen := make(map[string]bool, len(findings))
+	for _, f := range findings {
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		fp := f.GetFingerprint()
+		if _, ok := baseline[fp]; ok && fp != "" {
+			f.Metadata["baseline_status"] = baselineExisting
+			seen[fp] = true
+		} else {
+			f.Metadata["baseline_status"] = baselineNew
+		}
+	}
+
+	var resolved []baselineEntry
+	for fp, entry := range baseline {
+		if !seen[fp] {
+			resolved = append(resolved, entry)
+		}
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Fingerprint < resolved[j].Fingerprint })
+	return resolved
+}
+
+// countNewAtOrAbove counts findings tagged new whose severity is at least min.
+func countNewAtOrAbove(findings []*pluginv1.Finding, minSeverity pluginv1.Severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.GetMetadata()["baseline_status"] != baselineNew {
+			continue
+		}
+		if f.GetSeverity() == minSeverity || severityMoreSevere(f.GetSeverity(), minSeverity) {
+			n++
+		}
+	}
+	return n
+}
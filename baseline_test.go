@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func TestFindingFingerprintIgnoresIndentation(t *testing.T) {
+	a := findingFingerprint("TRIAGE-001", "app.py", "eval(x)")
+	b := findingFingerprint("TRIAGE-001", "app.py", "    eval(x)  ")
+	if a != b {
+		t.Error("fingerprint should ignore surrounding whitespace")
+	}
+	if a == findingFingerprint("TRIAGE-001", "other.py", "eval(x)") {
+		t.Error("fingerprint should depend on the file path")
+	}
+	if a == findingFingerprint("TRIAGE-002", "app.py", "eval(x)") {
+		t.Error("fingerprint should depend on the rule ID")
+	}
+}
+
+func TestLoadBaselineTextFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.txt")
+	content := "# generated baseline\n\nabc123  # TRIAGE-001 app.py:7\ndef456\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("loadBaseline: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries["abc123"]; e.RuleID != "TRIAGE-001" || e.Location != "app.py:7" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestLoadBaselineJSONFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	content := `[{"ruleId":"TRIAGE-002","fingerprint":"fp1","location":{"filePath":"api.py","startLine":3}}]`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("loadBaseline: %v", err)
+	}
+	if e := entries["fp1"]; e.RuleID != "TRIAGE-002" || e.Location != "api.py:3" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestScanBaselineComparison(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "legacy.py"), "eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "removed.py"), "exec(user_input)\n")
+
+	client := testClient(t)
+	first := invokeScan(t, client, root)
+
+	var lines []string
+	for _, f := range first.GetFindings() {
+		lines = append(lines, f.GetFingerprint())
+	}
+	writeFile(t, filepath.Join(root, ".nox-baseline"), strings.Join(lines, "\n")+"\n")
+
+	// Shift the legacy finding down a line, fix the other, and add a new one.
+	writeFile(t, filepath.Join(root, "legacy.py"), "import os\neval(user_input)\n")
+	if err := os.Remove(filepath.Join(root, "removed.py")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "fresh.py"), "os.system(cmd)\n")
+
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"baseline_file":  ".nox-baseline",
+		"fail_on_new":    "high",
+	})
+
+	statuses := make(map[string]string)
+	for _, f := range resp.GetFindings() {
+		statuses[filepath.Base(f.GetLocation().GetFilePath())] = f.GetMetadata()["baseline_status"]
+	}
+	if statuses["legacy.py"] != "existing" {
+		t.Errorf("legacy.py baseline_status = %q, want existing", statuses["legacy.py"])
+	}
+	if statuses["fresh.py"] != "new" {
+		t.Errorf("fresh.py baseline_status = %q, want new", statuses["fresh.py"])
+	}
+
+	var resolved, gate bool
+	for _, d := range resp.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "resolved: ") {
+			resolved = true
+		}
+		if d.GetSeverity() == pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_ERROR {
+			gate = true
+		}
+	}
+	if !resolved {
+		t.Error("expected a resolved diagnostic for the removed finding")
+	}
+	if !gate {
+		t.Error("expected an error diagnostic for the new HIGH finding")
+	}
+}
+
+func TestScanGenerateBaseline(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "legacy.py"), "eval(user_input)\nos.system(cmd)\n")
+	writeFile(t, filepath.Join(root, "lib", "old.js"), "eval(userInput)\n")
+
+	client := testClient(t)
+	first := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root":    root,
+		"generate_baseline": ".nox-baseline",
+	})
+
+	data, err := os.ReadFile(filepath.Join(root, ".nox-baseline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.HasPrefix(content, "# ") {
+		t.Errorf("expected a header comment, got:\n%s", content)
+	}
+	for _, want := range []string{"# TRIAGE-001 legacy.py:1", "# TRIAGE-001 lib/old.js:1"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in the baseline, got:\n%s", want, content)
+		}
+	}
+	if strings.Index(content, "legacy.py:2") > strings.Index(content, "lib/old.js") {
+		t.Errorf("expected entries sorted by path, got:\n%s", content)
+	}
+	var wrote bool
+	for _, d := range first.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "wrote ") && strings.Contains(d.GetMessage(), "fingerprint(s)") {
+			wrote = true
+		}
+	}
+	if !wrote {
+		t.Error("expected a diagnostic reporting the baseline")
+	}
+
+	// The generated file suppresses everything it lists.
+	writeFile(t, filepath.Join(root, "fresh.py"), "exec(code)\n")
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"baseline_file":  ".nox-baseline",
+	})
+	for _, f := range resp.GetFindings() {
+		want := "existing"
+		if filepath.Base(f.GetLocation().GetFilePath()) == "fresh.py" {
+			want = "new"
+		}
+		if got := f.GetMetadata()["baseline_status"]; got != want {
+			t.Errorf("%s %s: baseline_status = %q, want %q", f.GetRuleId(), f.GetLocation().GetFilePath(), got, want)
+		}
+	}
+}
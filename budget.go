@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"time"
+)
+
+// Limits on rule pattern complexity. RE2 matching is linear in the input,
+// but the constant factor grows with the compiled program, so oversized
+// patterns are rejected rather than allowed to slow every line.
+const (
+	maxPatternLength   = 4096
+	maxPatternProgSize = 5000
+)
+
+// checkPatternComplexity reports an error if a rule pattern is longer than
+// maxPatternLength or compiles to more than maxPatternProgSize instructions.
+func checkPatternComplexity(re *regexp.Regexp) error {
+	expr := re.String()
+	if len(expr) > maxPatternLength {
+		return fmt.Errorf("pattern is %d bytes, limit is %d", len(expr), maxPatternLength)
+	}
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return err
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return err
+	}
+	if n := len(prog.Inst); n > maxPatternProgSize {
+		return fmt.Errorf("pattern compiles to %d instructions, limit is %d", n, maxPatternProgSize)
+	}
+	return nil
+}
+
+// compileRulePattern compiles a rule pattern and applies the complexity
+// limits, for patterns that do not come from the built-in rule set.
+func compileRulePattern(expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPatternComplexity(re); err != nil {
+		return nil, err
+	}
+	return re, nil
+}
+
+// lineBudget tracks the time spent matching rules against one line. A zero
+// limit disables the budget.
+type lineBudget struct {
+	limit time.Duration
+	start time.Time
+}
+
+// reset starts the budget for a new line.
+func (b *lineBudget) reset() {
+	if b.limit > 0 {
+		b.start = time.Now()
+	}
+}
+
+// exceeded reports whether the current line has used up its budget.
+func (b *lineBudget) exceeded() bool {
+	return b.limit > 0 && time.Since(b.start) > b.limit
+}
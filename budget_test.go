@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuiltinPatternsWithinComplexityLimits(t *testing.T) {
+	for _, rule := range rules {
+		for ext, re := range rule.Patterns {
+			if err := checkPatternComplexity(re); err != nil {
+				t.Errorf("%s %s: %v", rule.ID, ext, err)
+			}
+		}
+		for ext, re := range rule.Excludes {
+			if err := checkPatternComplexity(re); err != nil {
+				t.Errorf("%s %s exclude: %v", rule.ID, ext, err)
+			}
+		}
+	}
+}
+
+func TestCompileRulePatternRejectsOversized(t *testing.T) {
+	if _, err := compileRulePattern(strings.Repeat("a", maxPatternLength+1)); err == nil {
+		t.Error("expected an overlong pattern to be rejected")
+	}
+	// A bounded repeat expands into one copy of its body per count.
+	if _, err := compileRulePattern(`(\w+\s*=\s*\w+;){1000}`); err == nil {
+		t.Error("expected a pattern with a large compiled program to be rejected")
+	}
+	if _, err := compileRulePattern(`(`); err == nil {
+		t.Error("expected a syntax error")
+	}
+	if _, err := compileRulePattern(`(?i)eval\(`); err != nil {
+		t.Errorf("simple pattern rejected: %v", err)
+	}
+}
+
+func TestLineBudget(t *testing.T) {
+	var off lineBudget
+	off.reset()
+	if off.exceeded() {
+		t.Error("a zero budget must never be exceeded")
+	}
+
+	b := lineBudget{limit: time.Nanosecond}
+	b.reset()
+	time.Sleep(time.Millisecond)
+	if !b.exceeded() {
+		t.Error("expected the budget to be exceeded")
+	}
+	b.limit = time.Hour
+	b.reset()
+	if b.exceeded() {
+		t.Error("expected a fresh budget not to be exceeded")
+	}
+}
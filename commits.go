@@ -0,0 +1,380 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// Metadata keys naming the commit that introduced a finding in a base_ref
+// scan.
+const (
+	commitKey        = "commit"
+	commitAuthorKey  = "commit_author"
+	commitSummaryKey = "commit_summary"
+)
+
+// commitRange is a scan of the commits between base_ref and head_ref. Both
+// revisions are exported from git into temporary directories: head is
+// scanned in place of the workspace, and the fingerprints of base decide
+// which findings the range introduced.
+type commitRange struct {
+	root       string // the workspace root, inside the git repository
+	base, head string
+	headDir    string
+	baseDir    string
+	// baseFingerprints holds the fingerprint of every finding at base.
+	baseFingerprints map[string]bool
+	// commits holds the commits blame attributed findings to, by hash.
+	commits map[string]*blameCommit
+}
+
+// openCommitRange checks that both revisions name commits, exports them, and
+// scans base. The caller scans headDir and must call close.
+func openCommitRange(ctx context.Context, root string, opts *scanOptions) (*commitRange, error) {
+	r := &commitRange{root: root, base: opts.BaseRef, head: opts.HeadRef, commits: make(map[string]*blameCommit)}
+	for _, ref := range []string{r.base, r.head} {
+		if strings.HasPrefix(ref, "-") {
+			return nil, newToolError(ErrInvalidInput, "invalid revision %q", ref)
+		}
+		if err := exec.CommandContext(ctx, "git", "-C", root, "rev-parse", "--verify", "--quiet", ref+"^{commit}").Run(); err != nil {
+			return nil, newToolError(ErrInvalidInput, "%s is not a commit in the git repository at %s", ref, root)
+		}
+	}
+
+	var err error
+	if r.baseDir, err = exportRevision(ctx, root, r.base); err != nil {
+		return nil, fmt.Errorf("exporting base_ref: %w", err)
+	}
+	if r.headDir, err = exportRevision(ctx, root, r.head); err != nil {
+		r.close()
+		return nil, fmt.Errorf("exporting head_ref: %w", err)
+	}
+
+	// Files that cannot be read at base only make their findings look new.
+	baseOpts := *opts
+	baseOpts.Progress = nil
+	baseResp := sdk.NewResponse()
+	ignore := func(string, error) error { return nil }
+	if err := walkRoot(ctx, baseResp, scanRoot{Path: r.baseDir}, &baseOpts, ignore); err != nil {
+		r.close()
+		return nil, fmt.Errorf("scanning base_ref: %w", err)
+	}
+	r.baseFingerprints = make(map[string]bool)
+	for _, f := range baseResp.Build().GetFindings() {
+		r.baseFingerprints[f.GetFingerprint()] = true
+	}
+	return r, nil
+}
+
+// close removes the exported revisions.
+func (r *commitRange) close() {
+	for _, dir := range []string{r.baseDir, r.headDir} {
+		if dir != "" {
+			_ = os.RemoveAll(dir)
+		}
+	}
+}
+
+// introduced returns the findings whose fingerprint is not in base, with
+// their paths moved from headDir to the workspace root.
+func (r *commitRange) introduced(findings []*pluginv1.Finding) []*pluginv1.Finding {
+	var kept []*pluginv1.Finding
+	for _, f := range findings {
+		if r.baseFingerprints[f.GetFingerprint()] {
+			continue
+		}
+		if loc := f.GetLocation(); loc != nil {
+			if rel, err := filepath.Rel(r.headDir, loc.GetFilePath()); err == nil && filepath.IsLocal(rel) {
+				loc.FilePath = filepath.Join(r.root, rel)
+			}
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// attribute blames the line of each finding within the range and records the
+// introducing commit in its metadata. Lines that predate base, such as code
+// moved from another file, are left unattributed. Files git cannot blame are
+// skipped and reported in the returned error.
+func (r *commitRange) attribute(ctx context.Context, findings []*pluginv1.Finding) error {
+	byFile := make(map[string][]*pluginv1.Finding)
+	for _, f := range findings {
+		file, _ := findingLocation(f)
+		rel, err := filepath.Rel(r.root, file)
+		if err != nil {
+			continue
+		}
+		byFile[filepath.ToSlash(rel)] = append(byFile[filepath.ToSlash(rel)], f)
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var errs []error
+	for _, file := range files {
+		var lines []int
+		for _, f := range byFile[file] {
+			_, line := findingLocation(f)
+			lines = append(lines, int(line))
+		}
+		blamed, err := blameLines(ctx, r.root, r.base+".."+r.head, file, lines)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		for _, f := range byFile[file] {
+			_, line := findingLocation(f)
+			c := blamed[int(line)]
+			if c == nil || c.Boundary {
+				continue
+			}
+			r.commits[c.Hash] = c
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			f.Metadata[commitKey] = c.Hash
+			f.Metadata[commitAuthorKey] = c.Author
+			f.Metadata[commitSummaryKey] = c.Summary
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// commitSection lists the findings one commit in the range introduced.
+type commitSection struct {
+	Commit    *blameCommit // nil for findings on lines that predate base
+	Locations []string
+}
+
+// String renders the section as reported in the commit range diagnostics: a
+// header line followed by one indented path:line and rule per finding.
+func (s commitSection) String() string {
+	var b strings.Builder
+	if s.Commit == nil {
+		fmt.Fprintf(&b, "unattributed: lines that predate base_ref, such as moved or renamed code (%d finding(s))", len(s.Locations))
+	} else {
+		fmt.Fprintf(&b, "commit %s %s: %s (%d finding(s))", shortHash(s.Commit.Hash), s.Commit.Author, s.Commit.Summary, len(s.Locations))
+	}
+	for _, loc := range s.Locations {
+		fmt.Fprintf(&b, "\n  %s", loc)
+	}
+	return b.String()
+}
+
+// sections groups findings by the commit attribute recorded, oldest commit
+// first, with unattributed findings last. Locations are ordered by path and
+// line.
+func (r *commitRange) sections(findings []*pluginv1.Finding) []commitSection {
+	sorted := append([]*pluginv1.Finding(nil), findings...)
+	sortFindings(sorted)
+
+	byCommit := make(map[string]*commitSection)
+	var order []string
+	for _, f := range sorted {
+		hash := f.GetMetadata()[commitKey]
+		s, ok := byCommit[hash]
+		if !ok {
+			s = &commitSection{Commit: r.commits[hash]}
+			byCommit[hash] = s
+			order = append(order, hash)
+		}
+		file, line := findingLocation(f)
+		s.Locations = append(s.Locations, fmt.Sprintf("%s:%d %s", relativeFindingPath(r.root, file), line, f.GetRuleId()))
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := r.commits[order[i]], r.commits[order[j]]
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		if a.Time != b.Time {
+			return a.Time < b.Time
+		}
+		return a.Hash < b.Hash
+	})
+	sections := make([]commitSection, 0, len(order))
+	for _, hash := range order {
+		sections = append(sections, *byCommit[hash])
+	}
+	return sections
+}
+
+// shortHash abbreviates a commit hash for display.
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 12)]
+}
+
+// exportRevision writes the tree of ref, as git archive produces it from
+// root, to a new temporary directory and returns its path.
+func exportRevision(ctx context.Context, root, ref string) (string, error) {
+	dir, err := os.MkdirTemp("", "nox-triage-rev-")
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "archive", "--format=tar", ref)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	extractErr := extractTar(out, dir)
+	// Drain the pipe so git can exit if extraction stopped early.
+	_, _ = io.Copy(io.Discard, out)
+	if err := cmd.Wait(); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("git archive %s: %v: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	if extractErr != nil {
+		_ = os.RemoveAll(dir)
+		return "", extractErr
+	}
+	return dir, nil
+}
+
+// extractTar writes the directories and regular files of a tar stream below
+// dir. Symlinks and entries whose names leave dir are skipped.
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			continue
+		}
+		target := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return err
+			}
+			if err := writeTarEntry(target, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeTarEntry copies the current tar entry to path.
+func writeTarEntry(path string, tr *tar.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, tr); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// blameCommit is a commit git blame attributes lines to.
+type blameCommit struct {
+	Hash    string
+	Author  string
+	Summary string
+	Time    int64 // committer time, in Unix seconds
+	// Boundary marks the base commit of the blamed range: lines attributed
+	// to it were not changed within the range.
+	Boundary bool
+}
+
+// blameLines runs git blame over revRange on the given lines of file, a path
+// relative to root, and returns the commit of each line.
+func blameLines(ctx context.Context, root, revRange, file string, lines []int) (map[int]*blameCommit, error) {
+	args := []string{"-C", root, "blame", "--porcelain"}
+	for _, line := range lines {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", line, line))
+	}
+	args = append(args, revRange, "--", file)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git blame: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseBlamePorcelain(out), nil
+}
+
+// parseBlamePorcelain reads git blame --porcelain output and returns the
+// commit of each final line number. A commit's header fields follow only its
+// first line, so commits are shared by hash.
+func parseBlamePorcelain(out []byte) map[int]*blameCommit {
+	commits := make(map[string]*blameCommit)
+	lines := make(map[int]*blameCommit)
+	var cur *blameCommit
+	for _, text := range strings.Split(string(out), "\n") {
+		key, value, _ := strings.Cut(text, " ")
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// The line's content.
+		case isCommitHash(key):
+			fields := strings.Fields(value)
+			if len(fields) < 2 {
+				continue
+			}
+			c, ok := commits[key]
+			if !ok {
+				c = &blameCommit{Hash: key}
+				commits[key] = c
+			}
+			if line, err := strconv.Atoi(fields[1]); err == nil {
+				lines[line] = c
+			}
+			cur = c
+		case cur == nil:
+		case key == "author":
+			cur.Author = value
+		case key == "summary":
+			cur.Summary = value
+		case key == "committer-time":
+			cur.Time, _ = strconv.ParseInt(value, 10, 64)
+		case key == "boundary":
+			cur.Boundary = true
+		}
+	}
+	return lines
+}
+
+// isCommitHash reports whether s is a full SHA-1 or SHA-256 commit hash.
+func isCommitHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
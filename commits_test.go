@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParseBlamePorcelain(t *testing.T) {
+	out := strings.Join([]string{
+		"1111111111111111111111111111111111111111 2 2 1",
+		"author Alice",
+		"committer-time 1700000000",
+		"summary Add export",
+		"filename app.py",
+		"\teval(a)",
+		"2222222222222222222222222222222222222222 1 5 1",
+		"author Bob",
+		"committer-time 1600000000",
+		"summary Initial import",
+		"boundary",
+		"filename app.py",
+		"\teval(old)",
+		"1111111111111111111111111111111111111111 3 7",
+		"\teval(b)",
+	}, "\n")
+
+	lines := parseBlamePorcelain([]byte(out))
+	if c := lines[2]; c == nil || c.Author != "Alice" || c.Summary != "Add export" || c.Time != 1700000000 || c.Boundary {
+		t.Errorf("line 2: got %+v", c)
+	}
+	if c := lines[5]; c == nil || !c.Boundary {
+		t.Errorf("line 5: expected the boundary commit, got %+v", c)
+	}
+	if lines[7] != lines[2] {
+		t.Errorf("line 7: expected the commit shared with line 2, got %+v", lines[7])
+	}
+}
+
+func TestScanCommitRange(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	git := func(author, date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=" + author, "-c", "user.email=dev@example.com", "-C", root}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	writeFile(t, filepath.Join(root, "app.py"), "eval(old)\n")
+	writeFile(t, filepath.Join(root, "old.py"), "eval(moved)\n")
+	git("Base", "2024-01-01T00:00:00Z", "init", "-q")
+	git("Base", "2024-01-01T00:00:00Z", "add", ".")
+	git("Base", "2024-01-01T00:00:00Z", "commit", "-q", "-m", "Initial import")
+	git("Base", "2024-01-01T00:00:00Z", "tag", "v1")
+
+	writeFile(t, filepath.Join(root, "app.py"), "eval(old)\neval(a)\n")
+	git("Alice", "2024-02-01T00:00:00Z", "mv", "old.py", "moved.py")
+	git("Alice", "2024-02-01T00:00:00Z", "commit", "-q", "-am", "Add export")
+	writeFile(t, filepath.Join(root, "lib.py"), "eval(b)\n")
+	git("Bob", "2024-03-01T00:00:00Z", "add", "lib.py")
+	git("Bob", "2024-03-01T00:00:00Z", "commit", "-q", "-m", "Add helpers")
+	// Uncommitted changes are not part of the range.
+	writeFile(t, filepath.Join(root, "app.py"), "eval(old)\neval(a)\neval(dirty)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"base_ref":       "v1",
+	})
+
+	got := make(map[string]string)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-001") {
+		rel, err := filepath.Rel(root, f.GetLocation().GetFilePath())
+		if err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("expected a path in the workspace, got %s", f.GetLocation().GetFilePath())
+		}
+		got[filepath.ToSlash(rel)] = f.GetMetadata()[commitAuthorKey]
+	}
+	want := map[string]string{"app.py": "Alice", "lib.py": "Bob", "moved.py": ""}
+	if len(got) != len(want) {
+		t.Errorf("expected findings in %v, got %v", want, got)
+	}
+	for file, author := range want {
+		if a, ok := got[file]; !ok || a != author {
+			t.Errorf("%s: expected author %q, got %q (present %v)", file, author, a, ok)
+		}
+	}
+
+	var sections []string
+	for _, d := range resp.GetDiagnostics() {
+		msg := d.GetMessage()
+		if strings.HasPrefix(msg, "commit range ") && !strings.Contains(msg, "3 new finding(s), 1 already present at v1") {
+			t.Errorf("unexpected summary: %s", msg)
+		}
+		if strings.HasPrefix(msg, "commit ") && !strings.HasPrefix(msg, "commit range ") || strings.HasPrefix(msg, "unattributed:") {
+			sections = append(sections, msg)
+		}
+	}
+	if len(sections) != 3 ||
+		!strings.Contains(sections[0], "Alice: Add export (1 finding(s))\n  app.py:2 TRIAGE-001") ||
+		!strings.Contains(sections[1], "Bob: Add helpers (1 finding(s))\n  lib.py:1 TRIAGE-001") ||
+		!strings.Contains(sections[2], "moved.py:1 TRIAGE-001") {
+		t.Errorf("expected sections for Alice, Bob, and the moved file, got %q", sections)
+	}
+}
+
+func TestScanCommitRangeInvalid(t *testing.T) {
+	client := testClient(t)
+	for _, extra := range []map[string]any{
+		{"head_ref": "HEAD"},
+		{"base_ref": "--output=/tmp/x"},
+		{"base_ref": "v1"},
+		{"base_ref": "HEAD~1", "diff_base": "HEAD"},
+	} {
+		fields := map[string]any{"workspace_root": t.TempDir()}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+		if got := status.Code(err); got != codes.InvalidArgument {
+			t.Errorf("%v: expected an invalid argument error, got %v", extra, err)
+		}
+	}
+}
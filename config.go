@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is loaded from the workspace root when config_file is not
+// given.
+const defaultConfigFile = ".nox-triage.yaml"
+
+// aiConfigKeys maps keys of the ai section of the configuration file to the
+// NOX_AI_* settings they override.
+var aiConfigKeys = map[string]string{
+	"provider":           "NOX_AI_PROVIDER",
+	"model":              "NOX_AI_MODEL",
+	"api_key":            "NOX_AI_API_KEY",
+	"base_url":           "NOX_AI_BASE_URL",
+	"batch_size":         "NOX_AI_BATCH_SIZE",
+	"timeout":            "NOX_AI_TIMEOUT",
+	"http_timeout":       "NOX_AI_HTTP_TIMEOUT",
+	"prices":             "NOX_AI_PRICES",
+	"stream":             "NOX_AI_STREAM",
+	"headers":            "NOX_AI_HEADERS",
+	"grouping":           "NOX_AI_GROUPING",
+	"audit_dir":          "NOX_AI_AUDIT_DIR",
+	"allowed_severities": "NOX_AI_ALLOWED_SEVERITIES",
+	"anonymize_paths":    "NOX_AI_ANONYMIZE_PATHS",
+	"context_lines":      "NOX_AI_CONTEXT_LINES",
+	"shared_context":     "NOX_AI_SHARED_CONTEXT",
+	"json_mode":          "NOX_AI_JSON_MODE",
+	"examples_file":      "NOX_AI_EXAMPLES_FILE",
+}
+
+// envOnlyAIKeys are the ai settings a configuration file may not set. The
+// file usually lives in the scanned workspace, and these decide where
+// provider requests go, with which credentials and headers, which examples
+// file is sent with them, and where their audit records are written, so
+// they are read from the environment only.
+var envOnlyAIKeys = map[string]bool{
+	"provider":      true,
+	"api_key":       true,
+	"base_url":      true,
+	"headers":       true,
+	"audit_dir":     true,
+	"examples_file": true,
+}
+
+// callerOnlyInputs are the tool inputs a configuration file may not set:
+// they decide where findings and the NOX_WEBHOOK_AUTH credential are posted.
+var callerOnlyInputs = map[string]bool{
+	"webhook_url":  true,
+	"webhook_auth": true,
+}
+
+// configPathInputs are the path inputs a configuration file may set only to
+// paths inside the workspace root.
+var configPathInputs = []string{"baseline_file", "generate_baseline", "output_file", "diff_file"}
+
+// runConfig is a parsed configuration file. Inputs holds defaults for tool
+// inputs and Settings the values that take precedence over the environment.
+type runConfig struct {
+	Inputs   map[string]any
+	Settings settings
+}
+
+// settings holds NOX_AI_* values read from a configuration file. Lookups fall
+// back to the environment, so a nil settings reads the environment only.
+type settings map[string]string
+
+// get returns the named setting, preferring the configuration file over the
+// environment.
+func (s settings) get(name string) string {
+	if v, ok := s[name]; ok {
+		return v
+	}
+	return os.Getenv(name)
+}
+
+// getInt returns the named setting as a positive integer, or def if it is
+// unset or invalid.
+func (s settings) getInt(name string, def int) int {
+	n, err := strconv.Atoi(s.get(name))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+// getBool reports whether the named setting is a true value such as "1" or
+// "true".
+func (s settings) getBool(name string) bool {
+	b, _ := strconv.ParseBool(s.get(name))
+	return b
+}
+
+// getDuration returns the named setting as a positive duration, or 0 if it is
+// unset or invalid.
+func (s settings) getDuration(name string) time.Duration {
+	d, err := time.ParseDuration(s.get(name))
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// loadRunConfig reads the configuration file for a run. An explicit path is
+// resolved against the workspace root and must exist; otherwise the default
+// file is read if present. A missing default file yields an empty config.
+// Path inputs in the file must stay inside the workspace root.
+func loadRunConfig(root, path string) (*runConfig, error) {
+	explicit := path != ""
+	if !explicit {
+		if root == "" {
+			return &runConfig{}, nil
+		}
+		path = defaultConfigFile
+	}
+	path = resolveOutputPath(root, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return &runConfig{}, nil
+		}
+		return nil, newToolError(ErrInvalidInput, "reading config_file: %v", err)
+	}
+	cfg, err := parseRunConfig(data)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "parsing %s: %v", filepath.Base(path), err)
+	}
+	for _, key := range configPathInputs {
+		if p, _ := cfg.Inputs[key].(string); p != "" && !withinRoot(root, p) {
+			return nil, newToolError(ErrInvalidInput, "%s: %s %q is outside the workspace root", filepath.Base(path), key, p)
+		}
+	}
+	return cfg, nil
+}
+
+// withinRoot reports whether path, resolved against root as
+// resolveOutputPath does, stays inside root once symbolic links are
+// followed. Parts of the path that do not exist yet are taken as written.
+func withinRoot(root, path string) bool {
+	realRoot, err := realPath(root)
+	if err != nil {
+		return false
+	}
+	target, err := realPath(resolveOutputPath(root, path))
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(realRoot, target)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// realPath returns p with symbolic links resolved in its longest existing
+// prefix.
+func realPath(p string) (string, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for {
+		real, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		parent := filepath.Dir(p)
+		if !errors.Is(err, fs.ErrNotExist) || parent == p {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
+
+// parseRunConfig decodes a configuration file. Top-level keys are tool input
+// names; the ai section sets NOX_AI_* values.
+func parseRunConfig(data []byte) (*runConfig, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	cfg := &runConfig{Inputs: make(map[string]any), Settings: make(settings)}
+	for key, value := range raw {
+		if callerOnlyInputs[key] {
+			return nil, fmt.Errorf("%s cannot be set in the configuration file; pass it as tool input", key)
+		}
+		if key != "ai" {
+			cfg.Inputs[key] = normalizeConfigValue(value)
+			continue
+		}
+		section, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("ai must be a mapping")
+		}
+		for k, v := range section {
+			name, ok := aiConfigKeys[k]
+			if !ok {
+				return nil, fmt.Errorf("unknown ai setting %q", k)
+			}
+			if envOnlyAIKeys[k] {
+				return nil, fmt.Errorf("ai.%s cannot be set in the configuration file; set %s in the environment", k, name)
+			}
+			s, err := settingString(v)
+			if err != nil {
+				return nil, fmt.Errorf("ai.%s: %w", k, err)
+			}
+			cfg.Settings[name] = s
+		}
+	}
+	return cfg, nil
+}
+
+// mergeInputs returns the tool input with configuration file defaults filled
+// in for every key the caller did not set.
+func (c *runConfig) mergeInputs(input map[string]any) map[string]any {
+	merged := make(map[string]any, len(input)+len(c.Inputs))
+	for k, v := range c.Inputs {
+		merged[k] = v
+	}
+	for k, v := range input {
+		merged[k] = v
+	}
+	return merged
+}
+
+// normalizeConfigValue converts YAML values to the shapes structpb produces
+// for tool input, so options parse the same way from either source.
+func normalizeConfigValue(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = normalizeConfigValue(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = normalizeConfigValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// settingString renders a scalar or, for prices, a mapping as the string the
+// equivalent environment variable would hold.
+func settingString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case int, float64, bool:
+		return fmt.Sprint(v), nil
+	case map[string]any:
+		b, err := json.Marshal(v)
+		return string(b), err
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseRunConfig(t *testing.T) {
+	cfg, err := parseRunConfig([]byte(`
+dedupe: true
+max_depth: 2
+ai:
+  model: local-model
+  batch_size: 10
+  timeout: 30s
+  prices:
+    local-model: {input: 0, output: 0}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := parseScanOptions(cfg.Inputs)
+	if !opts.Dedupe || opts.MaxDepth != 2 {
+		t.Errorf("inputs not applied: dedupe=%v max_depth=%d", opts.Dedupe, opts.MaxDepth)
+	}
+	if got := cfg.Settings.get("NOX_AI_MODEL"); got != "local-model" {
+		t.Errorf("NOX_AI_MODEL = %q, want local-model", got)
+	}
+	tc := newTriageConfig(cfg.Settings)
+	if tc.BatchSize != 10 || tc.Timeout != 30*time.Second {
+		t.Errorf("triage config = %+v, want batch 10 timeout 30s", tc)
+	}
+	if _, err := modelPrices(cfg.Settings); err != nil {
+		t.Errorf("prices from config rejected: %v", err)
+	}
+}
+
+func TestParseRunConfigUnknownAISetting(t *testing.T) {
+	if _, err := parseRunConfig([]byte("ai:\n  temperature: 0.5\n")); err == nil {
+		t.Error("expected an error for an unknown ai setting")
+	}
+}
+
+func TestParseRunConfigEnvOnlyAISettings(t *testing.T) {
+	for key := range envOnlyAIKeys {
+		if _, err := parseRunConfig([]byte("ai:\n  " + key + ": x\n")); err == nil {
+			t.Errorf("expected ai.%s in the configuration file to be rejected", key)
+		}
+	}
+}
+
+func TestLoadRunConfigPathsOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	for _, line := range []string{
+		"output_file: /tmp/findings.ndjson",
+		"output_file: ../findings.ndjson",
+		"generate_baseline: ../baseline.txt",
+		"baseline_file: " + filepath.Join(outside, "baseline.json"),
+		"baseline_file: escape/baseline.json",
+		"diff_file: escape/changes.diff",
+	} {
+		writeFile(t, filepath.Join(root, defaultConfigFile), line+"\n")
+		if _, err := loadRunConfig(root, ""); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", line, err)
+		}
+	}
+
+	writeFile(t, filepath.Join(root, defaultConfigFile), "output_file: out/findings.ndjson\nbaseline_file: "+filepath.Join(root, "baseline.json")+"\n")
+	if _, err := loadRunConfig(root, ""); err != nil {
+		t.Errorf("paths inside the root: %v", err)
+	}
+}
+
+func TestSettingsPrecedence(t *testing.T) {
+	t.Setenv("NOX_AI_MODEL", "env-model")
+	t.Setenv("NOX_AI_PROVIDER", "anthropic")
+
+	s := settings{"NOX_AI_MODEL": "file-model"}
+	if got := s.get("NOX_AI_MODEL"); got != "file-model" {
+		t.Errorf("expected config file to override env, got %q", got)
+	}
+	if got := s.get("NOX_AI_PROVIDER"); got != "anthropic" {
+		t.Errorf("expected env fallback, got %q", got)
+	}
+
+	cfg := &runConfig{Inputs: map[string]any{"dedupe": true, "compact": true}}
+	opts := parseScanOptions(cfg.mergeInputs(map[string]any{"dedupe": false}))
+	if opts.Dedupe {
+		t.Error("expected explicit input to override config file")
+	}
+	if !opts.Compact {
+		t.Error("expected config file default to apply")
+	}
+}
+
+func TestLoadRunConfig(t *testing.T) {
+	root := t.TempDir()
+
+	cfg, err := loadRunConfig(root, "")
+	if err != nil || len(cfg.Inputs) != 0 {
+		t.Fatalf("missing default file: cfg=%+v err=%v", cfg, err)
+	}
+
+	if _, err := loadRunConfig(root, "custom.yaml"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("missing explicit file: expected ErrInvalidInput, got %v", err)
+	}
+
+	writeFile(t, filepath.Join(root, defaultConfigFile), "dedupe: [\n")
+	if _, err := loadRunConfig(root, ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("malformed file: expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestScanUsesConfigFile(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "top.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "a", "deep.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "triage.yaml"), "max_depth: 0\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"config_file":    "triage.yaml",
+	})
+	if got := len(findByRule(resp.GetFindings(), "TRIAGE-001")); got != 1 {
+		t.Errorf("config max_depth=0: got %d TRIAGE-001 findings, want 1", got)
+	}
+
+	resp = invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"config_file":    "triage.yaml",
+		"max_depth":      float64(-1),
+	})
+	if got := len(findByRule(resp.GetFindings(), "TRIAGE-001")); got != 2 {
+		t.Errorf("explicit max_depth=-1: got %d TRIAGE-001 findings, want 2", got)
+	}
+}
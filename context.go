@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// Limits on source context sent to the LLM. Larger files are not excerpted
+// and long lines, usually minified code, are cut.
+const (
+	maxContextFileBytes = 4 << 20
+	maxContextLineBytes = 240
+)
+
+// sourceContext reads the lines around findings for the triage prompt when
+// NOX_AI_CONTEXT_LINES is set. A nil sourceContext adds no context.
+type sourceContext struct {
+	root   string
+	lines  int
+	shared bool
+	files  map[string][]string
+}
+
+// newSourceContext returns a sourceContext for cfg, or nil if context is
+// off. Relative finding paths are resolved against cfg.SourceRoot.
+func newSourceContext(cfg *triageConfig) *sourceContext {
+	if cfg == nil || cfg.ContextLines <= 0 {
+		return nil
+	}
+	return &sourceContext{
+		root:   cfg.SourceRoot,
+		lines:  cfg.ContextLines,
+		shared: cfg.SharedContext,
+		files:  make(map[string][]string),
+	}
+}
+
+// fileLines returns the lines of file with secrets masked, or nil if it
+// cannot be read, is too large, or is a configuration file, which may hold
+// secrets. Only relative paths that resolve inside the root, after following
+// symbolic links, are read, so a finding path cannot send other files to the
+// provider. Results are cached for the batch.
+func (c *sourceContext) fileLines(file string) []string {
+	if lines, ok := c.files[file]; ok {
+		return lines
+	}
+	var lines []string
+	if name, ok := c.resolve(file); ok && !configExtensions[filepath.Ext(name)] && envFileExt(name) == "" {
+		if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() && info.Size() <= maxContextFileBytes {
+			if data, err := os.ReadFile(name); err == nil {
+				lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+				for i, line := range lines {
+					lines[i] = maskSecrets(line)
+				}
+			}
+		}
+	}
+	c.files[file] = lines
+	return lines
+}
+
+// resolve returns the real path of file, or false if it is absolute or lies
+// outside the root.
+func (c *sourceContext) resolve(file string) (string, bool) {
+	if c.root == "" || filepath.IsAbs(file) || !withinRoot(c.root, file) {
+		return "", false
+	}
+	name, err := realPath(filepath.Join(c.root, file))
+	return name, err == nil
+}
+
+// lineRange is an inclusive range of 1-based line numbers.
+type lineRange struct{ start, end int }
+
+// render formats lines start to end of file with line numbers, marking the
+// lines in marked with ">".
+func (c *sourceContext) render(lines []string, r lineRange, marked map[int]bool) string {
+	var b strings.Builder
+	for n := r.start; n <= r.end; n++ {
+		text := lines[n-1]
+		if len(text) > maxContextLineBytes {
+			text = text[:maxContextLineBytes] + "..."
+		}
+		marker := " "
+		if marked[n] {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s%5d  %s\n", marker, n, text)
+	}
+	return b.String()
+}
+
+// around returns the range of ContextLines lines either side of line,
+// clipped to the file.
+func (c *sourceContext) around(lines []string, line int) lineRange {
+	return lineRange{max(1, line-c.lines), min(len(lines), line+c.lines)}
+}
+
+// snippet returns the lines around one finding, or "" if none are available.
+func (c *sourceContext) snippet(file string, line int32) string {
+	lines := c.fileLines(file)
+	if int(line) < 1 || int(line) > len(lines) {
+		return ""
+	}
+	return c.render(lines, c.around(lines, int(line)), map[int]bool{int(line): true})
+}
+
+// fileContext is the shared context for one file: the merged regions around
+// all of its findings in the batch.
+type fileContext struct {
+	File    string   `json:"file"`
+	Regions []string `json:"regions"`
+}
+
+// sharedContext returns one fileContext per file in findings, in order of
+// first appearance. Overlapping or adjacent regions are merged so each line
+// is sent once, with every finding line marked. File names go through
+// aliases.
+func (c *sourceContext) sharedContext(findings []*pluginv1.Finding, aliases *pathAliases) []fileContext {
+	var order []string
+	findingLines := make(map[string]map[int]bool)
+	for _, f := range findings {
+		file, line := findingLocation(f)
+		if findingLines[file] == nil {
+			findingLines[file] = make(map[int]bool)
+			order = append(order, file)
+		}
+		findingLines[file][int(line)] = true
+	}
+
+	var out []fileContext
+	for _, file := range order {
+		lines := c.fileLines(file)
+		var ranges []lineRange
+		for line := range findingLines[file] {
+			if line >= 1 && line <= len(lines) {
+				ranges = append(ranges, c.around(lines, line))
+			}
+		}
+		if len(ranges) == 0 {
+			continue
+		}
+		sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+		merged := ranges[:1]
+		for _, r := range ranges[1:] {
+			last := &merged[len(merged)-1]
+			if r.start <= last.end+1 {
+				last.end = max(last.end, r.end)
+				continue
+			}
+			merged = append(merged, r)
+		}
+		fc := fileContext{File: aliases.token(file)}
+		for _, r := range merged {
+			fc.Regions = append(fc.Regions, c.render(lines, r, findingLines[file]))
+		}
+		out = append(out, fc)
+	}
+	return out
+}
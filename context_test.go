@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// contextFixture writes a 30-line Python file and returns it with findings
+// on lines 10, 12, and 25.
+func contextFixture(t *testing.T) (string, []*pluginv1.Finding) {
+	t.Helper()
+	root := t.TempDir()
+	var src strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&src, "line_%02d = %d\n", i, i)
+	}
+	writeFile(t, filepath.Join(root, "app.py"), src.String())
+	var findings []*pluginv1.Finding
+	for _, line := range []int32{10, 12, 25} {
+		findings = append(findings, &pluginv1.Finding{
+			RuleId:   "TRIAGE-002",
+			Location: &pluginv1.Location{FilePath: "app.py", StartLine: line},
+		})
+	}
+	return root, findings
+}
+
+func TestTriagePromptContextPerFinding(t *testing.T) {
+	root, findings := contextFixture(t)
+	src := newSourceContext(&triageConfig{ContextLines: 2, SourceRoot: root})
+
+	prompt := buildTriagePrompt(findings, nil, src)
+
+	// Lines 10 and 12 are close, so line 11 appears in both snippets.
+	if n := strings.Count(prompt, "line_11 = 11"); n != 2 {
+		t.Errorf("expected line 11 in two snippets, got %d:\n%s", n, prompt)
+	}
+	if !strings.Contains(prompt, ">   25  line_25 = 25") {
+		t.Errorf("expected the finding line marked, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "line_20") || strings.Contains(prompt, "Source context") {
+		t.Errorf("expected only the lines around findings, got:\n%s", prompt)
+	}
+}
+
+func TestTriagePromptSharedContext(t *testing.T) {
+	root, findings := contextFixture(t)
+	src := newSourceContext(&triageConfig{ContextLines: 2, SharedContext: true, SourceRoot: root})
+
+	prompt := buildTriagePrompt(findings, nil, src)
+
+	if strings.Contains(prompt, `"context"`) {
+		t.Errorf("shared context should not repeat snippets per finding:\n%s", prompt)
+	}
+	for n := 8; n <= 14; n++ {
+		if c := strings.Count(prompt, fmt.Sprintf("line_%02d = %d", n, n)); c != 1 {
+			t.Errorf("expected line %d once in the merged region, got %d", n, c)
+		}
+	}
+	_, shared, ok := strings.Cut(prompt, "Source context")
+	if !ok || strings.Count(shared, `"file": "app.py"`) != 1 {
+		t.Fatalf("expected one file section, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, ">   10") || !strings.Contains(prompt, ">   12") || !strings.Contains(prompt, ">   25") {
+		t.Errorf("expected every finding line marked, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "line_20") {
+		t.Errorf("lines between regions should be left out, got:\n%s", prompt)
+	}
+}
+
+func TestSourceContextSkipsConfigAndMissingFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".env"), "API_TOKEN=abcdefgh12345678\n")
+	src := newSourceContext(&triageConfig{ContextLines: 3, SourceRoot: root})
+
+	if got := src.snippet(".env", 1); got != "" {
+		t.Errorf("configuration files should not be excerpted, got %q", got)
+	}
+	if got := src.snippet("missing.py", 1); got != "" {
+		t.Errorf("unreadable files should give no context, got %q", got)
+	}
+	if newSourceContext(&triageConfig{}) != nil || newSourceContext(nil) != nil {
+		t.Error("context should be off by default")
+	}
+}
+
+func TestSourceContextStaysInsideRoot(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(outside, "secret.py"), "token = 'outside'\n")
+	if err := os.Symlink(filepath.Join(outside, "secret.py"), filepath.Join(root, "link.py")); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+	src := newSourceContext(&triageConfig{ContextLines: 3, SourceRoot: root})
+
+	for _, file := range []string{
+		filepath.Join(outside, "secret.py"),
+		filepath.Join("..", filepath.Base(outside), "secret.py"),
+		"link.py",
+	} {
+		if got := src.snippet(file, 1); got != "" {
+			t.Errorf("%s: expected no context from outside the root, got %q", file, got)
+		}
+	}
+}
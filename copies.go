@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// contentHash identifies a rule match by the rule ID and the exact bytes of
+// the matched line, independently of the file it was found in.
+func contentHash(ruleID, line string) string {
+	h := sha256.New()
+	h.Write([]byte(ruleID))
+	h.Write([]byte{0})
+	h.Write([]byte(line))
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// dedupeCopies collapses findings from copies of the same file, such as
+// vendored directories or symlinks, into the first one reported. Findings
+// are copies when they share a content_hash and line number; a rule reports
+// at most one finding per line, so they are always in different files.
+// The primary finding lists the other locations in duplicate_paths as
+// comma-separated path:line entries. Findings without a content_hash are
+// kept as they are.
+func dedupeCopies(findings []*pluginv1.Finding) []*pluginv1.Finding {
+	type copyKey struct {
+		hash string
+		line int32
+	}
+
+	primaries := make(map[copyKey]*pluginv1.Finding)
+	kept := make([]*pluginv1.Finding, 0, len(findings))
+	for _, f := range findings {
+		hash := f.GetMetadata()["content_hash"]
+		if hash == "" {
+			kept = append(kept, f)
+			continue
+		}
+		file, line := findingLocation(f)
+		k := copyKey{hash, line}
+		primary, ok := primaries[k]
+		if !ok {
+			primaries[k] = f
+			kept = append(kept, f)
+			continue
+		}
+		dup := fmt.Sprintf("%s:%d", file, line)
+		if prev := primary.Metadata["duplicate_paths"]; prev != "" {
+			dup = strings.Join([]string{prev, dup}, ",")
+		}
+		primary.Metadata["duplicate_paths"] = dup
+	}
+	return kept
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func TestDedupeCopies(t *testing.T) {
+	finding := func(file string, line int32, hash string) *pluginv1.Finding {
+		return &pluginv1.Finding{
+			RuleId:   "TRIAGE-001",
+			Location: &pluginv1.Location{FilePath: file, StartLine: line},
+			Metadata: map[string]string{"content_hash": hash},
+		}
+	}
+	findings := []*pluginv1.Finding{
+		finding("app.py", 3, "aaa"),
+		finding("vendor/a/app.py", 3, "aaa"),
+		finding("vendor/b/app.py", 3, "aaa"),
+		finding("other.py", 7, "aaa"),
+		finding("util.py", 3, "bbb"),
+		{RuleId: "TRIAGE-001", Location: &pluginv1.Location{FilePath: "x.py", StartLine: 3}},
+	}
+
+	got := dedupeCopies(findings)
+
+	if len(got) != 4 {
+		t.Fatalf("expected 4 findings, got %d", len(got))
+	}
+	if dup := got[0].Metadata["duplicate_paths"]; dup != "vendor/a/app.py:3,vendor/b/app.py:3" {
+		t.Errorf("unexpected duplicate_paths %q", dup)
+	}
+	if _, ok := got[1].Metadata["duplicate_paths"]; ok {
+		t.Error("the same line on a different line number is not a copy")
+	}
+}
+
+func TestScanDedupeCopies(t *testing.T) {
+	root := t.TempDir()
+	src := "import os\nresult = eval(user_input)\n"
+	writeFile(t, filepath.Join(root, "app.py"), src)
+	writeFile(t, filepath.Join(root, "vendor", "lib", "app.py"), src)
+	if err := os.Symlink(filepath.Join(root, "app.py"), filepath.Join(root, "link.py")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"dedupe_copies":  true,
+	})
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 1 {
+		t.Fatalf("expected copies to collapse into 1 TRIAGE-001 finding, got %d", len(found))
+	}
+	if found[0].GetMetadata()["duplicate_paths"] == "" {
+		t.Errorf("expected duplicate_paths on the primary finding, got %v", found[0].GetMetadata())
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// charsPerToken approximates tokenization for English text and JSON. It is
+// deliberately simple: the estimate only needs to be the right order of
+// magnitude to decide whether a run is affordable.
+const charsPerToken = 4
+
+// outputTokensPerFinding approximates the size of one adjustment object in
+// the LLM response.
+const outputTokensPerFinding = 80
+
+// modelPrice is the price of a model in US dollars per million tokens.
+type modelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// defaultModelPrices covers the default model of each provider. Prices change
+// often; override or extend them with NOX_AI_PRICES.
+var defaultModelPrices = map[string]modelPrice{
+	"gpt-4o":                     {Input: 2.50, Output: 10.00},
+	"claude-sonnet-4-5-20250514": {Input: 3.00, Output: 15.00},
+	"gemini-pro":                 {Input: 0.50, Output: 1.50},
+	"llama3":                     {Input: 0, Output: 0},
+	"command-r-plus":             {Input: 2.50, Output: 10.00},
+	"anthropic.claude-3-sonnet-20240229-v1:0": {Input: 3.00, Output: 15.00},
+}
+
+// triageEstimate is the projected size and cost of an AI triage run.
+type triageEstimate struct {
+	Model        string
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+	Priced       bool // false when no price is known for Model
+}
+
+// estimateTriageCost builds the prompts AI triage would send for findings and
+// estimates their token usage and cost, without contacting the provider. A
+// relative NOX_AI_EXAMPLES_FILE is read from root.
+func estimateTriageCost(findings []*pluginv1.Finding, model string, s settings, root string) (triageEstimate, error) {
+	est := triageEstimate{Model: model}
+	cfg := newTriageConfig(s)
+	if file := s.get("NOX_AI_EXAMPLES_FILE"); file != "" {
+		cfg.Examples = loadTriageExamples(resolveOutputPath(root, file))
+	}
+	for _, batch := range triageBatches(findings, cfg.BatchSize, cfg.Grouping) {
+		var aliases *pathAliases
+		if cfg.AnonymizePaths {
+			aliases = newPathAliases(batch)
+		}
+		chars := len(cfg.systemPrompt()) + len(buildTriagePrompt(batch, aliases, newSourceContext(cfg)))
+		est.Requests++
+		est.InputTokens += (chars + charsPerToken - 1) / charsPerToken
+		est.OutputTokens += len(batch) * outputTokensPerFinding
+	}
+
+	prices, err := modelPrices(s)
+	if err != nil {
+		return est, err
+	}
+	if price, ok := prices[model]; ok {
+		est.Priced = true
+		est.Cost = float64(est.InputTokens)/1e6*price.Input + float64(est.OutputTokens)/1e6*price.Output
+	}
+	return est, nil
+}
+
+// modelPrices returns the default price table merged with NOX_AI_PRICES, a
+// JSON object of model name to {"input": n, "output": n} in USD per million tokens.
+func modelPrices(s settings) (map[string]modelPrice, error) {
+	prices := make(map[string]modelPrice, len(defaultModelPrices))
+	for model, price := range defaultModelPrices {
+		prices[model] = price
+	}
+
+	raw := s.get("NOX_AI_PRICES")
+	if raw == "" {
+		return prices, nil
+	}
+	var overrides map[string]modelPrice
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return prices, fmt.Errorf("invalid NOX_AI_PRICES: %w", err)
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+	return prices, nil
+}
+
+// String formats the estimate for a response diagnostic.
+func (e triageEstimate) String() string {
+	cost := "unknown (no price configured for model)"
+	if e.Priced {
+		cost = fmt.Sprintf("$%.4f", e.Cost)
+	}
+	return fmt.Sprintf("AI triage estimate for model %s: %d request(s), ~%d input tokens, ~%d output tokens, estimated cost %s",
+		e.Model, e.Requests, e.InputTokens, e.OutputTokens, cost)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+func TestEstimateTriageCost(t *testing.T) {
+	t.Setenv("NOX_AI_BATCH_SIZE", "2")
+	t.Setenv("NOX_AI_PRICES", `{"test-model":{"input":1000000,"output":0}}`)
+
+	findings := make([]*pluginv1.Finding, 3)
+	for i := range findings {
+		findings[i] = &pluginv1.Finding{
+			RuleId:   "TRIAGE-001",
+			Severity: sdk.SeverityHigh,
+			Location: &pluginv1.Location{FilePath: "app.py", StartLine: int32(i + 1)},
+		}
+	}
+
+	est, err := estimateTriageCost(findings, "test-model", nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if est.Requests != 2 {
+		t.Errorf("expected 2 requests for 3 findings in batches of 2, got %d", est.Requests)
+	}
+	if est.InputTokens == 0 {
+		t.Error("expected a non-zero input token estimate")
+	}
+	if est.OutputTokens != 3*outputTokensPerFinding {
+		t.Errorf("output tokens = %d, want %d", est.OutputTokens, 3*outputTokensPerFinding)
+	}
+	// At $1 per input token the cost equals the input token count.
+	if !est.Priced || est.Cost != float64(est.InputTokens) {
+		t.Errorf("cost = %v (priced=%v), want %d", est.Cost, est.Priced, est.InputTokens)
+	}
+}
+
+func TestEstimateTriageCostUnknownModel(t *testing.T) {
+	est, err := estimateTriageCost([]*pluginv1.Finding{{RuleId: "TRIAGE-001"}}, "unpriced-model", nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if est.Priced {
+		t.Error("expected no price for an unknown model")
+	}
+	if !strings.Contains(est.String(), "unknown") {
+		t.Errorf("expected estimate to report unknown cost, got %q", est.String())
+	}
+}
+
+func TestScanEstimateCostDoesNotTriage(t *testing.T) {
+	t.Setenv("NOX_AI_API_KEY", "")
+	t.Setenv("NOX_AI_PROVIDER", "")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": testdataDir(t),
+		"ai_triage":      true,
+		"estimate_cost":  true,
+	})
+
+	if len(resp.GetFindings()) == 0 {
+		t.Fatal("expected scan findings alongside the estimate")
+	}
+	for _, f := range resp.GetFindings() {
+		if _, ok := f.GetMetadata()["ai_triage_error"]; ok {
+			t.Fatal("estimate_cost must not attempt AI triage")
+		}
+	}
+
+	found := false
+	for _, d := range resp.GetDiagnostics() {
+		if strings.Contains(d.GetMessage(), "AI triage estimate") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected an AI triage estimate diagnostic")
+	}
+}
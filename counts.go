@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// findingCounts is the severity and priority histogram reported in the
+// counts diagnostic, so dashboards need not tally every finding.
+type findingCounts struct {
+	total      int
+	severities map[pluginv1.Severity]int
+	priorities map[string]int
+	levels     priorityLevels
+}
+
+// countFindings tallies findings by their final severity and priority.
+// A nil levels means defaultPriorityLevels.
+func countFindings(findings []*pluginv1.Finding, levels priorityLevels) findingCounts {
+	if levels == nil {
+		levels = defaultPriorityLevels
+	}
+	c := findingCounts{
+		severities: make(map[pluginv1.Severity]int),
+		priorities: make(map[string]int),
+		levels:     levels,
+	}
+	c.add(findings)
+	return c
+}
+
+// add tallies further findings, for counts kept across flushed batches.
+func (c *findingCounts) add(findings []*pluginv1.Finding) {
+	c.total += len(findings)
+	for _, f := range findings {
+		c.severities[f.GetSeverity()]++
+		if p := f.GetMetadata()["priority"]; p != "" {
+			c.priorities[c.levels.canonical(p)]++
+		}
+	}
+}
+
+// String renders the counts as space-separated key=value pairs: one
+// count_<severity> per standard severity, count_total, then one
+// count_<priority> per priority level and for any other priority seen, e.g.
+// "counts: count_critical=0 count_high=2 ... count_total=3 count_immediate=2 ...".
+func (c findingCounts) String() string {
+	var pairs []string
+	for _, sev := range reportSeverities {
+		pairs = append(pairs, fmt.Sprintf("count_%s=%d", severityName(sev), c.severities[sev]))
+	}
+	pairs = append(pairs, fmt.Sprintf("count_total=%d", c.total))
+	for _, level := range c.levels {
+		pairs = append(pairs, fmt.Sprintf("count_%s=%d", level, c.priorities[level]))
+	}
+	var others []string
+	for p := range c.priorities {
+		if !c.levels.valid(p) {
+			others = append(others, p)
+		}
+	}
+	sort.Strings(others)
+	for _, p := range others {
+		pairs = append(pairs, fmt.Sprintf("count_%s=%d", p, c.priorities[p]))
+	}
+	return "counts: " + strings.Join(pairs, " ")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func TestFindingCountsString(t *testing.T) {
+	finding := func(sev pluginv1.Severity, priority string) *pluginv1.Finding {
+		return &pluginv1.Finding{Severity: sev, Metadata: map[string]string{"priority": priority}}
+	}
+	findings := []*pluginv1.Finding{
+		finding(pluginv1.Severity_SEVERITY_HIGH, "immediate"),
+		finding(pluginv1.Severity_SEVERITY_HIGH, "Immediate"),
+		finding(pluginv1.Severity_SEVERITY_INFO, "informational"),
+		finding(pluginv1.Severity_SEVERITY_LOW, "someday"),
+		{Severity: pluginv1.Severity_SEVERITY_MEDIUM},
+	}
+
+	got := countFindings(findings, nil).String()
+	want := "counts: count_critical=0 count_high=2 count_medium=1 count_low=1 count_info=1 count_total=5 " +
+		"count_immediate=2 count_scheduled=0 count_backlog=0 count_informational=1 count_someday=1"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	got = countFindings(nil, priorityLevels{"p1", "p2"}).String()
+	if want := "counts: count_critical=0 count_high=0 count_medium=0 count_low=0 count_info=0 count_total=0 count_p1=0 count_p2=0"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestScanReportsCounts(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "handlers", "app.py"), "name = request.args[\"n\"]\n")
+	writeFile(t, filepath.Join(root, "app.py"), "name = request.args[\"n\"]\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"path_severity_rules": []any{
+			map[string]any{"path": "handlers/**", "severity": "+1"},
+		},
+	})
+
+	var counts []string
+	for _, d := range resp.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "counts: ") {
+			counts = append(counts, d.GetMessage())
+		}
+	}
+	if len(counts) != 1 {
+		t.Fatalf("expected one counts diagnostic, got %q", counts)
+	}
+	for _, pair := range []string{"count_high=1", "count_medium=1", "count_total=2", "count_scheduled=2"} {
+		if !strings.Contains(counts[0], pair) {
+			t.Errorf("expected %s after adjustment, got %s", pair, counts[0])
+		}
+	}
+}
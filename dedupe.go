@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// severityMoreSevere reports whether severity a is more severe than b.
+// SEVERITY_UNSPECIFIED is treated as the least severe value.
+func severityMoreSevere(a, b pluginv1.Severity) bool {
+	if a == pluginv1.Severity_SEVERITY_UNSPECIFIED {
+		return false
+	}
+	if b == pluginv1.Severity_SEVERITY_UNSPECIFIED {
+		return true
+	}
+	return a < b
+}
+
+// findingLocation returns the file path and start line of a finding.
+func findingLocation(f *pluginv1.Finding) (string, int32) {
+	if f.GetLocation() == nil {
+		return "", 0
+	}
+	return f.GetLocation().GetFilePath(), f.GetLocation().GetStartLine()
+}
+
+// dedupeFindings collapses findings that share a file and line into a single
+// finding. The most severe rule becomes the primary finding, keeping its rule
+// ID so AI triage can still match it. Every rule that fired is listed in the
+// matched_rules metadata, the union of their priorities in matched_priorities,
+// and priority is set to the most urgent of them by levels.
+func dedupeFindings(findings []*pluginv1.Finding, levels priorityLevels) []*pluginv1.Finding {
+	type lineKey struct {
+		file string
+		line int32
+	}
+
+	groups := make(map[lineKey][]*pluginv1.Finding)
+	var order []lineKey
+	for _, f := range findings {
+		file, line := findingLocation(f)
+		k := lineKey{file, line}
+		if _, seen := groups[k]; !seen {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], f)
+	}
+
+	merged := make([]*pluginv1.Finding, 0, len(order))
+	for _, k := range order {
+		merged = append(merged, mergeFindings(groups[k], levels))
+	}
+	return merged
+}
+
+// mergeFindings combines findings reported on the same line into the most
+// severe one. Ties keep the earliest finding, which follows rule order.
+func mergeFindings(group []*pluginv1.Finding, levels priorityLevels) *pluginv1.Finding {
+	primary := group[0]
+	for _, f := range group[1:] {
+		if severityMoreSevere(f.GetSeverity(), primary.GetSeverity()) {
+			primary = f
+		}
+	}
+
+	var ruleIDs []string
+	seenRules := make(map[string]bool)
+	var priorities []string
+	seenPriorities := make(map[string]bool)
+	for _, f := range group {
+		if !seenRules[f.GetRuleId()] {
+			seenRules[f.GetRuleId()] = true
+			ruleIDs = append(ruleIDs, f.GetRuleId())
+		}
+		if p := f.GetMetadata()["priority"]; p != "" && !seenPriorities[p] {
+			seenPriorities[p] = true
+			priorities = append(priorities, p)
+		}
+	}
+	sort.Strings(ruleIDs)
+	sort.Slice(priorities, func(i, j int) bool { return levels.less(priorities[i], priorities[j]) })
+
+	if primary.Metadata == nil {
+		primary.Metadata = make(map[string]string)
+	}
+	primary.Metadata["matched_rules"] = strings.Join(ruleIDs, ",")
+	if len(priorities) > 0 {
+		primary.Metadata["matched_priorities"] = strings.Join(priorities, ",")
+		primary.Metadata["priority"] = priorities[0]
+	}
+	return primary
+}
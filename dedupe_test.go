@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+func TestDedupeFindingsMergesSameLine(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{
+			RuleId:   "TRIAGE-004",
+			Severity: sdk.SeverityInfo,
+			Location: &pluginv1.Location{FilePath: "app.js", StartLine: 3},
+			Metadata: map[string]string{"priority": "informational"},
+		},
+		{
+			RuleId:   "TRIAGE-001",
+			Severity: sdk.SeverityHigh,
+			Location: &pluginv1.Location{FilePath: "app.js", StartLine: 3},
+			Metadata: map[string]string{"priority": "immediate"},
+		},
+		{
+			RuleId:   "TRIAGE-002",
+			Severity: sdk.SeverityMedium,
+			Location: &pluginv1.Location{FilePath: "app.js", StartLine: 4},
+			Metadata: map[string]string{"priority": "scheduled"},
+		},
+	}
+
+	got := dedupeFindings(findings, defaultPriorityLevels)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 findings after dedupe, got %d", len(got))
+	}
+
+	merged := got[0]
+	if merged.GetRuleId() != "TRIAGE-001" {
+		t.Errorf("expected most severe rule TRIAGE-001 as primary, got %s", merged.GetRuleId())
+	}
+	if merged.Metadata["matched_rules"] != "TRIAGE-001,TRIAGE-004" {
+		t.Errorf("matched_rules = %q", merged.Metadata["matched_rules"])
+	}
+	if merged.Metadata["matched_priorities"] != "immediate,informational" {
+		t.Errorf("matched_priorities = %q", merged.Metadata["matched_priorities"])
+	}
+	if merged.Metadata["priority"] != "immediate" {
+		t.Errorf("priority = %q, want immediate", merged.Metadata["priority"])
+	}
+
+	if got[1].Metadata["matched_rules"] != "TRIAGE-002" {
+		t.Errorf("single finding matched_rules = %q", got[1].Metadata["matched_rules"])
+	}
+}
+
+func TestDedupeFindingsStillMatchedByAITriage(t *testing.T) {
+	findings := dedupeFindings([]*pluginv1.Finding{
+		{
+			RuleId:   "TRIAGE-004",
+			Severity: sdk.SeverityInfo,
+			Location: &pluginv1.Location{FilePath: "app.py", StartLine: 9},
+			Metadata: map[string]string{"priority": "informational"},
+		},
+		{
+			RuleId:   "TRIAGE-001",
+			Severity: sdk.SeverityHigh,
+			Location: &pluginv1.Location{FilePath: "app.py", StartLine: 9},
+			Metadata: map[string]string{"priority": "immediate"},
+		},
+	}, defaultPriorityLevels)
+
+	respJSON, _ := json.Marshal([]triageAdjustment{{
+		RuleID:           "TRIAGE-001",
+		File:             "app.py",
+		Line:             9,
+		AdjustedSeverity: "critical",
+		Classification:   "true_positive",
+		Reason:           "user input reaches eval",
+	}})
+	aiTriageFindings(context.Background(), &mockProvider{response: string(respJSON)}, "mock-model", findings, nil)
+
+	if findings[0].Metadata["ai_triaged"] != "true" {
+		t.Error("expected merged finding to be matched by AI triage on its primary rule ID")
+	}
+	if findings[0].GetSeverity() != sdk.SeverityCritical {
+		t.Errorf("expected severity CRITICAL, got %v", findings[0].GetSeverity())
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// demoInputs declares the inputs of the demo tool.
+var demoInputs = []inputSpec{
+	{Name: "rules", Types: []string{"array", "string"}, Description: "Only scan the snippets of these rule IDs"},
+	{Name: "strict_inputs", Types: []string{"boolean"}, Default: false, Description: "Reject inputs not declared in this schema"},
+}
+
+// handleDemo scans the embedded selftest snippets as a corpus of
+// intentionally vulnerable code and returns the findings, so the plugin's
+// output can be seen without a workspace. Each snippet is reported at
+// selftest/<rule ID><extension>. The output holds no run IDs or times, so
+// it is the same on every call with the same rule set.
+func handleDemo(_ context.Context, req sdk.ToolRequest) (*pluginv1.InvokeToolResponse, error) {
+	if err := checkInputs("demo", req.Input); err != nil {
+		return nil, err
+	}
+	only := make(map[string]bool)
+	for _, id := range inputStrings(req.Input, "rules") {
+		if !isRuleID(id) {
+			return nil, newToolError(ErrInvalidInput, "unknown rule %q in rules", id)
+		}
+		only[id] = true
+	}
+
+	entries, err := fs.ReadDir(selftestSnippets, "selftest")
+	if err != nil {
+		return nil, err
+	}
+	resp := sdk.NewResponse()
+	snippets := 0
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".txt")
+		ext := path.Ext(name)
+		if len(only) > 0 && !only[strings.TrimSuffix(name, ext)] {
+			continue
+		}
+		data, err := selftestSnippets.ReadFile("selftest/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		display := "selftest/" + name
+		if err := scanSource(resp, bytes.NewReader(data), display, display, ext, &scanOptions{MaxDepth: -1}); err != nil {
+			return nil, err
+		}
+		snippets++
+	}
+
+	built := resp.Build()
+	addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+		fmt.Sprintf("demo: %d finding(s) in %d snippet(s) of the embedded corpus", len(built.GetFindings()), snippets))
+	return built, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func invokeDemo(t *testing.T, input map[string]any) (*pluginv1.InvokeToolResponse, error) {
+	t.Helper()
+	in, err := structpb.NewStruct(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testClient(t).InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "demo", Input: in})
+}
+
+func TestDemoCoversEveryRule(t *testing.T) {
+	resp, err := invokeDemo(t, map[string]any{})
+	if err != nil {
+		t.Fatalf("InvokeTool(demo): %v", err)
+	}
+	for i := range rules {
+		// Snippets may also trip other rules; each rule must fire on its own.
+		own := false
+		for _, f := range findByRule(resp.GetFindings(), rules[i].ID) {
+			if strings.HasPrefix(f.GetLocation().GetFilePath(), "selftest/"+rules[i].ID+".") {
+				own = true
+			}
+		}
+		if !own {
+			t.Errorf("expected %s to fire on its own demo snippets", rules[i].ID)
+		}
+	}
+	diags := resp.GetDiagnostics()
+	if len(diags) == 0 || !strings.HasPrefix(diags[len(diags)-1].GetMessage(), "demo: ") {
+		t.Errorf("expected a demo summary diagnostic, got %v", diags)
+	}
+}
+
+func TestDemoRulesFilter(t *testing.T) {
+	resp, err := invokeDemo(t, map[string]any{"rules": []any{"TRIAGE-001"}})
+	if err != nil {
+		t.Fatalf("InvokeTool(demo): %v", err)
+	}
+	if len(resp.GetFindings()) == 0 {
+		t.Fatal("expected findings for TRIAGE-001")
+	}
+	for _, f := range resp.GetFindings() {
+		if !strings.HasPrefix(f.GetLocation().GetFilePath(), "selftest/TRIAGE-001.") {
+			t.Errorf("unexpected finding from %q", f.GetLocation().GetFilePath())
+		}
+	}
+}
+
+func TestDemoRejectsUnknownRule(t *testing.T) {
+	if _, err := invokeDemo(t, map[string]any{"rules": "TRIAGE-999"}); err == nil {
+		t.Error("expected an unknown rule to be rejected")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// addedLines maps a slash-separated, workspace-relative path to the set of
+// post-change line numbers a diff adds to it.
+type addedLines map[string]map[int]bool
+
+// contains reports whether line of relPath was added by the diff.
+func (a addedLines) contains(relPath string, line int) bool {
+	return a[filepath.ToSlash(relPath)][line]
+}
+
+// hasFile reports whether the diff adds any line to relPath.
+func (a addedLines) hasFile(relPath string) bool {
+	return len(a[filepath.ToSlash(relPath)]) > 0
+}
+
+// hunkHeader matches a unified diff hunk header and captures the start of the
+// post-change range.
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// parseUnifiedDiff reads a unified diff and returns the lines it adds, keyed
+// by the post-change path with any "b/" prefix removed. Context and removed
+// lines are not recorded, and deleted files are skipped.
+func parseUnifiedDiff(r io.Reader) (addedLines, error) {
+	added := make(addedLines)
+	var file string
+	line := 0
+	inHunk := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			file = diffPath(strings.TrimPrefix(text, "+++ "))
+			inHunk = false
+		case strings.HasPrefix(text, "@@"):
+			m := hunkHeader.FindStringSubmatch(text)
+			if m == nil {
+				return nil, fmt.Errorf("malformed hunk header %q", text)
+			}
+			line, _ = strconv.Atoi(m[1])
+			inHunk = true
+		case !inHunk:
+			// File headers and git extended headers between hunks.
+		case strings.HasPrefix(text, "+"):
+			if file != "" {
+				if added[file] == nil {
+					added[file] = make(map[int]bool)
+				}
+				added[file][line] = true
+			}
+			line++
+		case strings.HasPrefix(text, " "), text == "":
+			line++
+		case strings.HasPrefix(text, "-"), strings.HasPrefix(text, `\`):
+			// Removed lines and "\ No newline at end of file" do not exist
+			// in the post-change file.
+		default:
+			inHunk = false
+		}
+	}
+	return added, scanner.Err()
+}
+
+// diffPath extracts the path from a "+++" header value. It returns "" for
+// /dev/null, which marks a deleted file.
+func diffPath(header string) string {
+	if i := strings.IndexByte(header, '\t'); i >= 0 {
+		header = header[:i]
+	}
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, "b/")
+}
+
+// loadDiff returns the added lines for a scan restricted by diff_file or
+// diff_base. It returns nil when neither is set. Paths in diff_file must be
+// relative to the workspace root; git output is made so with --relative.
+func loadDiff(ctx context.Context, root string, opts *scanOptions) (addedLines, error) {
+	switch {
+	case opts.DiffFile != "":
+		f, err := os.Open(resolveOutputPath(root, opts.DiffFile))
+		if err != nil {
+			return nil, newToolError(ErrInvalidInput, "reading diff_file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+		added, err := parseUnifiedDiff(f)
+		if err != nil {
+			return nil, newToolError(ErrInvalidInput, "parsing diff_file: %v", err)
+		}
+		return added, nil
+
+	case opts.DiffBase != "":
+		if strings.HasPrefix(opts.DiffBase, "-") {
+			return nil, newToolError(ErrInvalidInput, "invalid diff_base %q", opts.DiffBase)
+		}
+		out, err := exec.CommandContext(ctx, "git", "-C", root, "diff", "--relative", "--unified=0", "--no-color", "--no-ext-diff", opts.DiffBase, "--").Output()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			return nil, newToolError(ErrInvalidInput, "computing diff against %s: %v", opts.DiffBase, err)
+		}
+		added, err := parseUnifiedDiff(bytes.NewReader(out))
+		if err != nil {
+			return nil, newToolError(ErrInvalidInput, "parsing git diff: %v", err)
+		}
+		return added, nil
+	}
+	return nil, nil
+}
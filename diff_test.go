@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/app.py b/app.py
+index 1111111..2222222 100644
+--- a/app.py
++++ b/app.py
+@@ -1,4 +1,5 @@
+ import os
+-os.system(cmd)
++eval(data)
++exec(code)
+ x = 1
+ eval(old)
+@@ -10,0 +12,1 @@ def handler():
++eval(more)
+diff --git a/gone.py b/gone.py
+deleted file mode 100644
+--- a/gone.py
++++ /dev/null
+@@ -1 +0,0 @@
+-eval(x)
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	added, err := parseUnifiedDiff(strings.NewReader(sampleDiff))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []int{2, 3, 12} {
+		if !added.contains("app.py", line) {
+			t.Errorf("expected app.py:%d to be added", line)
+		}
+	}
+	for _, line := range []int{1, 4, 5} {
+		if added.contains("app.py", line) {
+			t.Errorf("context line app.py:%d should not be added", line)
+		}
+	}
+	if added.hasFile("gone.py") {
+		t.Error("deleted file should have no added lines")
+	}
+}
+
+func TestParseUnifiedDiffMalformedHunk(t *testing.T) {
+	if _, err := parseUnifiedDiff(strings.NewReader("+++ b/a.py\n@@ bogus @@\n")); err == nil {
+		t.Error("expected an error for a malformed hunk header")
+	}
+}
+
+func TestScanDiffFileRestrictsToAddedLines(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.py"), "import os\neval(data)\nexec(code)\nx = 1\neval(old)\n")
+	writeFile(t, filepath.Join(root, "other.py"), "eval(untouched)\n")
+	writeFile(t, filepath.Join(root, "pr.diff"), sampleDiff)
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"diff_file":      "pr.diff",
+	})
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 2 {
+		t.Fatalf("expected 2 TRIAGE-001 findings on added lines, got %d", len(found))
+	}
+	for _, f := range found {
+		if filepath.Base(f.GetLocation().GetFilePath()) != "app.py" {
+			t.Errorf("unexpected finding in %s", f.GetLocation().GetFilePath())
+		}
+		if l := f.GetLocation().GetStartLine(); l != 2 && l != 3 {
+			t.Errorf("finding on line %d, which the diff does not add", l)
+		}
+	}
+}
+
+func TestScanDiffBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-C", root}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	writeFile(t, filepath.Join(root, "app.py"), "eval(old)\n")
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+	writeFile(t, filepath.Join(root, "app.py"), "eval(old)\neval(new)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"diff_base":      "HEAD",
+	})
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 1 || found[0].GetLocation().GetStartLine() != 2 {
+		t.Errorf("expected one TRIAGE-001 finding on line 2, got %d findings", len(found))
+	}
+}
+
+func TestLoadDiffCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := loadDiff(ctx, t.TempDir(), &scanOptions{DiffBase: "HEAD"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// directiveHeaderLines is how many leading lines are searched for rule
+// directives.
+const directiveHeaderLines = 10
+
+// ruleDirective matches a "nox:rules" or "nox:disable" comment, in any of
+// the comment styles of the supported languages, and captures the rule IDs
+// after it.
+var ruleDirective = regexp.MustCompile(`(?:^|\s)(?://|#|/\*)\s*nox:(rules|disable)\s+([\w-]+(?:\s*,\s*[\w-]+)*)`)
+
+// fileRules is the rule set one file's directives allow. A nil *fileRules
+// allows every rule.
+type fileRules struct {
+	only     map[string]bool // from nox:rules; nil runs every rule
+	disabled map[string]bool // from nox:disable
+}
+
+// readRuleDirectives returns the rules allowed by the "nox:rules" and
+// "nox:disable" directives in the leading lines of r, or nil when there are
+// none. Several directives of a kind add up, and disabling a rule wins over
+// listing it. Unknown rule IDs are logged and ignored, so a nox:rules
+// directive naming no rule that exists does not turn every rule off. It
+// peeks without consuming, so r can then be scanned from the start.
+func readRuleDirectives(r *bufio.Reader, relPath string) *fileRules {
+	head, _ := r.Peek(4096)
+	var fr *fileRules
+	for i, line := range bytes.SplitN(head, []byte("\n"), directiveHeaderLines+1) {
+		if i == directiveHeaderLines {
+			break
+		}
+		m := ruleDirective.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if fr == nil {
+			fr = &fileRules{disabled: make(map[string]bool)}
+		}
+		for _, id := range strings.Split(string(m[2]), ",") {
+			id = strings.TrimSpace(id)
+			switch {
+			case !isRuleID(id):
+				log.Printf("triage: %s:%d: ignoring unknown rule %q in nox:%s", relPath, i+1, id, m[1])
+			case string(m[1]) == "disable":
+				fr.disabled[id] = true
+			default:
+				if fr.only == nil {
+					fr.only = make(map[string]bool)
+				}
+				fr.only[id] = true
+			}
+		}
+	}
+	return fr
+}
+
+// allows reports whether the rule with the given ID runs on the file.
+func (fr *fileRules) allows(id string) bool {
+	if fr == nil {
+		return true
+	}
+	return !fr.disabled[id] && (fr.only == nil || fr.only[id])
+}
+
+// isRuleID reports whether id names a built-in rule.
+func isRuleID(id string) bool {
+	for i := range rules {
+		if rules[i].ID == id {
+			return true
+		}
+	}
+	return false
+}
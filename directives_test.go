@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadRuleDirectives(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		allowed []string
+		denied  []string
+	}{
+		{"none", "package x\n", []string{"TRIAGE-001", "TRIAGE-004"}, nil},
+		{"rules", "// nox:rules TRIAGE-001, TRIAGE-002\npackage x\n", []string{"TRIAGE-001", "TRIAGE-002"}, []string{"TRIAGE-003", "TRIAGE-004"}},
+		{"disable", "# nox:disable TRIAGE-004\n", []string{"TRIAGE-001", "TRIAGE-003"}, []string{"TRIAGE-004"}},
+		{"disable wins", "/* nox:rules TRIAGE-001,TRIAGE-004 */\n// nox:disable TRIAGE-004\n", []string{"TRIAGE-001"}, []string{"TRIAGE-002", "TRIAGE-004"}},
+		{"repeated", "# nox:rules TRIAGE-001\n# nox:rules TRIAGE-003\n", []string{"TRIAGE-001", "TRIAGE-003"}, []string{"TRIAGE-002"}},
+		{"unknown only", "// nox:rules TRIAGE-999\n", []string{"TRIAGE-001", "TRIAGE-004"}, nil},
+		{"not a comment", "x = \"nox:disable TRIAGE-004\"\n", []string{"TRIAGE-004"}, nil},
+		{"too deep", strings.Repeat("x = 1\n", directiveHeaderLines) + "# nox:disable TRIAGE-004\n", []string{"TRIAGE-004"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fr := readRuleDirectives(bufio.NewReader(strings.NewReader(tt.src)), "x")
+			for _, id := range tt.allowed {
+				if !fr.allows(id) {
+					t.Errorf("expected %s allowed", id)
+				}
+			}
+			for _, id := range tt.denied {
+				if fr.allows(id) {
+					t.Errorf("expected %s not allowed", id)
+				}
+			}
+		})
+	}
+}
+
+func TestScanRuleDirectives(t *testing.T) {
+	root := t.TempDir()
+	src := "result = eval(user_input)\ntoken = jwt.encode(payload, key)\n"
+	writeFile(t, filepath.Join(root, "only.py"), "# nox:rules TRIAGE-001\n"+src)
+	writeFile(t, filepath.Join(root, "disabled.py"), "# nox:disable TRIAGE-001\n"+src)
+	writeFile(t, filepath.Join(root, "plain.py"), src)
+
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]string)
+	for _, f := range resp.GetFindings() {
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetRuleId())
+	}
+	if !slices.Contains(got["plain.py"], "TRIAGE-001") || !slices.Contains(got["plain.py"], "TRIAGE-004") {
+		t.Fatalf("expected TRIAGE-001 and TRIAGE-004 in plain.py, got %v", got["plain.py"])
+	}
+	if want := []string{"TRIAGE-001"}; !slices.Equal(got["only.py"], want) {
+		t.Errorf("only.py: got %v, want %v", got["only.py"], want)
+	}
+	if slices.Contains(got["disabled.py"], "TRIAGE-001") || !slices.Contains(got["disabled.py"], "TRIAGE-004") {
+		t.Errorf("disabled.py: expected TRIAGE-004 without TRIAGE-001, got %v", got["disabled.py"])
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Source encodings accepted by the encoding input.
+const (
+	encodingAuto    = "auto"
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+)
+
+// validEncodings lists the values accepted by the encoding input.
+var validEncodings = map[string]bool{
+	encodingAuto:    true,
+	encodingUTF8:    true,
+	encodingUTF16LE: true,
+	encodingUTF16BE: true,
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// decodeSource wraps r so it yields UTF-8 text. A leading byte order mark is
+// stripped and selects the encoding; without one, enc decides, defaulting to
+// UTF-8. CRLF line endings need no handling here because bufio.ScanLines
+// drops the trailing \r, which also keeps line numbers unchanged.
+func decodeSource(r io.Reader, enc string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(3)
+
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		_, _ = br.Discard(len(bomUTF8))
+		return br, nil
+	case bytes.HasPrefix(head, bomUTF16LE):
+		_, _ = br.Discard(len(bomUTF16LE))
+		return decodeUTF16(br, binary.LittleEndian), nil
+	case bytes.HasPrefix(head, bomUTF16BE):
+		_, _ = br.Discard(len(bomUTF16BE))
+		return decodeUTF16(br, binary.BigEndian), nil
+	}
+
+	switch enc {
+	case "", encodingAuto, encodingUTF8:
+		return br, nil
+	case encodingUTF16LE:
+		return decodeUTF16(br, binary.LittleEndian), nil
+	case encodingUTF16BE:
+		return decodeUTF16(br, binary.BigEndian), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", enc)
+	}
+}
+
+// decodeUTF16 returns a reader that decodes r as UTF-16 in the given byte
+// order and yields the text re-encoded as UTF-8. It decodes as it is read,
+// so a large file is never held in memory. Unpaired surrogates become
+// U+FFFD and a trailing odd byte is ignored.
+func decodeUTF16(r *bufio.Reader, order binary.ByteOrder) io.Reader {
+	return &utf16Reader{r: r, order: order}
+}
+
+// utf16Reader is the reader returned by decodeUTF16.
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	// next is a unit read ahead while looking for a low surrogate that
+	// turned out not to be one.
+	next    uint16
+	hasNext bool
+	// out holds the encoded bytes of a rune not yet returned.
+	out []byte
+	err error
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(u.out) == 0 {
+			if u.err != nil {
+				break
+			}
+			r, err := u.readRune()
+			if err != nil {
+				u.err = err
+				break
+			}
+			u.out = utf8.AppendRune(u.out[:0], r)
+		}
+		c := copy(p[n:], u.out)
+		u.out = u.out[c:]
+		n += c
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, u.err
+}
+
+// readRune decodes the next code point.
+func (u *utf16Reader) readRune() (rune, error) {
+	first, err := u.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	if !utf16.IsSurrogate(rune(first)) {
+		return rune(first), nil
+	}
+	second, err := u.readUnit()
+	if err == io.EOF {
+		return utf8.RuneError, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if r := utf16.DecodeRune(rune(first), rune(second)); r != utf8.RuneError {
+		return r, nil
+	}
+	u.next, u.hasNext = second, true
+	return utf8.RuneError, nil
+}
+
+// readUnit reads one code unit, treating a trailing odd byte as the end of
+// the input.
+func (u *utf16Reader) readUnit() (uint16, error) {
+	if u.hasNext {
+		u.hasNext = false
+		return u.next, nil
+	}
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	return u.order.Uint16(b[:]), nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encodes s as UTF-16 in the given byte order, optionally with a BOM.
+func encodeUTF16(s string, order binary.AppendByteOrder, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	var out []byte
+	if bom {
+		out = order.AppendUint16(out, 0xFEFF)
+	}
+	for _, u := range units {
+		out = order.AppendUint16(out, u)
+	}
+	return out
+}
+
+func TestDecodeSource(t *testing.T) {
+	const text = "line one\r\nline two\r\n"
+	tests := []struct {
+		name  string
+		input []byte
+		enc   string
+	}{
+		{"plain", []byte(text), ""},
+		{"utf8 bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), ""},
+		{"utf16le bom", encodeUTF16(text, binary.LittleEndian, true), ""},
+		{"utf16be bom", encodeUTF16(text, binary.BigEndian, true), ""},
+		{"utf16le forced", encodeUTF16(text, binary.LittleEndian, false), encodingUTF16LE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := decodeSource(strings.NewReader(string(tt.input)), tt.enc)
+			if err != nil {
+				t.Fatalf("decodeSource: %v", err)
+			}
+			got, _ := io.ReadAll(r)
+			if string(got) != text {
+				t.Errorf("decoded %q, want %q", got, text)
+			}
+		})
+	}
+}
+
+func TestDecodeSourceUnknownEncoding(t *testing.T) {
+	if _, err := decodeSource(strings.NewReader("x"), "ebcdic"); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
+
+func TestDecodeUTF16Streams(t *testing.T) {
+	// A surrogate pair, an unpaired high and low surrogate, and a trailing
+	// odd byte, decoded through reads of every size.
+	units := append(utf16.Encode([]rune("a\U0001F600b")), 0xD800, 'c', 0xDC00)
+	var data []byte
+	for _, u := range units {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	want := string(utf16.Decode(units))
+	r := decodeUTF16(bufio.NewReader(bytes.NewReader(append(data, 0x41))), binary.LittleEndian)
+	if err := iotest.TestReader(r, []byte(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScanUTF16CRLFLineNumbers(t *testing.T) {
+	root := t.TempDir()
+	src := "import os\r\n\r\neval(user_input)\r\n"
+	writeFile(t, filepath.Join(root, "win.py"), string(encodeUTF16(src, binary.LittleEndian, true)))
+
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 1 {
+		t.Fatalf("expected 1 TRIAGE-001 finding in UTF-16 file, got %d", len(found))
+	}
+	if line := found[0].GetLocation().GetStartLine(); line != 3 {
+		t.Errorf("expected finding on line 3, got %d", line)
+	}
+	if strings.Contains(found[0].GetMessage(), "\r") {
+		t.Error("finding message should not contain a carriage return")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Sentinel errors returned by the tool handlers. Match them with errors.Is;
+// over gRPC they surface as the status codes listed in errorCodes.
+var (
+	// ErrWorkspaceNotFound means the workspace root does not exist or is not a directory.
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	// ErrInvalidInput means a tool input has an unsupported value.
+	ErrInvalidInput = errors.New("invalid tool input")
+	// ErrProviderConfig means the AI provider environment is incomplete or invalid.
+	ErrProviderConfig = errors.New("invalid AI provider configuration")
+	// ErrScanTimeout means the scan deadline expired before the walk finished.
+	ErrScanTimeout = errors.New("scan timed out")
+	// ErrUnreadableFile means a file or directory could not be read during a
+	// strict scan.
+	ErrUnreadableFile = errors.New("unreadable file")
+	// ErrWorkspaceLocked means the input named a workspace root other than
+	// the host's while NOX_TRIAGE_LOCK_WORKSPACE is set.
+	ErrWorkspaceLocked = errors.New("workspace locked")
+)
+
+// errorCodes maps each sentinel error to the gRPC status code hosts receive.
+var errorCodes = map[error]codes.Code{
+	ErrWorkspaceNotFound: codes.NotFound,
+	ErrInvalidInput:      codes.InvalidArgument,
+	ErrProviderConfig:    codes.FailedPrecondition,
+	ErrScanTimeout:       codes.DeadlineExceeded,
+	ErrUnreadableFile:    codes.Unavailable,
+	ErrWorkspaceLocked:   codes.PermissionDenied,
+}
+
+// toolError pairs a sentinel error with a detailed message.
+type toolError struct {
+	kind error
+	msg  string
+}
+
+// newToolError returns an error that matches kind with errors.Is and carries
+// the formatted detail message.
+func newToolError(kind error, format string, args ...any) error {
+	return &toolError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *toolError) Error() string { return e.kind.Error() + ": " + e.msg }
+
+func (e *toolError) Unwrap() error { return e.kind }
+
+// GRPCStatus lets the gRPC server report the sentinel's status code instead of
+// codes.Unknown.
+func (e *toolError) GRPCStatus() *status.Status {
+	code, ok := errorCodes[e.kind]
+	if !ok {
+		code = codes.Unknown
+	}
+	return status.New(code, e.Error())
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestToolErrorMatchesSentinel(t *testing.T) {
+	err := newToolError(ErrWorkspaceNotFound, "%s", "/missing")
+	if !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Error("expected errors.Is to match ErrWorkspaceNotFound")
+	}
+	if errors.Is(err, ErrScanTimeout) {
+		t.Error("did not expect errors.Is to match ErrScanTimeout")
+	}
+	if got := status.Code(err); got != codes.NotFound {
+		t.Errorf("status code = %v, want NotFound", got)
+	}
+}
+
+func TestResolveProviderConfigError(t *testing.T) {
+	t.Setenv("NOX_AI_PROVIDER", "openai")
+	t.Setenv("NOX_AI_API_KEY", "")
+
+	_, _, err := resolveProvider(nil)
+	if !errors.Is(err, ErrProviderConfig) {
+		t.Errorf("expected ErrProviderConfig, got %v", err)
+	}
+}
+
+func TestScanTimeoutError(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := handleScan(ctx, sdk.ToolRequest{Input: map[string]any{"workspace_root": testdataDir(t)}})
+	if !errors.Is(err, ErrScanTimeout) {
+		t.Errorf("expected ErrScanTimeout, got %v", err)
+	}
+}
+
+func TestScanCancelledReturnsPartialResults(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		posts.Add(1)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		grace     float64
+		wantPosts int32
+	}{
+		{grace: 0, wantPosts: 0},
+		{grace: 5000, wantPosts: 1},
+	} {
+		posts.Store(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		resp, err := handleScan(ctx, sdk.ToolRequest{Input: map[string]any{
+			"workspace_root":  testdataDir(t),
+			"webhook_url":     srv.URL,
+			"cancel_grace_ms": tc.grace,
+		}})
+		if err != nil {
+			t.Fatalf("grace %v: a cancelled scan should not fail: %v", tc.grace, err)
+		}
+		var flagged bool
+		for _, d := range resp.GetDiagnostics() {
+			if strings.HasPrefix(d.GetMessage(), "cancelled: true") {
+				flagged = true
+			}
+		}
+		if !flagged {
+			t.Errorf("grace %v: expected a cancelled diagnostic", tc.grace)
+		}
+		if got := posts.Load(); got != tc.wantPosts {
+			t.Errorf("grace %v: webhook called %d time(s), want %d", tc.grace, got, tc.wantPosts)
+		}
+	}
+}
+
+func TestScanMissingWorkspaceStatusCode(t *testing.T) {
+	client := testClient(t)
+	input, _ := structpb.NewStruct(map[string]any{
+		"workspace_root": filepath.Join(t.TempDir(), "does-not-exist"),
+	})
+	_, err := client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{
+		ToolName: "scan",
+		Input:    input,
+	})
+	if got := status.Code(err); got != codes.NotFound {
+		t.Errorf("status code = %v, want NotFound (err: %v)", got, err)
+	}
+}
+
+func TestScanStrictUnreadableFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Symlink(filepath.Join(root, "missing.py"), filepath.Join(root, "broken.py")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	client := testClient(t)
+	input, _ := structpb.NewStruct(map[string]any{"workspace_root": root, "strict": true})
+	_, err := client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{
+		ToolName: "scan",
+		Input:    input,
+	})
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("status code = %v, want Unavailable (err: %v)", got, err)
+	}
+}
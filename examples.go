@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+// maxTriageExamples caps the few-shot examples sent with every batch, since
+// each one costs tokens on every request.
+const maxTriageExamples = 10
+
+// triageExample is one few-shot example from NOX_AI_EXAMPLES_FILE: a finding
+// as the prompt presents it and the adjustment the team wants for it.
+type triageExample struct {
+	Finding    exampleFinding    `json:"finding"`
+	Adjustment exampleAdjustment `json:"adjustment"`
+}
+
+// exampleFinding holds the finding fields the triage prompt sends.
+type exampleFinding struct {
+	RuleID   string `json:"rule_id"`
+	Severity string `json:"severity,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	Context  string `json:"context,omitempty"`
+}
+
+// exampleAdjustment holds the response fields the model is asked for.
+type exampleAdjustment struct {
+	AdjustedSeverity string `json:"adjusted_severity,omitempty"`
+	AdjustedPriority string `json:"adjusted_priority,omitempty"`
+	Classification   string `json:"classification,omitempty"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// loadTriageExamples reads a JSON array of examples from path. A missing or
+// malformed file is logged and gives no examples, so AI triage still runs
+// without them. Entries without a rule ID or any adjustment are skipped,
+// and only the first maxTriageExamples are kept.
+func loadTriageExamples(path string) []triageExample {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("ai_triage: ignoring NOX_AI_EXAMPLES_FILE: %v", err)
+		return nil
+	}
+	var all []triageExample
+	if err := json.Unmarshal(data, &all); err != nil {
+		log.Printf("ai_triage: ignoring NOX_AI_EXAMPLES_FILE %s: %v", path, err)
+		return nil
+	}
+	var examples []triageExample
+	for i, ex := range all {
+		if ex.Finding.RuleID == "" || ex.Adjustment == (exampleAdjustment{}) {
+			log.Printf("ai_triage: skipping example %d in %s: it needs a finding rule_id and an adjustment", i, path)
+			continue
+		}
+		examples = append(examples, ex)
+	}
+	if len(examples) > maxTriageExamples {
+		log.Printf("ai_triage: using the first %d of %d examples in %s", maxTriageExamples, len(examples), path)
+		examples = examples[:maxTriageExamples]
+	}
+	return examples
+}
+
+// examplesPrompt renders examples for the system prompt, or "" for none.
+func examplesPrompt(examples []triageExample) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	return "\n\nFollow the conventions of these triaged examples, each a finding and the adjustment wanted for it:\n\n" + promptJSON(examples)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func TestLoadTriageExamples(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "examples.json")
+	writeFile(t, path, `[
+  {"finding": {"rule_id": "TRIAGE-021", "file": "deploy/staging.py", "message": "Hardcoded internal address"},
+   "adjustment": {"adjusted_severity": "info", "classification": "false_positive", "reason": "Staging only"}},
+  {"finding": {"file": "app.py"}, "adjustment": {"classification": "true_positive"}},
+  {"finding": {"rule_id": "TRIAGE-001"}, "adjustment": {}}
+]`)
+
+	examples := loadTriageExamples(path)
+	if len(examples) != 1 || examples[0].Finding.RuleID != "TRIAGE-021" || examples[0].Adjustment.Reason != "Staging only" {
+		t.Fatalf("expected the one complete example, got %+v", examples)
+	}
+
+	prompt := (&triageConfig{Examples: examples}).systemPrompt()
+	if !strings.HasPrefix(prompt, triageSystemPrompt) ||
+		!strings.Contains(prompt, `"rule_id": "TRIAGE-021"`) ||
+		!strings.Contains(prompt, `"classification": "false_positive"`) {
+		t.Errorf("expected the example after the system prompt, got:\n%s", prompt)
+	}
+	if (&triageConfig{}).systemPrompt() != triageSystemPrompt {
+		t.Error("expected no examples section without examples")
+	}
+}
+
+func TestLoadTriageExamplesLimit(t *testing.T) {
+	var entries []string
+	for i := range maxTriageExamples + 5 {
+		entries = append(entries, fmt.Sprintf(`{"finding": {"rule_id": "TRIAGE-%03d"}, "adjustment": {"classification": "needs_review"}}`, i))
+	}
+	path := filepath.Join(t.TempDir(), "examples.json")
+	writeFile(t, path, "["+strings.Join(entries, ",")+"]")
+
+	if got := len(loadTriageExamples(path)); got != maxTriageExamples {
+		t.Errorf("expected %d examples, got %d", maxTriageExamples, got)
+	}
+}
+
+func TestLoadTriageExamplesUnusable(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "bad.json"), `{"finding": {}}`)
+	for _, path := range []string{filepath.Join(dir, "missing.json"), filepath.Join(dir, "bad.json")} {
+		if examples := loadTriageExamples(path); examples != nil {
+			t.Errorf("%s: expected no examples, got %+v", filepath.Base(path), examples)
+		}
+	}
+}
+
+func TestEstimateTriageCostCountsExamples(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "examples.json"), `[{"finding": {"rule_id": "TRIAGE-001", "message": "`+strings.Repeat("x", 4000)+`"}, "adjustment": {"classification": "true_positive"}}]`)
+	findings := []*pluginv1.Finding{{RuleId: "TRIAGE-001", Location: &pluginv1.Location{FilePath: "app.py", StartLine: 1}}}
+
+	without, err := estimateTriageCost(findings, "test-model", nil, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	with, err := estimateTriageCost(findings, "test-model", settings{"NOX_AI_EXAMPLES_FILE": "examples.json"}, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if with.InputTokens < without.InputTokens+1000 {
+		t.Errorf("expected the examples counted, got %d tokens with and %d without", with.InputTokens, without.InputTokens)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// flushIncompatible lists the inputs that need every finding in memory at
+// once, which flush_threshold rules out.
+var flushIncompatible = []string{
+	"ai_triage", "estimate_cost", "dedupe", "dedupe_copies", "baseline_file",
+	"generate_baseline", "base_ref", "webhook_url", "sort_by", "group_by", "affected_files",
+	"priority_sla",
+}
+
+// findingFlusher streams findings to output_file as the scan produces them
+// once more than threshold have been gathered. From then on each file's
+// findings are adjusted, filtered by drop_below, counted, and written as
+// NDJSON, and only the first threshold findings stay in memory for the
+// response. Below the threshold nothing is flushed and the scan finishes as
+// usual.
+type findingFlusher struct {
+	ctx       context.Context
+	path      string
+	gz        bool
+	threshold int
+	adjusters []Adjuster
+	dropBelow pluginv1.Severity
+
+	out      *outputFile
+	window   []*pluginv1.Finding
+	counts   findingCounts
+	written  int
+	dropped  int
+	warnings []string
+	err      error
+}
+
+// newFindingFlusher returns a flusher writing to path, or nil when threshold
+// is not positive.
+func newFindingFlusher(ctx context.Context, path string, gz bool, threshold int, adjusters []Adjuster, dropBelow pluginv1.Severity, levels priorityLevels) *findingFlusher {
+	if threshold <= 0 {
+		return nil
+	}
+	return &findingFlusher{
+		ctx:       ctx,
+		path:      path,
+		gz:        gz,
+		threshold: threshold,
+		adjusters: adjusters,
+		dropBelow: dropBelow,
+		counts:    countFindings(nil, levels),
+	}
+}
+
+// flushed reports whether findings have been streamed to the file, in
+// which case the response holds only the window.
+func (fl *findingFlusher) flushed() bool {
+	return fl != nil && fl.out != nil
+}
+
+// flush moves the findings gathered in resp to the file once there are more
+// than the threshold. It is called after each scanned file; a write error
+// stops further flushing and is returned by finish.
+func (fl *findingFlusher) flush(resp *sdk.ResponseBuilder) {
+	if fl == nil || fl.err != nil {
+		return
+	}
+	built := resp.Build()
+	if fl.out == nil {
+		if len(built.GetFindings()) <= fl.threshold {
+			return
+		}
+		if fl.out, fl.err = createOutputFile(fl.path, fl.gz); fl.err != nil {
+			return
+		}
+	}
+	batch := built.GetFindings()
+	built.Findings = nil
+	fl.write(batch)
+}
+
+// write processes one batch of findings and appends it to the file.
+func (fl *findingFlusher) write(batch []*pluginv1.Finding) {
+	chunk := &pluginv1.InvokeToolResponse{Findings: batch}
+	runAdjusters(fl.ctx, chunk, fl.adjusters)
+	for _, d := range chunk.GetDiagnostics() {
+		if !slices.Contains(fl.warnings, d.GetMessage()) {
+			fl.warnings = append(fl.warnings, d.GetMessage())
+		}
+	}
+	if fl.dropBelow != pluginv1.Severity(0) {
+		var dropped int
+		batch, dropped = dropBelow(batch, fl.dropBelow)
+		fl.dropped += dropped
+	}
+	fl.counts.add(batch)
+	if room := fl.threshold - len(fl.window); room > 0 {
+		fl.window = append(fl.window, batch[:min(room, len(batch))]...)
+	}
+	if fl.err = writeNDJSON(fl.out.Writer, batch); fl.err == nil {
+		fl.written += len(batch)
+	}
+}
+
+// finish writes the findings left in built, closes the file, and replaces
+// the response findings with the window. It does nothing if no findings
+// were flushed.
+func (fl *findingFlusher) finish(built *pluginv1.InvokeToolResponse) error {
+	if !fl.flushed() {
+		return nil
+	}
+	if fl.err == nil {
+		fl.write(built.GetFindings())
+	}
+	if err := fl.out.close(); fl.err == nil {
+		fl.err = err
+	}
+	if fl.err != nil {
+		return fl.err
+	}
+	built.Findings = fl.window
+	for _, w := range fl.warnings {
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING, w)
+	}
+	addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+		fmt.Sprintf("flushed %d finding(s) to %s during the scan; the response holds the first %d", fl.written, fl.path, len(fl.window)))
+	return nil
+}
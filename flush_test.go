@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// readNDJSON decodes the findings written to an NDJSON output file.
+func readNDJSON(t *testing.T, path string) []*pluginv1.Finding {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var findings []*pluginv1.Finding
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		finding := new(pluginv1.Finding)
+		if err := protojson.Unmarshal(sc.Bytes(), finding); err != nil {
+			t.Fatalf("decoding %q: %v", sc.Text(), err)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func TestScanFlushThreshold(t *testing.T) {
+	root := t.TempDir()
+	for i := range 5 {
+		writeFile(t, filepath.Join(root, fmt.Sprintf("app%d.py", i)), "result = eval(user_input)\ntoken = jwt.encode(payload, key)\n")
+	}
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root":  root,
+		"output_file":     "out.ndjson",
+		"flush_threshold": 3,
+		"drop_below":      "low",
+	})
+
+	if got := len(resp.GetFindings()); got != 3 {
+		t.Errorf("expected the response capped at 3 findings, got %d", got)
+	}
+	written := readNDJSON(t, filepath.Join(root, "out.ndjson"))
+	if len(written) != 5 {
+		t.Fatalf("expected the 5 eval findings in output_file, got %d", len(written))
+	}
+	for _, f := range written {
+		if f.GetRuleId() == "TRIAGE-004" {
+			t.Errorf("expected drop_below applied to flushed findings, got %v", f)
+		}
+	}
+
+	var summary, counts, dropped bool
+	for _, d := range resp.GetDiagnostics() {
+		msg := d.GetMessage()
+		summary = summary || strings.HasPrefix(msg, "flushed 5 finding(s) to "+filepath.Join(root, "out.ndjson"))
+		counts = counts || strings.HasPrefix(msg, "counts:") && strings.Contains(msg, "count_total=5")
+		dropped = dropped || msg == "drop_below: dropped 5 finding(s) below low"
+		if strings.HasPrefix(msg, "wrote ") {
+			t.Errorf("expected output_file left as flushed, got %q", msg)
+		}
+	}
+	if !summary || !counts || !dropped {
+		t.Errorf("expected flush, counts, and drop_below diagnostics, got %v", resp.GetDiagnostics())
+	}
+}
+
+func TestScanFlushThresholdNotReached(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root":  root,
+		"output_file":     "out.ndjson",
+		"flush_threshold": 10,
+	})
+
+	if got := len(readNDJSON(t, filepath.Join(root, "out.ndjson"))); got != len(resp.GetFindings()) || got == 0 {
+		t.Errorf("expected output_file written at the end with every finding, got %d of %d", got, len(resp.GetFindings()))
+	}
+	for _, d := range resp.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "flushed ") {
+			t.Errorf("expected no flush under the threshold, got %q", d.GetMessage())
+		}
+	}
+}
+
+func TestScanFlushThresholdInvalid(t *testing.T) {
+	client := testClient(t)
+	for _, extra := range []map[string]any{
+		{"flush_threshold": -1},
+		{"flush_threshold": 10},
+		{"flush_threshold": 10, "output_file": "out.txt", "output_format": "text"},
+		{"flush_threshold": 10, "output_file": "out.ndjson", "ai_triage": true},
+		{"flush_threshold": 10, "output_file": "out.ndjson", "sort_by": "severity"},
+	} {
+		fields := map[string]any{"workspace_root": t.TempDir()}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+		if got := status.Code(err); got != codes.InvalidArgument || !strings.Contains(err.Error(), "flush_threshold") {
+			t.Errorf("%v: expected an invalid argument error, got %v", extra, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// frameworkSignature recognizes a web framework by an import in a source
+// file or a dependency in a project manifest.
+type frameworkSignature struct {
+	Name     string
+	Import   *regexp.Regexp
+	Manifest *regexp.Regexp
+}
+
+// frameworkSignatures lists the frameworks detected per language, most
+// specific first: when a file imports several, as a Gin handler also
+// importing net/http does, the earliest in the list is reported.
+var frameworkSignatures = map[string][]frameworkSignature{
+	".go": {
+		{"gin", regexp.MustCompile(`"github\.com/gin-gonic/gin"`), regexp.MustCompile(`(?m)^\s*(require\s+)?github\.com/gin-gonic/gin\s`)},
+		{"echo", regexp.MustCompile(`"github\.com/labstack/echo(/v\d+)?"`), regexp.MustCompile(`(?m)^\s*(require\s+)?github\.com/labstack/echo(/v\d+)?\s`)},
+		{"fiber", regexp.MustCompile(`"github\.com/gofiber/fiber(/v\d+)?"`), regexp.MustCompile(`(?m)^\s*(require\s+)?github\.com/gofiber/fiber(/v\d+)?\s`)},
+		{"chi", regexp.MustCompile(`"github\.com/go-chi/chi(/v\d+)?"`), regexp.MustCompile(`(?m)^\s*(require\s+)?github\.com/go-chi/chi(/v\d+)?\s`)},
+		{"gorilla", regexp.MustCompile(`"github\.com/gorilla/mux"`), regexp.MustCompile(`(?m)^\s*(require\s+)?github\.com/gorilla/mux\s`)},
+		{"net/http", regexp.MustCompile(`"net/http"`), nil},
+	},
+	".py": {
+		{"django", regexp.MustCompile(`^\s*(from|import)\s+django\b`), regexp.MustCompile(`(?im)(^\s*|["'])django\b`)},
+		{"fastapi", regexp.MustCompile(`^\s*(from|import)\s+fastapi\b`), regexp.MustCompile(`(?im)(^\s*|["'])fastapi\b`)},
+		{"flask", regexp.MustCompile(`^\s*(from|import)\s+flask\b`), regexp.MustCompile(`(?im)(^\s*|["'])flask\b`)},
+		{"tornado", regexp.MustCompile(`^\s*(from|import)\s+tornado\b`), regexp.MustCompile(`(?im)(^\s*|["'])tornado\b`)},
+		{"aiohttp", regexp.MustCompile(`^\s*(from|import)\s+aiohttp\b`), regexp.MustCompile(`(?im)(^\s*|["'])aiohttp\b`)},
+	},
+	".js": jsFrameworkSignatures,
+	".ts": jsFrameworkSignatures,
+}
+
+// jsFrameworkSignatures serve JavaScript and TypeScript, matching both
+// import statements and require calls.
+var jsFrameworkSignatures = []frameworkSignature{
+	{"nestjs", regexp.MustCompile(`(from\s+|require\(\s*)["']@nestjs/`), regexp.MustCompile(`"@nestjs/core"\s*:`)},
+	{"nextjs", regexp.MustCompile(`(from\s+|require\(\s*)["']next(/[\w/-]+)?["']`), regexp.MustCompile(`"next"\s*:`)},
+	{"fastify", regexp.MustCompile(`(from\s+|require\(\s*)["']fastify["']`), regexp.MustCompile(`"fastify"\s*:`)},
+	{"koa", regexp.MustCompile(`(from\s+|require\(\s*)["']koa["']`), regexp.MustCompile(`"koa"\s*:`)},
+	{"hapi", regexp.MustCompile(`(from\s+|require\(\s*)["']@hapi/hapi["']`), regexp.MustCompile(`"@hapi/hapi"\s*:`)},
+	{"express", regexp.MustCompile(`(from\s+|require\(\s*)["']express["']`), regexp.MustCompile(`"express"\s*:`)},
+}
+
+// frameworkManifests lists the project files whose dependencies name the
+// framework, per language.
+var frameworkManifests = map[string][]string{
+	".go": {"go.mod"},
+	".py": {"requirements.txt", "pyproject.toml", "Pipfile", "setup.py"},
+	".js": {"package.json"},
+	".ts": {"package.json"},
+}
+
+// projectFrameworks returns the framework each language's manifests in root
+// depend on, keyed by extension. Files that import a framework themselves
+// are tagged by their imports instead; see fileFramework.
+func projectFrameworks(root string) map[string]string {
+	found := make(map[string]string)
+	contents := make(map[string]string)
+	for ext, names := range frameworkManifests {
+		for _, name := range names {
+			data, ok := contents[name]
+			if !ok {
+				b, _ := os.ReadFile(filepath.Join(root, name))
+				data = string(b)
+				contents[name] = data
+			}
+			if data == "" {
+				continue
+			}
+			for _, sig := range frameworkSignatures[ext] {
+				if sig.Manifest != nil && sig.Manifest.MatchString(data) {
+					found[ext] = sig.Name
+					break
+				}
+			}
+			if found[ext] != "" {
+				break
+			}
+		}
+	}
+	return found
+}
+
+// fileFramework tracks the framework imported by one source file as its
+// lines are scanned.
+type fileFramework struct {
+	sigs []frameworkSignature
+	best int // index into sigs, or len(sigs) when none matched
+}
+
+func newFileFramework(ext string) *fileFramework {
+	sigs := frameworkSignatures[ext]
+	return &fileFramework{sigs: sigs, best: len(sigs)}
+}
+
+// observe checks one line for a framework import.
+func (f *fileFramework) observe(line string) {
+	if !strings.Contains(line, "import") && !strings.Contains(line, "require") && !strings.Contains(line, `"`) {
+		return
+	}
+	for i, sig := range f.sigs[:f.best] {
+		if sig.Import.MatchString(line) {
+			f.best = i
+			return
+		}
+	}
+}
+
+// name returns the imported framework, or fallback if the file imports
+// none.
+func (f *fileFramework) name(fallback string) string {
+	if f.best < len(f.sigs) {
+		return f.sigs[f.best].Name
+	}
+	return fallback
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func TestFileFrameworkPrefersMostSpecific(t *testing.T) {
+	fw := newFileFramework(".go")
+	fw.observe(`	"net/http"`)
+	fw.observe(`	"github.com/gin-gonic/gin"`)
+	fw.observe(`	"net/http"`)
+	if got := fw.name(""); got != "gin" {
+		t.Errorf("expected gin to win over net/http, got %q", got)
+	}
+	if got := newFileFramework(".rb").name("rails"); got != "rails" {
+		t.Errorf("expected the fallback for unknown languages, got %q", got)
+	}
+}
+
+func TestProjectFrameworks(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "requirements.txt"), "requests==2.31.0\nDjango>=4.2\n")
+	writeFile(t, filepath.Join(root, "package.json"), "{\n  \"dependencies\": {\n    \"express\": \"^4.18.2\"\n  }\n}\n")
+
+	got := projectFrameworks(root)
+	want := map[string]string{".py": "django", ".js": "express", ".ts": "express"}
+	for ext, name := range want {
+		if got[ext] != name {
+			t.Errorf("%s: expected %q, got %q", ext, name, got[ext])
+		}
+	}
+	if _, ok := got[".go"]; ok {
+		t.Errorf("expected no Go framework without go.mod, got %q", got[".go"])
+	}
+}
+
+func TestScanTagsFrameworks(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "requirements.txt"), "django==4.2\n")
+	writeFile(t, filepath.Join(root, "api.py"), "from flask import request\nresult = eval(request.args['q'])\n")
+	writeFile(t, filepath.Join(root, "legacy.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "server.js"), "const express = require('express');\nconst out = eval(req.query.q);\n")
+	writeFile(t, filepath.Join(root, "tool.go"), "package main\n\nimport \"crypto/md5\"\n")
+
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	want := map[string]string{"api.py": "flask", "legacy.py": "django", "server.js": "express", "tool.go": ""}
+	seen := make(map[string]bool)
+	for _, f := range resp.GetFindings() {
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		name, ok := want[file]
+		if !ok {
+			continue
+		}
+		seen[file] = true
+		if got := f.GetMetadata()["framework"]; got != name {
+			t.Errorf("%s %s: expected framework %q, got %q", file, f.GetRuleId(), name, got)
+		}
+	}
+	for file := range want {
+		if !seen[file] {
+			t.Errorf("expected findings in %s", file)
+		}
+	}
+}
+
+func TestTriagePromptIncludesFramework(t *testing.T) {
+	findings := []*pluginv1.Finding{{
+		RuleId:   "TRIAGE-002",
+		Location: &pluginv1.Location{FilePath: "api.py", StartLine: 2},
+		Metadata: map[string]string{"framework": "flask"},
+	}}
+	if prompt := buildTriagePrompt(findings, nil, nil); !strings.Contains(prompt, `"framework": "flask"`) {
+		t.Errorf("expected the framework in the prompt, got:\n%s", prompt)
+	}
+}
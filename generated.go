@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+)
+
+// generatedHeaderLines is how many leading lines are searched for a
+// generated-code marker.
+const generatedHeaderLines = 10
+
+// generatedMarker matches the headers code generators write: the Go
+// convention, @generated, and the protobuf and Thrift compiler banners.
+var generatedMarker = regexp.MustCompile(`(?i)(^\s*//\s*Code generated .* DO NOT EDIT\.$|@generated\b|Generated by the protocol buffer compiler|Autogenerated by Thrift)`)
+
+// isGeneratedSource reports whether the leading lines of r carry a
+// generated-code marker. It peeks without consuming, so r can then be
+// scanned from the start.
+func isGeneratedSource(r *bufio.Reader) bool {
+	head, _ := r.Peek(4096)
+	for i, line := range bytes.SplitN(head, []byte("\n"), generatedHeaderLines+1) {
+		if i == generatedHeaderLines {
+			break
+		}
+		if generatedMarker.Match(bytes.TrimRight(line, "\r")) {
+			return true
+		}
+	}
+	return false
+}
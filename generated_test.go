@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsGeneratedSource(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{"go", "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage x\n", true},
+		{"go crlf", "// Code generated by mockgen. DO NOT EDIT.\r\npackage x\r\n", true},
+		{"at-generated", "/**\n * @generated\n */\nconst x = 1;\n", true},
+		{"protobuf python", "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n", true},
+		{"thrift", "/**\n * Autogenerated by Thrift Compiler (0.19.0)\n */\n", true},
+		{"hand written", "package x\n\n// Code generated here is reviewed.\n", false},
+		{"marker too deep", strings.Repeat("x = 1\n", generatedHeaderLines) + "# @generated\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGeneratedSource(bufio.NewReader(strings.NewReader(tt.src))); got != tt.want {
+				t.Errorf("isGeneratedSource = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanGeneratedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "gen.py"), "# @generated by codegen\neval(x)\n")
+	writeFile(t, filepath.Join(root, "app.py"), "eval(x)\n")
+
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 2 {
+		t.Fatalf("expected 2 TRIAGE-001 findings, got %d", len(found))
+	}
+	for _, f := range found {
+		gen := filepath.Base(f.GetLocation().GetFilePath()) == "gen.py"
+		if got := f.GetMetadata()["generated"] == "true"; got != gen {
+			t.Errorf("%s: generated tag = %v, want %v", f.GetLocation().GetFilePath(), got, gen)
+		}
+	}
+
+	resp = invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"skip_generated": true,
+	})
+	found = findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 1 || filepath.Base(found[0].GetLocation().GetFilePath()) != "app.py" {
+		t.Errorf("expected only the hand-written finding with skip_generated, got %d", len(found))
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// groupOutputByRule is the group_by value that lists findings per rule.
+const groupOutputByRule = "rule"
+
+// ruleSection lists the findings of one rule for a rule-centric review.
+type ruleSection struct {
+	RuleID      string
+	Description string
+	Locations   []string
+}
+
+// String renders the section as reported in the group_by diagnostics: a
+// header line followed by one indented path:line per finding.
+func (s ruleSection) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rule %s: %s (%d finding(s))", s.RuleID, s.Description, len(s.Locations))
+	for _, loc := range s.Locations {
+		fmt.Fprintf(&b, "\n  %s", loc)
+	}
+	return b.String()
+}
+
+// ruleSections groups findings by rule ID, with paths relative to root.
+// Sections are ordered by rule ID and locations by path and line; the
+// findings themselves are left untouched.
+func ruleSections(root string, findings []*pluginv1.Finding) []ruleSection {
+	descriptions := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		descriptions[rule.ID] = rule.Desc
+	}
+
+	type location struct {
+		path string
+		line int32
+	}
+	byRule := make(map[string][]location)
+	for _, f := range findings {
+		file, line := findingLocation(f)
+		byRule[f.GetRuleId()] = append(byRule[f.GetRuleId()], location{relativeFindingPath(root, file), line})
+		if _, ok := descriptions[f.GetRuleId()]; !ok {
+			descriptions[f.GetRuleId()] = f.GetMessage()
+		}
+	}
+
+	sections := make([]ruleSection, 0, len(byRule))
+	for id, locs := range byRule {
+		sort.Slice(locs, func(i, j int) bool {
+			if locs[i].path != locs[j].path {
+				return locs[i].path < locs[j].path
+			}
+			return locs[i].line < locs[j].line
+		})
+		section := ruleSection{RuleID: id, Description: descriptions[id]}
+		for _, loc := range locs {
+			section.Locations = append(section.Locations, fmt.Sprintf("%s:%d", loc.path, loc.line))
+		}
+		sections = append(sections, section)
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].RuleID < sections[j].RuleID })
+	return sections
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestRuleSections(t *testing.T) {
+	finding := func(rule, file string, line int32) *pluginv1.Finding {
+		return &pluginv1.Finding{
+			RuleId:   rule,
+			Message:  "custom message",
+			Location: &pluginv1.Location{FilePath: "/repo/" + file, StartLine: line},
+		}
+	}
+	findings := []*pluginv1.Finding{
+		finding("TRIAGE-002", "b.py", 9),
+		finding("TRIAGE-001", "b.py", 4),
+		finding("TRIAGE-002", "a.py", 30),
+		finding("TRIAGE-002", "b.py", 2),
+		finding("CUSTOM-1", "c.js", 1),
+	}
+
+	got := ruleSections("/repo", findings)
+	var ids []string
+	for _, s := range got {
+		ids = append(ids, s.RuleID)
+	}
+	if strings.Join(ids, ",") != "CUSTOM-1,TRIAGE-001,TRIAGE-002" {
+		t.Fatalf("unexpected section order %v", ids)
+	}
+	if got[0].Description != "custom message" {
+		t.Errorf("unknown rules should fall back to the finding message, got %q", got[0].Description)
+	}
+	if strings.Join(got[2].Locations, ",") != "a.py:30,b.py:2,b.py:9" {
+		t.Errorf("unexpected locations %v", got[2].Locations)
+	}
+	want := "rule TRIAGE-002: " + got[2].Description + " (3 finding(s))\n  a.py:30\n  b.py:2\n  b.py:9"
+	if s := got[2].String(); s != want {
+		t.Errorf("unexpected rendering %q", s)
+	}
+}
+
+func TestScanGroupByRule(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "b.py"), "eval(y)\n")
+
+	client := testClient(t)
+	plain := invokeScan(t, client, root)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"group_by":       "rule",
+	})
+	if len(resp.GetFindings()) != len(plain.GetFindings()) {
+		t.Errorf("group_by should not change the findings: got %d, want %d", len(resp.GetFindings()), len(plain.GetFindings()))
+	}
+
+	var sections []string
+	for _, d := range resp.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "rule ") {
+			sections = append(sections, d.GetMessage())
+		}
+	}
+	if len(sections) != 1 || !strings.HasSuffix(sections[0], "(2 finding(s))\n  a.py:1\n  b.py:1") {
+		t.Errorf("expected one section listing both files, got %q", sections)
+	}
+}
+
+func TestScanGroupByUnsupported(t *testing.T) {
+	client := testClient(t)
+	input, _ := structpb.NewStruct(map[string]any{
+		"workspace_root": t.TempDir(),
+		"group_by":       "severity",
+	})
+	_, err := client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an unknown group_by, got %v (%v)", got, err)
+	}
+}
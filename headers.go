@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// headerName matches a valid HTTP header field name (RFC 9110 token).
+var headerName = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// parseHeaders reads NOX_AI_HEADERS: either a JSON object of string values or
+// "name=value" pairs separated by semicolons.
+func parseHeaders(raw string) (http.Header, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	pairs := make(map[string]string)
+	if strings.HasPrefix(raw, "{") {
+		if err := json.Unmarshal([]byte(raw), &pairs); err != nil {
+			return nil, fmt.Errorf("NOX_AI_HEADERS: %w", err)
+		}
+	} else {
+		for _, part := range strings.Split(raw, ";") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(part, "=")
+			if !ok {
+				return nil, fmt.Errorf("NOX_AI_HEADERS: %q is not name=value", part)
+			}
+			pairs[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+
+	header := make(http.Header, len(pairs))
+	for name, value := range pairs {
+		if !headerName.MatchString(name) {
+			return nil, fmt.Errorf("NOX_AI_HEADERS: invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("NOX_AI_HEADERS: header %s contains a line break", name)
+		}
+		header.Set(name, value)
+	}
+	return header, nil
+}
+
+// redactHeaders lists header names with their values masked, for logging.
+func redactHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%s=[redacted %d chars]", name, len(header.Get(name)))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+func TestParseHeaders(t *testing.T) {
+	for _, raw := range []string{
+		`{"X-Tenant-Id": "acme", "X-Trace": "abc123"}`,
+		"X-Tenant-Id=acme; X-Trace=abc123;",
+	} {
+		h, err := parseHeaders(raw)
+		if err != nil {
+			t.Fatalf("parseHeaders(%q): %v", raw, err)
+		}
+		if h.Get("X-Tenant-Id") != "acme" || h.Get("X-Trace") != "abc123" {
+			t.Errorf("parseHeaders(%q) = %v", raw, h)
+		}
+	}
+
+	for _, raw := range []string{
+		"no-equals-sign",
+		"Bad Name=x",
+		`{"X-Evil": "a\r\nHost: other"}`,
+		`{"X-Tenant-Id": 1}`,
+	} {
+		if _, err := parseHeaders(raw); err == nil {
+			t.Errorf("expected parseHeaders(%q) to fail", raw)
+		}
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Tenant-Id", "secret-tenant")
+	got := redactHeaders(h)
+	if strings.Contains(got, "secret") || !strings.Contains(got, "X-Tenant-Id") {
+		t.Errorf("redactHeaders = %q", got)
+	}
+}
+
+func TestProviderHeadersAttached(t *testing.T) {
+	var got []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+	}))
+	defer srv.Close()
+	get := func(ctx context.Context) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	route, err := newProviderRoute(settings{"NOX_AI_HEADERS": "X-Tenant-Id=acme"}, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := routeProvider(funcProvider(func(ctx context.Context, _ plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+		get(ctx)
+		return plannerllm.CompletionResponse{}, nil
+	}), route)
+	if _, err := provider.Complete(context.Background(), plannerllm.CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	// A request made outside a completion call, like a webhook post.
+	get(context.Background())
+
+	if len(got) != 2 || got[0].Get("X-Tenant-Id") != "acme" {
+		t.Fatalf("expected X-Tenant-Id on the provider request, got %v", got)
+	}
+	if got[1].Get("X-Tenant-Id") != "" {
+		t.Errorf("expected no custom header outside the provider call, got %v", got[1])
+	}
+}
+
+func TestResolveProviderInvalidHeaders(t *testing.T) {
+	for _, s := range []settings{
+		{"NOX_AI_PROVIDER": "ollama", "NOX_AI_HEADERS": "broken", "NOX_AI_BASE_URL": "http://gateway:8080"},
+		{"NOX_AI_PROVIDER": "ollama", "NOX_AI_HEADERS": "X-Tenant-Id=acme"},
+	} {
+		if _, _, err := resolveProvider(s); !errors.Is(err, ErrProviderConfig) {
+			t.Errorf("%v: expected ErrProviderConfig, got %v", s, err)
+		}
+	}
+}
+
+func TestRouteProviderKeepsStreaming(t *testing.T) {
+	route := &providerRoute{header: http.Header{"X-Tenant-Id": {"acme"}}, host: "gateway:8080"}
+	if _, ok := routeProvider(&streamProvider{}, route).(streamingProvider); !ok {
+		t.Error("expected a routed streaming provider to still stream")
+	}
+	if _, ok := routeProvider(&mockProvider{}, route).(streamingProvider); ok {
+		t.Error("expected a routed blocking provider not to stream")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	timedTransportsMu sync.Mutex
+	// timedTransports holds the transport built for each NOX_AI_HTTP_TIMEOUT
+	// value, so repeated calls reuse its connections.
+	timedTransports = map[time.Duration]*http.Transport{}
+)
+
+// timedTransport returns a transport that limits each of dialing, the TLS
+// handshake, and waiting for response headers to timeout. It is cloned from
+// the default transport as found before installProviderTransport wrapped it,
+// and is nil if that is not an *http.Transport.
+func timedTransport(timeout time.Duration) *http.Transport {
+	timedTransportsMu.Lock()
+	defer timedTransportsMu.Unlock()
+	if timed := timedTransports[timeout]; timed != nil {
+		return timed
+	}
+	base := http.DefaultTransport
+	if pt, ok := base.(*providerTransport); ok {
+		base = pt.base
+	}
+	defaultTransport, ok := base.(*http.Transport)
+	if !ok {
+		log.Printf("ai_triage: ignoring NOX_AI_HTTP_TIMEOUT: the default HTTP transport was replaced")
+		return nil
+	}
+	timed := defaultTransport.Clone()
+	timed.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	timed.TLSHandshakeTimeout = timeout
+	timed.ResponseHeaderTimeout = timeout
+	timedTransports[timeout] = timed
+	return timed
+}
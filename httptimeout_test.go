@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+func TestHTTPTimeoutFailsSlowResponse(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	route, err := newProviderRoute(settings{"NOX_AI_HTTP_TIMEOUT": "50ms"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := routeProvider(funcProvider(func(ctx context.Context, _ plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		if err != nil {
+			return plannerllm.CompletionResponse{}, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return plannerllm.CompletionResponse{}, err
+		}
+		_ = resp.Body.Close()
+		return plannerllm.CompletionResponse{}, nil
+	}), route)
+
+	start := time.Now()
+	if _, err := provider.Complete(context.Background(), plannerllm.CompletionRequest{}); err == nil {
+		t.Fatal("expected the request to time out waiting for headers")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the timeout to fail fast, took %v", elapsed)
+	}
+}
+
+func TestResolveProviderHTTPTimeout(t *testing.T) {
+	p, _, err := resolveProvider(settings{
+		"NOX_AI_PROVIDER":     "ollama",
+		"NOX_AI_JSON_MODE":    "false",
+		"NOX_AI_HTTP_TIMEOUT": "7s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	routed, ok := p.(*routedProvider)
+	if !ok {
+		t.Fatalf("expected a routed provider, got %T", p)
+	}
+	timed := routed.route.timed
+	if timed == nil || timed.ResponseHeaderTimeout != 7*time.Second || timed.TLSHandshakeTimeout != 7*time.Second {
+		t.Fatalf("expected a 7s timed transport, got %+v", timed)
+	}
+	if timedTransport(7*time.Second) != timed {
+		t.Error("expected the timed transport to be reused")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// jsonMode names how a provider's API constrains the model to emit JSON.
+type jsonMode string
+
+const (
+	// jsonModeOff leaves the output format to the prompt.
+	jsonModeOff jsonMode = ""
+	// jsonModeOpenAI sets response_format to json_object, as OpenAI and
+	// compatible APIs such as Copilot accept. The model must then answer
+	// with an object rather than an array; see triageConfig.systemPrompt.
+	jsonModeOpenAI jsonMode = "openai"
+	// jsonModeOllama sets format to "json".
+	jsonModeOllama jsonMode = "ollama"
+	// jsonModeGemini sets generationConfig.responseMimeType to
+	// application/json.
+	jsonModeGemini jsonMode = "gemini"
+)
+
+// providerJSONModes lists the providers whose API has a native JSON mode.
+// Anthropic, Cohere, and Bedrock have none and rely on the prompt alone.
+var providerJSONModes = map[string]jsonMode{
+	"openai":  jsonModeOpenAI,
+	"copilot": jsonModeOpenAI,
+	"ollama":  jsonModeOllama,
+	"gemini":  jsonModeGemini,
+}
+
+// triageJSONMode returns the JSON mode of the configured provider, or
+// jsonModeOff if it has none or NOX_AI_JSON_MODE is set to a false value.
+func triageJSONMode(s settings) jsonMode {
+	if on, err := strconv.ParseBool(s.get("NOX_AI_JSON_MODE")); err == nil && !on {
+		return jsonModeOff
+	}
+	providerName := strings.ToLower(s.get("NOX_AI_PROVIDER"))
+	if providerName == "" {
+		providerName = "openai"
+	}
+	return providerJSONModes[providerName]
+}
+
+// withJSONModeBody returns req with the field that enables mode added to its
+// body. The provider clients do not expose the field, so providerTransport
+// adds it to the HTTP request of each completion call. Requests that are not
+// POSTs of a chat completion in JSON are returned unchanged.
+func withJSONModeBody(req *http.Request, mode jsonMode) (*http.Request, error) {
+	if mode == jsonModeOff || req.Body == nil || req.Method != http.MethodPost {
+		return req, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if patched, ok := addJSONMode(body, mode); ok {
+		body = patched
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return req, nil
+}
+
+// addJSONMode returns body with the field that enables mode added, or false
+// if body is not a chat completion request in JSON or already sets it.
+func addJSONMode(body []byte, mode jsonMode) ([]byte, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+	switch mode {
+	case jsonModeOpenAI:
+		if fields["messages"] == nil || fields["response_format"] != nil {
+			return nil, false
+		}
+		fields["response_format"] = json.RawMessage(`{"type":"json_object"}`)
+	case jsonModeOllama:
+		if fields["messages"] == nil || fields["format"] != nil {
+			return nil, false
+		}
+		fields["format"] = json.RawMessage(`"json"`)
+	case jsonModeGemini:
+		if fields["contents"] == nil {
+			return nil, false
+		}
+		config := make(map[string]json.RawMessage)
+		if raw := fields["generationConfig"]; raw != nil {
+			if err := json.Unmarshal(raw, &config); err != nil {
+				return nil, false
+			}
+		}
+		if config["responseMimeType"] != nil {
+			return nil, false
+		}
+		config["responseMimeType"] = json.RawMessage(`"application/json"`)
+		raw, err := json.Marshal(config)
+		if err != nil {
+			return nil, false
+		}
+		fields["generationConfig"] = raw
+	default:
+		return nil, false
+	}
+	patched, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+	return patched, true
+}
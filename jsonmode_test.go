@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+func TestTriageJSONMode(t *testing.T) {
+	t.Setenv("NOX_AI_JSON_MODE", "")
+	cases := []struct {
+		s    settings
+		want jsonMode
+	}{
+		{settings{}, jsonModeOpenAI},
+		{settings{"NOX_AI_PROVIDER": "Copilot"}, jsonModeOpenAI},
+		{settings{"NOX_AI_PROVIDER": "ollama"}, jsonModeOllama},
+		{settings{"NOX_AI_PROVIDER": "gemini"}, jsonModeGemini},
+		{settings{"NOX_AI_PROVIDER": "anthropic"}, jsonModeOff},
+		{settings{"NOX_AI_PROVIDER": "openai", "NOX_AI_JSON_MODE": "false"}, jsonModeOff},
+	}
+	for _, c := range cases {
+		if got := triageJSONMode(c.s); got != c.want {
+			t.Errorf("%v: got %q, want %q", c.s, got, c.want)
+		}
+	}
+}
+
+func TestAddJSONMode(t *testing.T) {
+	cases := []struct {
+		mode jsonMode
+		body string
+		want map[string]string
+	}{
+		{jsonModeOpenAI, `{"model":"gpt-4o","messages":[]}`, map[string]string{"response_format": `{"type":"json_object"}`}},
+		{jsonModeOllama, `{"model":"llama3","messages":[]}`, map[string]string{"format": `"json"`}},
+		{jsonModeGemini, `{"contents":[],"generationConfig":{"temperature":0.2}}`, map[string]string{"generationConfig": `{"responseMimeType":"application/json","temperature":0.2}`}},
+	}
+	for _, c := range cases {
+		patched, ok := addJSONMode([]byte(c.body), c.mode)
+		if !ok {
+			t.Errorf("%s: expected %s to be patched", c.mode, c.body)
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(patched, &fields); err != nil {
+			t.Fatal(err)
+		}
+		for key, want := range c.want {
+			if got := string(fields[key]); got != want {
+				t.Errorf("%s: %s = %s, want %s", c.mode, key, got, want)
+			}
+		}
+	}
+
+	for _, body := range []string{
+		`not json`,
+		`{"input":"no messages"}`,
+		`{"messages":[],"response_format":{"type":"text"}}`,
+	} {
+		if _, ok := addJSONMode([]byte(body), jsonModeOpenAI); ok {
+			t.Errorf("expected %s to be left alone", body)
+		}
+	}
+}
+
+func TestJSONModeTransport(t *testing.T) {
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	t.Cleanup(srv.Close)
+	client := &http.Client{Transport: &providerTransport{base: http.DefaultTransport}}
+	route := &providerRoute{jsonMode: jsonModeOllama}
+
+	for _, ctx := range []context.Context{
+		context.WithValue(context.Background(), providerRouteKey{}, route),
+		context.Background(),
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader(`{"messages":[]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if len(received) != 2 || !strings.Contains(received[0], `"format":"json"`) {
+		t.Errorf("expected JSON mode on the provider request, got %q", received)
+	}
+	if len(received) == 2 && received[1] != `{"messages":[]}` {
+		t.Errorf("requests outside a provider call should pass through unchanged, got %q", received[1])
+	}
+}
+
+func TestProviderRouteJSONMode(t *testing.T) {
+	route, err := newProviderRoute(settings{"NOX_AI_PROVIDER": "gemini"}, "")
+	if err != nil || route == nil || route.jsonMode != jsonModeGemini {
+		t.Errorf("expected a gemini JSON-mode route, got %+v, %v", route, err)
+	}
+	if route, err := newProviderRoute(settings{"NOX_AI_PROVIDER": "anthropic"}, ""); route != nil || err != nil {
+		t.Errorf("expected no route without JSON mode or headers, got %+v, %v", route, err)
+	}
+}
+
+func TestTriageBatchJSONMode(t *testing.T) {
+	var system string
+	provider := funcProvider(func(_ context.Context, req plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+		system = req.Messages[0].Content
+		return plannerllm.CompletionResponse{Message: plannerllm.Message{Content: `{"adjustments": [
+			{"rule_id": "TRIAGE-001", "file": "app.py", "line": 3, "adjusted_severity": "low", "classification": "false_positive", "reason": "constant"}
+		]}`}}, nil
+	})
+	finding := &pluginv1.Finding{
+		RuleId:   "TRIAGE-001",
+		Severity: pluginv1.Severity_SEVERITY_HIGH,
+		Location: &pluginv1.Location{FilePath: "app.py", StartLine: 3},
+		Metadata: map[string]string{},
+	}
+
+	triageBatch(context.Background(), provider, "gpt-4o", []*pluginv1.Finding{finding}, &triageConfig{JSONMode: jsonModeOpenAI})
+
+	if !strings.Contains(system, `{"adjustments": [...]}`) || strings.Contains(system, "JSON array.") {
+		t.Errorf("expected the prompt to ask for a wrapping object, got:\n%s", system)
+	}
+	if finding.GetSeverity() != pluginv1.Severity_SEVERITY_LOW {
+		t.Errorf("expected the wrapped adjustment to be applied, got %v (%v)", finding.GetSeverity(), finding.GetMetadata())
+	}
+}
+
+func TestParseTriageResponseWrapped(t *testing.T) {
+	adjs, err := parseTriageResponse(`{"adjustments": [{"rule_id": "TRIAGE-002", "file": "a.go", "line": 1}]}`)
+	if err != nil || len(adjs) != 1 || adjs[0].RuleID != "TRIAGE-002" {
+		t.Errorf("expected one adjustment, got %v, %v", adjs, err)
+	}
+	if _, err := parseTriageResponse(`{"results": []}`); err == nil {
+		t.Error("expected an error for an object without adjustments")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LanguageDetector picks the rule pattern set for a file the built-in
+// detection does not recognize. Detectors are consulted after the extension
+// and well-known file names, and before shebang sniffing.
+type LanguageDetector interface {
+	// DetectLanguage returns the extension key of the pattern set to use,
+	// such as ".py", or "" if it does not recognize the file. rel is the
+	// slash-separated path relative to the workspace root; head returns the
+	// first bytes of the file and is only read when called.
+	DetectLanguage(rel string, head func() []byte) string
+}
+
+// languageDetectors are the detectors added with RegisterLanguageDetector.
+var languageDetectors []LanguageDetector
+
+// RegisterLanguageDetector adds a detector for files with non-standard names.
+// Call it from an init function.
+func RegisterLanguageDetector(d LanguageDetector) {
+	languageDetectors = append(languageDetectors, d)
+}
+
+// languageExts maps language names, as reported in the language metadata,
+// to the extension key of their pattern set.
+var languageExts = map[string]string{
+	"go":         ".go",
+	"python":     ".py",
+	"javascript": ".js",
+	"typescript": ".ts",
+}
+
+// filenameLanguages maps extension-less build and task files written in a
+// supported language to its extension key.
+var filenameLanguages = map[string]string{
+	"SConstruct": ".py",
+	"SConscript": ".py",
+	"wscript":    ".py",
+	"Jakefile":   ".js",
+}
+
+// shebangLanguages maps interpreter names found in a #! line to extension
+// keys. Versioned names such as python3.12 match by prefix.
+var shebangLanguages = []struct {
+	prefix string
+	ext    string
+}{
+	{"python", ".py"},
+	{"ts-node", ".ts"},
+	{"deno", ".ts"},
+	{"tsx", ".ts"},
+	{"node", ".js"},
+	{"bun", ".js"},
+}
+
+// sniffBytes is how much of a file content-based detection looks at.
+const sniffBytes = 512
+
+// languageMapping assigns files matching a glob to a language; see
+// parseLanguageMap.
+type languageMapping struct {
+	Pattern string
+	Ext     string
+}
+
+// parseLanguageMap reads the language_map input: an object of path glob,
+// matched like severity_adjustments paths, to language name. Globs are tried
+// in lexical order and the first match wins.
+func parseLanguageMap(input map[string]any) ([]languageMapping, error) {
+	raw, _ := input["language_map"].(map[string]any)
+	mappings := make([]languageMapping, 0, len(raw))
+	for pattern, v := range raw {
+		name, _ := v.(string)
+		ext, ok := languageExts[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("language_map[%q]: unknown language %v", pattern, v)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("language_map[%q]: %v", pattern, err)
+		}
+		mappings = append(mappings, languageMapping{Pattern: pattern, Ext: ext})
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Pattern < mappings[j].Pattern })
+	return mappings, nil
+}
+
+// parseForceLanguage reads the force_language input and returns the
+// extension key of the named language's pattern set, or "" if it is unset.
+func parseForceLanguage(input map[string]any) (string, error) {
+	raw, ok := input["force_language"]
+	if !ok {
+		return "", nil
+	}
+	name, _ := raw.(string)
+	ext, ok := languageExts[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(languageExts))
+		for n := range languageExts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("force_language: unknown language %v (want one of %s)", raw, strings.Join(names, ", "))
+	}
+	return ext, nil
+}
+
+// fileLanguage returns the extension key of the pattern set for the file at
+// absPath: ForceLanguage if set, otherwise as found by detectLanguage.
+func (o *scanOptions) fileLanguage(rel, absPath string) string {
+	if o.ForceLanguage != "" {
+		return o.ForceLanguage
+	}
+	return detectLanguage(rel, absPath, o.LanguageMap)
+}
+
+// detectLanguage returns the extension key of the pattern set for the file
+// at absPath, or "" if it is not a supported language. It tries, in order,
+// the language_map input, the file extension, well-known file names,
+// registered detectors, and for files without an extension the shebang line.
+func detectLanguage(rel, absPath string, mappings []languageMapping) string {
+	rel = filepath.ToSlash(rel)
+	for _, m := range mappings {
+		if matchPathGlob(m.Pattern, rel) {
+			return m.Ext
+		}
+	}
+
+	ext := filepath.Ext(absPath)
+	if supportedExtensions[ext] || configExtensions[ext] {
+		return ext
+	}
+	if env := envFileExt(absPath); env != "" {
+		return env
+	}
+	if lang, ok := filenameLanguages[filepath.Base(absPath)]; ok {
+		return lang
+	}
+
+	var head []byte
+	read := false
+	readHead := func() []byte {
+		if !read {
+			read = true
+			head = readFileHead(absPath)
+		}
+		return head
+	}
+	for _, d := range languageDetectors {
+		if lang := d.DetectLanguage(rel, readHead); supportedExtensions[lang] {
+			return lang
+		}
+	}
+	if ext == "" {
+		return shebangLanguage(readHead())
+	}
+	return ""
+}
+
+// readFileHead returns up to sniffBytes from the start of a file. Errors
+// yield nil; the scan reports unreadable files when it opens them.
+func readFileHead(name string) []byte {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	buf := make([]byte, sniffBytes)
+	n, _ := io.ReadFull(f, buf)
+	return buf[:n]
+}
+
+// shebangLanguage returns the extension key for the interpreter named in a
+// leading #! line, following /usr/bin/env and its -S flag.
+func shebangLanguage(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bufio.NewReader(bytes.NewReader(head[2:])).ReadLine()
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := path.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = path.Base(f)
+				break
+			}
+		}
+	}
+	for _, s := range shebangLanguages {
+		if strings.HasPrefix(interp, s.prefix) {
+			return s.ext
+		}
+	}
+	return ""
+}
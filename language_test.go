@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestShebangLanguage(t *testing.T) {
+	tests := map[string]string{
+		"#!/usr/bin/env python3\nimport os\n":  ".py",
+		"#!/usr/bin/python2.7\n":               ".py",
+		"#!/usr/bin/env -S node --no-warnings": ".js",
+		"#!/usr/bin/env ts-node\n":             ".ts",
+		"#!/bin/sh\n":                          "",
+		"print('no shebang')\n":                "",
+	}
+	for head, want := range tests {
+		if got := shebangLanguage([]byte(head)); got != want {
+			t.Errorf("shebangLanguage(%q) = %q, want %q", head, got, want)
+		}
+	}
+}
+
+type prefixDetector struct{}
+
+func (prefixDetector) DetectLanguage(_ string, head func() []byte) string {
+	if strings.HasPrefix(string(head()), "// lang: go") {
+		return ".go"
+	}
+	return ""
+}
+
+func TestScanDetectsLanguageBeyondExtension(t *testing.T) {
+	saved := languageDetectors
+	t.Cleanup(func() { languageDetectors = saved })
+	RegisterLanguageDetector(prefixDetector{})
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "bin", "deploy"), "#!/usr/bin/env python3\nresult = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "SConstruct"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "gen", "handler.tmpl"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "custom.src"), "// lang: go\ncmd := exec.Command(\"sh\", \"-c\", \"ls \"+userInput)\n")
+	writeFile(t, filepath.Join(root, "notes.txt"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"language_map":   map[string]any{"gen/*.tmpl": "python"},
+	})
+
+	languages := make(map[string]string)
+	for _, f := range resp.GetFindings() {
+		rel, _ := filepath.Rel(root, f.GetLocation().GetFilePath())
+		languages[filepath.ToSlash(rel)] = f.GetMetadata()["language"]
+	}
+	want := map[string]string{
+		"bin/deploy":       "python",
+		"SConstruct":       "python",
+		"gen/handler.tmpl": "python",
+		"custom.src":       "go",
+	}
+	for file, lang := range want {
+		if languages[file] != lang {
+			t.Errorf("%s: language %q, want %q", file, languages[file], lang)
+		}
+	}
+	if _, ok := languages["notes.txt"]; ok {
+		t.Error("files in unsupported languages should not be scanned")
+	}
+}
+
+func TestParseLanguageMapInvalid(t *testing.T) {
+	for _, m := range []map[string]any{
+		{"*.tmpl": "cobol"},
+		{"[": "python"},
+		{"*.tmpl": float64(1)},
+	} {
+		if _, err := parseLanguageMap(map[string]any{"language_map": m}); err == nil {
+			t.Errorf("expected an error for %v", m)
+		}
+	}
+}
+
+func TestScanForceLanguage(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "handler.go"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "views", "page.jinja"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "notes.txt"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"force_language": "Python",
+	})
+
+	languages := make(map[string]string)
+	for _, f := range resp.GetFindings() {
+		rel, _ := filepath.Rel(root, f.GetLocation().GetFilePath())
+		languages[filepath.ToSlash(rel)] = f.GetMetadata()["language"]
+	}
+	for _, file := range []string{"handler.go", "views/page.jinja", "notes.txt"} {
+		if languages[file] != "python" {
+			t.Errorf("%s: language %q, want python", file, languages[file])
+		}
+	}
+}
+
+func TestScanForceLanguageInvalid(t *testing.T) {
+	client := testClient(t)
+	for _, extra := range []map[string]any{
+		{"force_language": "cobol"},
+		{"force_language": "python", "language_map": map[string]any{"*.tmpl": "go"}},
+	} {
+		fields := map[string]any{"workspace_root": t.TempDir()}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+		if got := status.Code(err); got != codes.InvalidArgument || !strings.Contains(err.Error(), "force_language") {
+			t.Errorf("%v: expected an invalid argument error naming force_language, got %v", extra, err)
+		}
+	}
+}
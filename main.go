@@ -3,15 +3,25 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
 	"github.com/nox-hq/nox/sdk"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var version = "dev"
@@ -23,17 +33,276 @@ type triageRule struct {
 	Severity   pluginv1.Severity
 	Confidence pluginv1.Confidence
 	Priority   string
-	Patterns   map[string]*regexp.Regexp // extension -> compiled regex
+	// Patterns maps an extension to its compiled regex. The anyExtension
+	// key applies to every supported source language without its own
+	// entry. Text matched by a group named secretGroup is masked in the
+	// finding message.
+	Patterns map[string]*regexp.Regexp
+
+	// Excludes optionally suppresses a match when the same line also matches
+	// the exclude pattern for that extension. RE2 has no negative lookahead,
+	// so "call without flag X" is expressed as a pattern plus an exclude.
+	Excludes map[string]*regexp.Regexp
+
+	// Follows optionally requires the same line or the one before it to
+	// match as well, for rules such as fetch-then-execute whose two halves
+	// are often written on adjacent lines. A line before that matched
+	// Patterns too was a complete match of its own and does not count.
+	Follows map[string]*regexp.Regexp
+
+	// Gated optionally holds a second pattern per extension that only
+	// matches once a line matching Gate has been seen in the file, for calls
+	// that are only dangerous from a package the file imports. Gate lines
+	// count even where diff filtering skips them.
+	Gated map[string]*regexp.Regexp
+	Gate  map[string]*regexp.Regexp
+
+	// WeakEvidence optionally picks out matches that are weaker evidence
+	// than the rest of the rule; a line that also matches it is reported at
+	// WeakConfidence instead of Confidence.
+	WeakEvidence   map[string]*regexp.Regexp
+	WeakConfidence pluginv1.Confidence
+
+	// Remediation is canned fix guidance copied into each finding's
+	// remediation metadata, independent of AI triage.
+	Remediation string
+
+	// CustomSeverity optionally labels the rule with a severity outside the
+	// standard five (see customSeverities). Severity must then hold the
+	// nearest standard level.
+	CustomSeverity string
+}
+
+// anyExtension is the Patterns and Excludes key for language-agnostic
+// patterns.
+const anyExtension = "*"
+
+// pattern returns the rule's pattern for ext, falling back to anyExtension
+// for source languages.
+func (r *triageRule) pattern(ext string) (*regexp.Regexp, bool) {
+	if re, ok := r.Patterns[ext]; ok || configExtensions[ext] {
+		return re, ok
+	}
+	re, ok := r.Patterns[anyExtension]
+	return re, ok
+}
+
+// exclude returns the rule's exclude pattern for ext, falling back to
+// anyExtension for source languages.
+func (r *triageRule) exclude(ext string) (*regexp.Regexp, bool) {
+	if re, ok := r.Excludes[ext]; ok || configExtensions[ext] {
+		return re, ok
+	}
+	re, ok := r.Excludes[anyExtension]
+	return re, ok
+}
+
+// follows returns the rule's Follows pattern for ext, falling back to
+// anyExtension for source languages.
+func (r *triageRule) follows(ext string) (*regexp.Regexp, bool) {
+	if re, ok := r.Follows[ext]; ok || configExtensions[ext] {
+		return re, ok
+	}
+	re, ok := r.Follows[anyExtension]
+	return re, ok
+}
+
+// gated returns the rule's Gate and Gated patterns for ext, falling back to
+// anyExtension for source languages. ok is false unless both are set.
+func (r *triageRule) gated(ext string) (gate, gated *regexp.Regexp, ok bool) {
+	gate, ok = r.Gate[ext]
+	if !ok && !configExtensions[ext] {
+		gate, ok = r.Gate[anyExtension]
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	gated, ok = r.Gated[ext]
+	if !ok && !configExtensions[ext] {
+		gated, ok = r.Gated[anyExtension]
+	}
+	return gate, gated, ok
 }
 
+// confidence returns the confidence of a match of the rule on line.
+func (r *triageRule) confidence(ext, line string) pluginv1.Confidence {
+	re, ok := r.WeakEvidence[ext]
+	if !ok && !configExtensions[ext] {
+		re, ok = r.WeakEvidence[anyExtension]
+	}
+	if ok && re.MatchString(line) {
+		return r.WeakConfidence
+	}
+	return r.Confidence
+}
+
+// extensions returns the supported extensions the rule has a pattern for,
+// sorted.
+func (r *triageRule) extensions() []string {
+	var exts []string
+	for _, set := range []map[string]bool{supportedExtensions, configExtensions} {
+		for ext := range set {
+			if _, ok := r.pattern(ext); ok {
+				exts = append(exts, ext)
+			}
+		}
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// Heuristics for TRIAGE-025, matched against the source text of a regex
+// literal. nestedQuantifier is a parenthesized group containing + or * that
+// is followed by another quantifier; overlappingAlternation is a repeated
+// alternation of two classes that can match the same character, such as
+// (\w|\d)+, allowing for the doubled backslashes of string literals.
+const (
+	nestedQuantifier       = `\([^()]*[+*][^()]*\)[+*{]`
+	overlappingAlternation = `\((\.|\\{1,2}[wWdDsS])[+*]?\|(\.|\\{1,2}[wWdDsS])[+*]?\)[+*{]`
+)
+
+// configSecret matches a key that names a credential assigned a value of
+// eight or more characters in any of the configExtensions formats: KEY=value
+// in .env and .properties, key: value in YAML, "key": "value" in JSON, and
+// key = "value" in TOML. Values starting with $, {, or < are references or
+// templates and do not match.
+const configSecret = `(?i)^\s*(export\s+)?["']?[\w.-]*(passw(or)?d|pwd|secret|token|api[_.-]?key|access[_.-]?key|private[_.-]?key|auth[_.-]?key|credentials?|conn(ection)?[_.-]?string)[\w.-]*["']?\s*[:=]\s*["']?(?P<` + secretGroup + `>[^\s"'#,${}<>]{8,})`
+
+// goRequestValue matches the request accessors of net/http and the common Go
+// routers whose values the client controls; redirectTargetName matches the
+// variable names that conventionally hold a redirect target taken from them.
+const (
+	goRequestValue     = `(r\.URL\.(Query\(\)|RawQuery)|r\.(Form|PostForm)\b|r\.(FormValue|PostFormValue|PathValue|Referer)\(|r\.Header\.Get\(|mux\.Vars\(|chi\.URLParam\(|c\.(Query|DefaultQuery|Param|FormValue)\()`
+	redirectTargetName = `(?i:next|redirect\w*|return\w*|target\w*|dest\w*|continue\w*|callback\w*)\b`
+)
+
+// Heuristics for TRIAGE-028. shellDownload is a curl or wget command line,
+// including one embedded in a string, and pipeToShell pipes into a shell.
+const (
+	shellDownload = `\b(curl|wget)\s`
+	pipeToShell   = `\|\s*(sudo\s+)?(ba|z|da)?sh\b`
+)
+
+// Heuristics for TRIAGE-029. corsAnyOrigin is a CORS policy admitting every
+// origin, written the same way in every language; configDebug is a debug,
+// development-environment, or allow-all-origins setting in a configuration
+// file.
+const (
+	corsAnyOrigin = `(?i:["']Access-Control-Allow-Origin["']\s*,\s*["']\*["']|\bAllowAnyOrigin\(\))`
+	configDebug   = `(?i)^\s*(export\s+)?["']?([\w.-]*(debug|debug_mode|debugmode)["']?\s*[:=]\s*["']?(true|1|on|yes)\b|(node_env|flask_env|app_env|rails_env|environment)["']?\s*[:=]\s*["']?(development|dev)\b|[\w.-]*(allow[_.-]?all[_.-]?origins|origin[_.-]?allow[_.-]?all)["']?\s*[:=]\s*["']?(true|1|on|yes)\b|[\w.-]*(allowed[_.-]?origins|allow[_.-]?origins?|cors[_.-]?origins?)["']?\s*[:=]\s*\[?\s*["']?\*["']?\s*\]?\s*,?\s*$|management\.endpoints\.web\.exposure\.include\s*[:=]\s*["']?\*)`
+)
+
+// Heuristics for TRIAGE-030, matching a request value in the first argument
+// of a template constructor or render call, that is before the first comma,
+// so user input passed as template data in later arguments is not flagged.
+const (
+	pyTemplateSource = `\b(render_template_string|Template|from_string)\(\s*[^,]*?\brequest\.(args|form|values|json|data|GET|POST|query_params|get_json|cookies|headers)\b`
+	jsTemplateSource = `\b(Handlebars\.compile|handlebars\.compile|ejs\.(render|compile)|pug\.(render|compile)|_\.template|lodash\.template|nunjucks\.renderString|env\.renderString|doT\.template|Hogan\.compile|new\s+Template|Mustache\.render|mustache\.render|eta\.render(String)?|Twig\.twig)\(\s*[^,]*?\breq\.(body|query|params|headers|cookies)\b`
+)
+
+// Heuristics for TRIAGE-031: certificate verification callbacks that accept
+// everything. goVerifyCallback opens a crypto/tls callback and, as the
+// rule's Follows pattern, lets its "return nil" sit on the next line.
+const (
+	goVerifyCallback = `\b(VerifyPeerCertificate|VerifyConnection)\s*[:=]\s*func\s*\([^)]*\)\s*error\s*\{`
+	jsNoopCertVerify = `\bcheckServerIdentity\s*[:=]\s*(\(?[^)=]*\)?\s*=>\s*(\{\s*(return\s*(undefined|null)?\s*;?\s*)?\}|undefined|null)|function\s*\w*\s*\([^)]*\)\s*\{\s*(return\s*(undefined|null)?\s*;?\s*)?\})`
+	pyCertVerifyOff  = `\b((cert_reqs|verify_mode)\s*=\s*(ssl\.)?CERT_NONE\b|check_hostname\s*=\s*False\b|ssl\._create_unverified_context\b)`
+)
+
+// Heuristics for TRIAGE-032, matching a method or attribute name taken from
+// the request in a dynamic lookup: the name must come from the request on
+// the same line.
+const (
+	pyReflectedName = `\bgetattr\(\s*[^,]+,\s*[^,)]*\brequest\.(args|form|values|json|data|GET|POST|query_params|get_json|headers|cookies)\b`
+	jsReflectedCall = `[\w)\]]\s*\[\s*req\.(body|query|params|headers|cookies)\b[^\]]*\]\s*\(`
+)
+
+// Heuristics for TRIAGE-033. authKeyName matches a variable or setting
+// named like a JWT or signing secret, such as jwtKey, JWT_SECRET_KEY, or
+// SECRET_KEY. The js, py, and go patterns match such a name or a signing
+// call given a string literal, including a literal fallback for an
+// environment variable, and a key drawn from a non-cryptographic random
+// source.
+const (
+	authKeyName   = `(?i:\b\w*(jwt\w*|secret_?|signing_?)(key|secret)\w*)`
+	jsWeakJWTKey  = `(\bjwt\.(sign|verify)\(.*,\s*["'\x60][^"'\x60]*["'\x60]\s*[,)]|\.sign\(\s*new\s+TextEncoder\(\)\.encode\(\s*["'\x60]|` + authKeyName + `["'\x60]?\s*[:=]\s*(process\.env\.\w+\s*(\|\||\?\?)\s*)?["'\x60]|` + authKeyName + `\s*[:=].*\bMath\.random\()`
+	pyWeakJWTKey  = `(\bjwt\.(encode|decode)\(.*,\s*[rb]?["'][^"']*["']\s*[,)]|` + authKeyName + `["']?\]?\s*=\s*(os\.(environ\.get|getenv)\([^,)]+,\s*)?[rb]?["']|` + authKeyName + `["']?\]?\s*=.*\brandom\.(random|randint|randrange|getrandbits|choices?|sample)\(|\brandfunc\s*=\s*random\.)`
+	goWeakJWTKey  = `(\.SignedString\(\s*\[\]byte\(\s*"|` + authKeyName + `\s*(:?=)\s*(\[\]byte\(\s*)?"|\.GenerateKey\(.*\b(rand\.New(Source)?\(|mrand\.|mathrand\.))`
+	weakRandomJWT = `(Math\.random|\brandom\.|\brand\.New|\bm(ath)?rand\.)`
+)
+
+// Heuristics for TRIAGE-034. weakKeyBits is an RSA modulus below 2048 bits
+// and lowIterations a PBKDF2 iteration count below 10,000.
+const (
+	weakKeyBits   = `(512|768|1024)\b`
+	lowIterations = `([1-9]\d{0,3})`
+	goWeakCrypto  = `(\brc4\.NewCipher\(|\bdes\.NewTripleDESCipher\(|(?i:\bnew\w*ECB\w*\()|\brsa\.GenerateKey\([^,]+,\s*` + weakKeyBits + `|\bpbkdf2\.Key\(.*,\s*` + lowIterations + `\s*,\s*\d+\s*[,)])`
+	pyWeakCrypto  = `(\bMODE_ECB\b|\bmodes\.ECB\(|\balgorithms\.(ARC4|TripleDES)\(|\b(ARC4|DES3)\.new\(|\bgenerate_private_key\(.*\bkey_size\s*=\s*` + weakKeyBits + `|\bRSA\.generate\(\s*` + weakKeyBits + `|\bpbkdf2_hmac\(\s*[^,]+,[^,]+,[^,]+,\s*` + lowIterations + `\s*[,)]|\biterations\s*=\s*` + lowIterations + `\b)`
+	jsWeakCrypto  = `((?i:["'\x60][\w-]*-ecb["'\x60])|\.mode\.ECB\b|(?i:createCipher(iv)?\(\s*["'\x60](rc4|des-ede3?)\b)|\bCryptoJS\.(RC4|TripleDES)\b|\bmodulusLength\s*:\s*` + weakKeyBits + `|\bpbkdf2(Sync)?\([^,]+,[^,]+,\s*` + lowIterations + `\s*,|\biterations\s*:\s*` + lowIterations + `\b)`
+)
+
+// Heuristics for TRIAGE-035, matching error details or a stack trace
+// written to an HTTP response: errVar is a conventional error variable
+// name, and the patterns match it passed to a response writer or helper.
+const (
+	errVar          = `(err|error|e|ex|exc)`
+	goErrorResponse = `(\bfmt\.Fprint(f|ln)?\(\s*(w|rw|res|resp|writer|c\.Writer)\s*,.*\berr\b|\bhttp\.Error\(\s*\w+\s*,\s*(err\.Error\(\)|fmt\.Sprint\w*\(.*\berr\b)|\.Write\(\s*debug\.Stack\(\)|\bc\.(String|JSON|AbortWithStatusJSON)\(.*\berr\.Error\(\))`
+	pyErrorResponse = `((\breturn\b|\b(jsonify|make_response|HttpResponse|JsonResponse|JSONResponse|PlainTextResponse|Response)\().*\btraceback\.format_(exc|exception|tb|stack)\(|\b(jsonify|make_response|HttpResponse|JsonResponse|JSONResponse|PlainTextResponse|Response)\(.*\b(str|repr)\(\s*` + errVar + `\s*\)|\breturn\s+(str|repr)\(\s*` + errVar + `\s*\)\s*,\s*\d{3}\b)`
+	jsErrorResponse = `(\b(res|response|reply)\.(status\(\s*\d+\s*\)\.)?(send|json|write|end)\((\s*` + errVar + `\s*\)|.*\b` + errVar + `\.(stack|message)\b|\s*\{[^}]*:\s*` + errVar + `\s*[,}])|\bctx\.body\s*=.*\b` + errVar + `\.(stack|message)\b)`
+)
+
+// Heuristics for TRIAGE-036. The go, py, and js patterns match an explicit
+// escaping bypass (template.HTML, mark_safe, the Jinja safe filter,
+// dangerouslySetInnerHTML) given a non-literal value, or a non-literal
+// value written to a raw HTML sink; htmlSink matches the sinks, whose
+// findings are weaker since the value may not be user-controlled.
+const (
+	goUnescapedHTML = `(\btemplate\.HTML\(\s*[^")\x60\s]|\bfmt\.Fprint\w*\(\s*(w|rw|writer)\s*,\s*"[^"]*<\w[^"]*".*` + goRequestValue + `)`
+	pyUnescapedHTML = `(\b(mark_safe|Markup|SafeString)\(\s*[^"')\s]|\|\s*safe\s*(\}\}|\|)|\{%\s*autoescape\s+(false|off)\b|\bautoescape\s*=\s*False\b)`
+	jsUnescapedHTML = `(\b(inner|outer)HTML\s*\+?=\s*([^"'\x60\s]|\x60[^\x60]*\$\{)|\binsertAdjacentHTML\(\s*[^,]+,\s*([^"'\x60\s]|\x60[^\x60]*\$\{)|\bdocument\.write(ln)?\(\s*([^"'\x60\s)]|\x60[^\x60]*\$\{)|\bdangerouslySetInnerHTML\s*=\s*\{\{\s*__html\s*:\s*[^"'\x60\s]|\$\([^)]*\)\.html\(\s*[^"'\x60\s)]|\b(res|response)\.(send|write|end)\(.*<\w.*\breq\.(query|body|params|cookies|headers)\b)`
+	htmlSink        = `(\b(inner|outer)HTML\b|\binsertAdjacentHTML\(|\bdocument\.write(ln)?\(|\)\.html\(|\b(res|response)\.(send|write|end)\(|\bfmt\.Fprint\w*\()`
+)
+
+// Heuristics for TRIAGE-037, matching a command run from a string built
+// by formatting or interpolation: fmt.Sprintf in Go, f-strings, %, and
+// str.format in Python, and template literals and util.format in
+// JavaScript. jsCommandCall is a child_process call, bare or qualified by
+// the module, but not a method such as RegExp.exec.
+const (
+	goFormattedCommand = `\bexec\.Command(Context)?\((.*,)?\s*fmt\.Sprintf\(`
+	pyFormattedCommand = `\b(os\.(system|popen)|subprocess\.(run|call|check_call|check_output|getoutput|getstatusoutput|Popen))\(\s*(\[[^\]]*)?([rb]?f[rb]?["'][^"']*\{|["'][^"']*%[sdr][^"']*["']\s*%|["'][^"']*\{\w*\}[^"']*["']\.format\()`
+	jsCommandCall      = `(^|[^.\w]|\b(child_process|childProcess|cp)\.)(exec|execSync|spawn|spawnSync|execFile|execFileSync)\(`
+	jsFormattedCommand = jsCommandCall + `\s*(\x60[^\x60]*\$\{|(util\.)?format\()`
+)
+
+// Heuristics for TRIAGE-038. goTemplateCast is an html/template type cast
+// other than template.HTML, which TRIAGE-036 covers, given a non-literal
+// value. After goTextTemplate imports text/template, goTextTemplateHTML
+// matches a template executed into an HTTP response or parsed from HTML.
+const (
+	goTemplateCast     = `\btemplate\.(HTMLAttr|JS|JSStr|URL|CSS|Srcset)\(\s*[^")\x60\s]`
+	goTextTemplate     = `^\s*(import\s+)?(\w+\s+)?"text/template"`
+	goTextTemplateHTML = `(\.Execute(Template)?\(\s*(w|rw|writer|res|resp|c\.Writer)\s*,|\.Parse\(\s*["\x60][^"\x60]*<[a-zA-Z!]|\.Parse(Files|Glob)\(.*\.(html?|gohtml)["\x60])`
+)
+
+// Heuristics for TRIAGE-039, matching an environment variable read on the
+// same line as a command or eval call: os.Getenv in Go, os.environ and
+// os.getenv in Python, and process.env in JavaScript.
+const (
+	goEnvCommand = `\bexec\.Command(Context)?\(.*\bos\.Getenv\(`
+	pyEnvCommand = `(\b(os\.(system|popen|exec[lv]p?e?)|subprocess\.(run|call|check_call|check_output|getoutput|getstatusoutput|Popen))|(^|[^.\w])(eval|exec))\(.*\bos\.(environ\b|getenv\()`
+	jsEnvCommand = `(` + jsCommandCall + `|(^|[^.\w])eval\()` + `.*\bprocess\.env\b`
+)
+
 // Compiled regex patterns for each triage rule.
 var rules = []triageRule{
 	{
-		ID:         "TRIAGE-001",
-		Desc:       "Critical security pattern requiring immediate review: dangerous code execution with user input",
-		Severity:   sdk.SeverityHigh,
-		Confidence: sdk.ConfidenceHigh,
-		Priority:   "immediate",
+		ID:          "TRIAGE-001",
+		Desc:        "Critical security pattern requiring immediate review: dangerous code execution with user input",
+		Severity:    sdk.SeverityHigh,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "immediate",
+		Remediation: "Avoid building commands or code from user input; pass arguments as an array to the process API and never eval untrusted data.",
 		Patterns: map[string]*regexp.Regexp{
 			".go": regexp.MustCompile(`(?i)(exec\.Command\(.*\+|os\.Exec|syscall\.Exec)`),
 			// \b anchors eval/exec so identifiers that merely contain them as a
@@ -45,11 +314,12 @@ var rules = []triageRule{
 		},
 	},
 	{
-		ID:         "TRIAGE-002",
-		Desc:       "High-priority pattern for scheduled review: missing input validation on external data",
-		Severity:   sdk.SeverityMedium,
-		Confidence: sdk.ConfidenceHigh,
-		Priority:   "scheduled",
+		ID:          "TRIAGE-002",
+		Desc:        "High-priority pattern for scheduled review: missing input validation on external data",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "scheduled",
+		Remediation: "Validate external input against an allowlist or schema (type, length, format) before using it.",
 		Patterns: map[string]*regexp.Regexp{
 			".go": regexp.MustCompile(`(?i)(r\.URL\.Query\(\)\.Get\(|r\.FormValue\(|r\.Body|json\.Unmarshal\(.*req)`),
 			".py": regexp.MustCompile(`(?i)(request\.(args|form|json|data|values)\[|request\.get_json\(|flask\.request\.(args|form))`),
@@ -58,11 +328,12 @@ var rules = []triageRule{
 		},
 	},
 	{
-		ID:         "TRIAGE-003",
-		Desc:       "Low-priority hygiene pattern: deprecated API usage or security-related TODO comments",
-		Severity:   sdk.SeverityLow,
-		Confidence: sdk.ConfidenceMedium,
-		Priority:   "backlog",
+		ID:          "TRIAGE-003",
+		Desc:        "Low-priority hygiene pattern: deprecated API usage or security-related TODO comments",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "backlog",
+		Remediation: "Resolve the security TODO or replace the deprecated API (e.g. MD5/SHA1/DES with SHA-256/AES-GCM, ioutil with io/os).",
 		Patterns: map[string]*regexp.Regexp{
 			".go": regexp.MustCompile(`(?i)(//\s*(TODO|FIXME|HACK|XXX)\s*.*secur|ioutil\.|crypto/md5|crypto/sha1|crypto/des)`),
 			".py": regexp.MustCompile(`(?i)(#\s*(TODO|FIXME|HACK|XXX)\s*.*secur|import\s+md5|import\s+sha\b|hashlib\.md5)`),
@@ -71,11 +342,12 @@ var rules = []triageRule{
 		},
 	},
 	{
-		ID:         "TRIAGE-004",
-		Desc:       "Informational pattern for context: security-relevant code areas for review",
-		Severity:   sdk.SeverityInfo,
-		Confidence: sdk.ConfidenceHigh,
-		Priority:   "informational",
+		ID:          "TRIAGE-004",
+		Desc:        "Informational pattern for context: security-relevant code areas for review",
+		Severity:    sdk.SeverityInfo,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "informational",
+		Remediation: "No fix required; review that this security-sensitive code follows current best practice.",
 		Patterns: map[string]*regexp.Regexp{
 			".go": regexp.MustCompile(`(?i)(crypto\.|tls\.|x509\.|net/http\.Handle|middleware|jwt\.|bcrypt\.|oauth)`),
 			".py": regexp.MustCompile(`(?i)(cryptography\.|hashlib\.|hmac\.|ssl\.|jwt\.|bcrypt\.|passlib\.|oauth)`),
@@ -83,9 +355,460 @@ var rules = []triageRule{
 			".ts": regexp.MustCompile(`(?i)(crypto\.|jsonwebtoken|bcrypt|passport|helmet|cors|csrf|oauth)`),
 		},
 	},
+	{
+		ID:          "TRIAGE-018",
+		Desc:        "Prototype pollution vector for scheduled review: recursive merge or dynamic key assignment from request data",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "scheduled",
+		Remediation: "Never merge request data into objects recursively or assign request-controlled keys; reject __proto__, constructor, and prototype keys or use Object.create(null)/Map.",
+		Patterns: map[string]*regexp.Regexp{
+			".js": regexp.MustCompile(`(?i)(function\s+(deep)?(merge|extend)\w*\s*\(|\$\.extend\(\s*true|(_|lodash)\.(merge|mergeWith|defaultsDeep)\([^)]*req\.(body|query|params)|Object\.assign\([^)]*req\.(body|query|params)|\w+\[req\.(body|query|params)\.\w+\]\s*=[^=])`),
+			".ts": regexp.MustCompile(`(?i)(function\s+(deep)?(merge|extend)\w*\s*\(|\$\.extend\(\s*true|(_|lodash)\.(merge|mergeWith|defaultsDeep)\([^)]*req\.(body|query|params)|Object\.assign\([^)]*req\.(body|query|params)|\w+\[req\.(body|query|params)\.\w+\]\s*=[^=])`),
+		},
+	},
+	{
+		ID:          "TRIAGE-019",
+		Desc:        "Insecure cookie or session configuration for scheduled review: cookie set without Secure/HttpOnly/SameSite protection",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "scheduled",
+		Remediation: "Set Secure, HttpOnly, and SameSite=Lax or Strict on cookies, especially session and auth cookies.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(?i)((Secure|HttpOnly)\s*:\s*false|SameSite\s*:\s*http\.SameSiteNoneMode|http\.SetCookie\(.*&http\.Cookie\{)`),
+			".py": regexp.MustCompile(`(?i)((SESSION|CSRF)_COOKIE_(SECURE|HTTPONLY)\s*=\s*False|\b(secure|httponly)\s*=\s*False|samesite\s*=\s*['"]?None|\.set_cookie\()`),
+			".js": regexp.MustCompile(`(?i)((secure|httpOnly)\s*:\s*false|sameSite\s*:\s*['"]none['"]|res\.cookie\()`),
+			".ts": regexp.MustCompile(`(?i)((secure|httpOnly)\s*:\s*false|sameSite\s*:\s*['"]none['"]|res\.cookie\()`),
+		},
+		Excludes: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`Secure\s*:\s*true`),
+			".py": regexp.MustCompile(`(?i)secure\s*=\s*True`),
+			".js": regexp.MustCompile(`(?i)secure\s*:\s*true`),
+			".ts": regexp.MustCompile(`(?i)secure\s*:\s*true`),
+		},
+	},
+	{
+		ID:          "TRIAGE-020",
+		Desc:        "Authentication endpoint for backlog review: confirm login, sign-in, and token handlers are rate limited",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceLow,
+		Priority:    "backlog",
+		Remediation: "Throttle authentication attempts per account and per client (rate limiter, exponential backoff, or lockout) to slow credential brute-forcing.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(?i)func\s+(\(\w+\s+\*?\w+\)\s*)?\w*(login|signin|authenticate|auth|token)\w*\s*\(\s*\w+\s+http\.ResponseWriter`),
+			".py": regexp.MustCompile(`(?i)def\s+(\w+_)?(login|log_in|signin|sign_in|authenticate|auth|token)(_\w+)?\s*\(`),
+			".js": regexp.MustCompile(`(?i)((app|router)\.(post|all)\(\s*['"][^'"]*/(login|signin|sign-in|authenticate|auth|token)\b|function\s+\w*(login|signin|authenticate)\w*\s*\()`),
+			".ts": regexp.MustCompile(`(?i)((app|router)\.(post|all)\(\s*['"][^'"]*/(login|signin|sign-in|authenticate|auth|token)\b|function\s+\w*(login|signin|authenticate)\w*\s*\()`),
+		},
+		// A limiter referenced on the same line, typically route middleware,
+		// already answers the review question.
+		Excludes: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(?i)(rate.?limit|limiter|throttle)`),
+			".py": regexp.MustCompile(`(?i)(rate.?limit|limiter|throttle)`),
+			".js": regexp.MustCompile(`(?i)(rate.?limit|limiter|throttle)`),
+			".ts": regexp.MustCompile(`(?i)(rate.?limit|limiter|throttle)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-021",
+		Desc:        "Hardcoded internal address for backlog review: private IP or internal hostname in a string literal",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "backlog",
+		Remediation: "Move internal hostnames and addresses into configuration or environment variables so each environment can supply its own.",
+		// Quoted RFC 1918 addresses and *.internal / *.corp hostnames look
+		// the same in every supported language; \x60 is a backtick, for Go
+		// raw strings and JavaScript template literals.
+		Patterns: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)["'\x60][^"'\x60]*\b(10\.\d{1,3}\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3}|172\.(1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3}|[a-z0-9-]+(\.[a-z0-9-]+)*\.(internal|corp))\b`),
+		},
+		// Documentation placeholders are the usual false positive.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)\b(example|sample|placeholder)\b`),
+		},
+	},
+	{
+		ID:          "TRIAGE-022",
+		Desc:        "Insecure temporary file for backlog review: predictable temp path another local user could pre-create or read",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "backlog",
+		Remediation: "Create temporary files with an API that picks a random name and restrictive permissions (os.CreateTemp, tempfile.mkstemp or NamedTemporaryFile, fs.mkdtemp) instead of a fixed path under /tmp.",
+		// Python's mktemp is insecure by design; a hardcoded /tmp path or a
+		// fixed name joined to the temp dir is often harmless in tests and
+		// scripts, which AI triage can tell apart.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(["\x60]/tmp(/|["\x60])|os\.TempDir\(\)\s*\+|filepath\.Join\(\s*os\.TempDir\(\)\s*,\s*"|ioutil\.TempFile\(\s*"/tmp)`),
+			".py": regexp.MustCompile(`(\bmktemp\(|['"]/tmp(/|['"]))`),
+			".js": regexp.MustCompile(`(['"\x60]/tmp(/|['"\x60])|os\.tmpdir\(\)\s*\+|path\.join\(\s*os\.tmpdir\(\)\s*,\s*['"\x60])`),
+			".ts": regexp.MustCompile(`(['"\x60]/tmp(/|['"\x60])|os\.tmpdir\(\)\s*\+|path\.join\(\s*os\.tmpdir\(\)\s*,\s*['"\x60])`),
+		},
+		// A random-name API on the same line already answers the question.
+		Excludes: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(os\.CreateTemp|os\.MkdirTemp|ioutil\.TempDir)\(`),
+			".py": regexp.MustCompile(`(mkstemp|mkdtemp|TemporaryFile|TemporaryDirectory)\(`),
+			".js": regexp.MustCompile(`mkdtemp(Sync)?\(`),
+			".ts": regexp.MustCompile(`mkdtemp(Sync)?\(`),
+		},
+	},
+	{
+		ID:          "TRIAGE-023",
+		Desc:        "Possible insecure direct object reference: record fetched by an ID taken straight from the request",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceLow,
+		Priority:    "scheduled",
+		Remediation: "Check that the caller owns or may access the record, for example by scoping the query to the authenticated user, before returning or changing it.",
+		// The missing ownership check cannot be seen by a line regex, so
+		// this flags ID-driven lookups for AI triage to judge in context.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(?i)\b(get|find|first|load|fetch|delete|update)\w*\(.*(r\.URL\.Query\(\)\.Get\(|mux\.Vars\(r\)\[|chi\.URLParam\(r,|c\.Param\(|r\.PathValue\()\s*"\w*id"`),
+			".py": regexp.MustCompile(`(?i)(\.objects\.(get|filter)|\.query\.get|get_or_404|get_object_or_404|\.find_one|\.filter_by)\(.*request\.(args|form|values|get|post|query_params|data)(\[|\.get\()\s*['"]\w*id['"]`),
+			".js": regexp.MustCompile(`(?i)\b(findById\w*|findOne|findByPk|findUnique|findFirst|get|delete\w*|update\w*)\(.*req\.(params|query|body)(\.\w*id\b|\[\s*['"]\w*id['"])`),
+			".ts": regexp.MustCompile(`(?i)\b(findById\w*|findOne|findByPk|findUnique|findFirst|get|delete\w*|update\w*)\(.*req\.(params|query|body)(\.\w*id\b|\[\s*['"]\w*id['"])`),
+		},
+		// A lookup scoped to the current user on the same line is the
+		// ownership check this rule asks for.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)(\bowner|current_?user|req\.user|request\.user|userFromContext|authoriz|permission)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-024",
+		Desc:        "Cleartext HTTP endpoint for backlog review: http:// URL in a string literal",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "backlog",
+		Remediation: "Use an https:// endpoint so data in transit is encrypted and the server is authenticated.",
+		// A quoted http:// URL looks the same in every supported language;
+		// \x60 is a backtick, as in TRIAGE-021, and hosts may be interpolated.
+		Patterns: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)["'\x60]http://[a-z0-9\[${]`),
+		},
+		// Loopback addresses never leave the host, and XML namespaces and
+		// schema identifiers are names that are never fetched.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)(http://(localhost|127\.\d{1,3}\.\d{1,3}\.\d{1,3}|0\.0\.0\.0|\[::1\])\b|http://(www\.)?(w3\.org|json-schema\.org|purl\.org|schemas\.[a-z0-9.-]+|xmlns\.[a-z0-9.-]+|java\.sun\.com|maven\.apache\.org|example\.(com|org|net))\b|\bxmlns\b)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-025",
+		Desc:        "Regex prone to catastrophic backtracking (ReDoS): nested quantifier or overlapping alternation under a quantifier",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "scheduled",
+		Remediation: "Rewrite the pattern so no repeated group can match the same input in more than one way, for example (a+)+ as a+, or bound the input length before matching.",
+		// A group holding a quantifier that is itself repeated, such as
+		// (a+)+ or (.*)*, or a repeated alternation of overlapping classes,
+		// such as (\w|\d)+. Go's regexp package is linear-time and immune,
+		// so only the backtracking regexp2 package is flagged there.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`regexp2\.(MustCompile|Compile)\(.*(` + nestedQuantifier + `|` + overlappingAlternation + `)`),
+			".py": regexp.MustCompile(`\bre(gex)?\.(compile|match|search|fullmatch|findall|finditer|sub|split)\(\s*[rbuRBU]*["'].*(` + nestedQuantifier + `|` + overlappingAlternation + `)`),
+			".js": regexp.MustCompile(`(new RegExp\(\s*["'\x60]|(^|[=(:,!&|?{};]|return)\s*/[^/*\s]).*(` + nestedQuantifier + `|` + overlappingAlternation + `)`),
+			".ts": regexp.MustCompile(`(new RegExp\(\s*["'\x60]|(^|[=(:,!&|?{};]|return)\s*/[^/*\s]).*(` + nestedQuantifier + `|` + overlappingAlternation + `)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-026",
+		Desc:        "Possible open redirect (Go): Location header or http.Redirect target taken from the request; check it is validated against an allowlist of hosts or paths",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "scheduled",
+		Remediation: "Redirect only to relative paths or to hosts on an allowlist, parsing the target with url.Parse and rejecting anything else, rather than echoing a URL from the request.",
+		// Go handlers redirect by setting the Location header as often as
+		// through http.Redirect. The target is flagged when it is read from
+		// the request on the same line or passed in a variable named like a
+		// redirect parameter; the allowlist check itself is left to AI triage.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(\.Header\(\)\.(Set|Add)\(\s*"Location"\s*,.*` + goRequestValue + `|\.Header\(\)\.(Set|Add)\(\s*"Location"\s*,\s*` + redirectTargetName + `|http\.Redirect\(\s*\w+\s*,\s*\w+\s*,\s*([^,]*` + goRequestValue + `|` + redirectTargetName + `))`),
+		},
+		// A check on the same line means the target was vetted.
+		Excludes: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(?i)(allow|whitelist|safe|trusted|sameorigin|isLocal)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-027",
+		Desc:        "Hardcoded secret in configuration file: credential-named key assigned a literal value",
+		Severity:    sdk.SeverityHigh,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "immediate",
+		Remediation: "Remove the value from the file and rotate it, then load it at runtime from the environment or a secrets manager; keep only a placeholder or reference such as ${DB_PASSWORD} in committed configuration.",
+		Patterns:    configPatterns(regexp.MustCompile(configSecret)),
+		// Numbers and plain URLs are settings, not secrets; keys ending in
+		// _file, _name, _url, and the like refer to a secret stored
+		// elsewhere; and placeholders document the value without holding it.
+		Excludes: configPatterns(regexp.MustCompile(`(?i)([:=]\s*["']?\d+["']?\s*,?\s*$|[:=]\s*["']?https?://[^@\s]*$|(passw(or)?d|pwd|secret|token|key|credentials?)[\w-]*?[_.-](file|path|name|ref|env|var|url|uri|endpoint|id|length|ttl|expiry|expires\w*|timeout|type|header|prefix|enabled)["']?\s*[:=]|changeme|change[_-]me|replace[_-]?me|your[_-]|example|placeholder|dummy|redacted|xxxx|\*{3}|tokeniz)`)),
+	},
+	{
+		ID:          "TRIAGE-028",
+		Desc:        "Remote content executed or loaded without an integrity check: download followed by exec, eval, a shell, or a module load on the same or next line; check whether a hash or signature is verified in between",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "scheduled",
+		Remediation: "Verify downloaded content against a pinned SHA-256 digest or a signature before executing or loading it, or install it through a package manager whose lockfile records the hash.",
+		// The execute half; Follows requires the download half on the same
+		// line, as in curl | sh, or on the line before, as in urlretrieve
+		// followed by exec. Bare exec and eval exclude method calls such as
+		// re.exec and model.eval. Whether the two halves touch the same data
+		// is left to AI triage.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(exec\.Command(Context)?\(|plugin\.Open\(|` + pipeToShell + `)`),
+			".py": regexp.MustCompile(`((^|[^.\w])(exec|eval)\(|\bos\.(system|popen|exec\w*)\(|\bsubprocess\.\w+\(|\brunpy\.run_path\(|\bimportlib\.|` + pipeToShell + `)`),
+			".js": regexp.MustCompile(`((^|[^.\w])eval\(|\bnew Function\(|\bvm\.run\w*\(|\b(require|import)\(\s*([^"'\x60\s)]|["'\x60]https?://)|(^|[^.\w]|child_process\.)(exec|execSync|spawn|spawnSync)\(|` + pipeToShell + `)`),
+			".ts": regexp.MustCompile(`((^|[^.\w])eval\(|\bnew Function\(|\bvm\.run\w*\(|\b(require|import)\(\s*([^"'\x60\s)]|["'\x60]https?://)|(^|[^.\w]|child_process\.)(exec|execSync|spawn|spawnSync)\(|` + pipeToShell + `)`),
+		},
+		Follows: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(` + shellDownload + `|\bhttp\.(Get|Post)\(|\.Do\(\s*req\b|io\.Copy\(\s*\w+\s*,\s*resp\.Body)`),
+			".py": regexp.MustCompile(`(` + shellDownload + `|\burl(retrieve|open)\(|\b(requests|httpx|session)\.get\()`),
+			".js": regexp.MustCompile(`(` + shellDownload + `|\bfetch\(|\baxios(\.get)?\(|\bhttps?\.get\(|\bgot(\.get)?\(|\b(require|import)\(\s*["'\x60]https?://)`),
+			".ts": regexp.MustCompile(`(` + shellDownload + `|\bfetch\(|\baxios(\.get)?\(|\bhttps?\.get\(|\bgot(\.get)?\(|\b(require|import)\(\s*["'\x60]https?://)`),
+		},
+		// A digest or signature check on the line means the content is
+		// verified.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)(sha(1|256|384|512)|checksum|integrity|verif|signature|\bgpg\b|cosign|minisign|hashlib)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-029",
+		Desc:        "Debug mode or permissive security setting: fine in development but dangerous if this code or configuration reaches production; check whether the file is production-bound",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "backlog",
+		Remediation: "Turn debug mode off and restrict CORS to known origins by default, enabling the development settings only from an environment variable or a development-only configuration file that is never deployed.",
+		// Settings hardcoded on, in source and in configuration files alike.
+		// Whether the file is a development or production one is left to AI
+		// triage, which sees its path.
+		Patterns: map[string]*regexp.Regexp{
+			".go":         regexp.MustCompile(`(\.Debug\s*=\s*true\b|gin\.SetMode\(\s*gin\.DebugMode\s*\)|AllowAllOrigins\s*:\s*true\b|AllowOrigins\s*:\s*(\[\]string\{\s*)?"\*"|AllowedOrigins\s*:\s*\[\]string\{\s*"\*"|` + corsAnyOrigin + `)`),
+			".py":         regexp.MustCompile(`(^\s*DEBUG\s*=\s*True\b|\.debug\s*=\s*True\b|\.run\(.*\bdebug\s*=\s*True\b|^\s*CORS_(ORIGIN_ALLOW_ALL|ALLOW_ALL_ORIGINS)\s*=\s*True\b|^\s*ALLOWED_HOSTS\s*=\s*\[\s*["']\*["']\s*\]|\bCORS\(\s*\w+\s*,\s*(origins|resources)\s*=\s*["']\*["']|` + corsAnyOrigin + `)`),
+			".js":         regexp.MustCompile(`(\bapp\.set\(\s*["']env["']\s*,\s*["']development["']|process\.env\.NODE_ENV\s*(\|\||\?\?)\s*["']development["']|\bdebug\s*:\s*true\b|\bcors\(\s*\)|\borigin\s*:\s*(["']\*["']|true\b)|` + corsAnyOrigin + `)`),
+			".ts":         regexp.MustCompile(`(\bapp\.set\(\s*["']env["']\s*,\s*["']development["']|process\.env\.NODE_ENV\s*(\|\||\?\?)\s*["']development["']|\bdebug\s*:\s*true\b|\bcors\(\s*\)|\borigin\s*:\s*(["']\*["']|true\b)|` + corsAnyOrigin + `)`),
+			".env":        regexp.MustCompile(configDebug),
+			".yaml":       regexp.MustCompile(configDebug),
+			".yml":        regexp.MustCompile(configDebug),
+			".json":       regexp.MustCompile(configDebug),
+			".toml":       regexp.MustCompile(configDebug),
+			".properties": regexp.MustCompile(configDebug),
+		},
+		// A condition on the same line limits the setting to development
+		// or tests.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)\b(if|when|unless)\b.*\b(dev|development|local|test|testing)\b`),
+		},
+	},
+	{
+		ID:          "TRIAGE-030",
+		Desc:        "Possible server-side template injection: the template source itself is taken from the request, not just the data rendered into it; confirm the template string is user-controlled",
+		Severity:    sdk.SeverityHigh,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "immediate",
+		Remediation: "Load templates only from files or constants and pass user input to them as template data; if users must supply templates, render them with a logic-less engine or a sandbox such as Jinja2's SandboxedEnvironment.",
+		// The request value must reach the template source: Flask and
+		// Jinja2 render_template_string, Template, and from_string; the
+		// common Node engines; and Go's text/template and html/template
+		// Parse, through template.New or a variable named like a template.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(\btemplate\.New\([^)]*\)(\.\w+\([^)]*\))*\.Parse\(|\b(tmpl|tpl|templ)\w*\.Parse\()[^;]*` + goRequestValue),
+			".py": regexp.MustCompile(pyTemplateSource),
+			".js": regexp.MustCompile(jsTemplateSource),
+			".ts": regexp.MustCompile(jsTemplateSource),
+		},
+		// A sandboxed environment limits what a template can reach.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)sandbox`),
+		},
+	},
+	{
+		ID:          "TRIAGE-031",
+		Desc:        "Certificate or hostname verification bypassed: a custom verifier that accepts every certificate, or hostname checking turned off",
+		Severity:    sdk.SeverityHigh,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "immediate",
+		Remediation: "Remove the override and rely on the default chain and hostname verification; to trust a private CA, add it to the root pool (tls.Config.RootCAs, ssl cafile, the ca option) instead of replacing the verifier.",
+		// Go: a VerifyPeerCertificate or VerifyConnection callback whose
+		// body is only "return nil", on the same or the next line. Python:
+		// CERT_NONE, check_hostname = False, or an unverified context.
+		// Node: a checkServerIdentity that returns nothing.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(^\s*return\s+nil\s*$|` + goVerifyCallback + `\s*return\s+nil\s*\})`),
+			".py": regexp.MustCompile(pyCertVerifyOff),
+			".js": regexp.MustCompile(jsNoopCertVerify),
+			".ts": regexp.MustCompile(jsNoopCertVerify),
+		},
+		Follows: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goVerifyCallback),
+		},
+	},
+	{
+		ID:          "TRIAGE-032",
+		Desc:        "Reflection or dynamic dispatch driven by user input: a method or attribute name taken from the request; check which methods it can reach",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "scheduled",
+		Remediation: "Map the request value to a handler through an explicit allowlist, such as a map from action names to functions, instead of looking up methods or attributes by name.",
+		// Go reflect MethodByName, Python getattr, and JavaScript obj[name]()
+		// where the name is read from the request on the same line.
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`\.MethodByName\([^;]*` + goRequestValue),
+			".py": regexp.MustCompile(pyReflectedName),
+			".js": regexp.MustCompile(jsReflectedCall),
+			".ts": regexp.MustCompile(jsReflectedCall),
+		},
+		// A check on the same line means the name was vetted.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)(allow|whitelist|permitted)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-033",
+		Desc:        "Weak JWT or signing key: a hardcoded secret, or key material from a non-cryptographic random source; check whether it protects production tokens",
+		Severity:    sdk.SeverityHigh,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "immediate",
+		Remediation: "Load signing secrets from a secret manager or the environment without a literal fallback, use at least 256 random bits for HMAC keys, and generate keys and secrets with a cryptographic source (crypto/rand, secrets, crypto.randomBytes).",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goWeakJWTKey),
+			".py": regexp.MustCompile(pyWeakJWTKey),
+			".js": regexp.MustCompile(jsWeakJWTKey),
+			".ts": regexp.MustCompile(jsWeakJWTKey),
+		},
+		// A literal secret is near-certain; a weak random source may only
+		// seed test keys, so AI triage sorts those out.
+		WeakEvidence: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(weakRandomJWT),
+		},
+		WeakConfidence: sdk.ConfidenceMedium,
+	},
+	{
+		ID:          "TRIAGE-034",
+		Desc:        "Weak cryptographic configuration: ECB mode, RC4, 3DES, an RSA key under 2048 bits, or PBKDF2 with under 10,000 iterations",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "scheduled",
+		Remediation: "Encrypt with an authenticated mode such as AES-GCM or ChaCha20-Poly1305, generate RSA keys of at least 2048 bits (3072 for new keys), and derive keys from passwords with Argon2id, scrypt, or PBKDF2 at hundreds of thousands of iterations.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goWeakCrypto),
+			".py": regexp.MustCompile(pyWeakCrypto),
+			".js": regexp.MustCompile(jsWeakCrypto),
+			".ts": regexp.MustCompile(jsWeakCrypto),
+		},
+		// ECB, RC4, and 3DES are weak wherever they appear; key sizes and
+		// iteration counts depend on what the key protects, so AI triage
+		// weighs those.
+		WeakEvidence: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)(GenerateKey|generate_private_key|RSA\.generate|modulusLength|pbkdf2|iterations)`),
+		},
+		WeakConfidence: sdk.ConfidenceMedium,
+	},
+	{
+		ID:          "TRIAGE-035",
+		Desc:        "Error details or a stack trace sent in an HTTP response; check whether the path is user-facing",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "scheduled",
+		Remediation: "Log the error and stack trace on the server and return a generic message, with a request ID to correlate it with the log, to the client.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goErrorResponse),
+			".py": regexp.MustCompile(pyErrorResponse),
+			".js": regexp.MustCompile(jsErrorResponse),
+			".ts": regexp.MustCompile(jsErrorResponse),
+		},
+	},
+	{
+		ID:          "TRIAGE-036",
+		Desc:        "Unescaped output into HTML (XSS): escaping bypassed or a non-literal value written to a raw HTML sink; trace whether the value comes from the user",
+		Severity:    sdk.SeverityHigh,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "immediate",
+		Remediation: "Let the template engine escape output (html/template, Jinja autoescaping, JSX text), set textContent instead of innerHTML, and pass any HTML that must be rendered through a sanitizer such as DOMPurify or bleach before marking it safe.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goUnescapedHTML),
+			".py": regexp.MustCompile(pyUnescapedHTML),
+			".js": regexp.MustCompile(jsUnescapedHTML),
+			".ts": regexp.MustCompile(jsUnescapedHTML),
+		},
+		// A sanitizer or escaper on the same line handles the value.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)(DOMPurify|\bsanitiz\w*\(|\bescape\w*\(|bleach\.clean)`),
+		},
+		// Explicit bypasses are near-certain; raw sinks may only ever see
+		// trusted values, so AI triage traces those.
+		WeakEvidence: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(htmlSink),
+		},
+		WeakConfidence: sdk.ConfidenceMedium,
+	},
+	{
+		ID:          "TRIAGE-037",
+		Desc:        "Command built from a format or template string: interpolated values reach the shell; confirm whether they come from the user",
+		Severity:    sdk.SeverityMedium,
+		Confidence:  sdk.ConfidenceMedium,
+		Priority:    "immediate",
+		Remediation: "Pass the program and each argument separately (exec.Command(name, args...), subprocess.run([...]) without shell=True, execFile or spawn with an argument array) instead of formatting a command line, and validate values against an allowlist.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goFormattedCommand),
+			".py": regexp.MustCompile(pyFormattedCommand),
+			".js": regexp.MustCompile(jsFormattedCommand),
+			".ts": regexp.MustCompile(jsFormattedCommand),
+		},
+		// Quoting the value for the shell on the same line defuses it.
+		Excludes: map[string]*regexp.Regexp{
+			anyExtension: regexp.MustCompile(`(?i)(shlex\.quote|pipes\.quote|shell-?escape|escapeShellArg|shellQuote)`),
+		},
+	},
+	{
+		ID:          "TRIAGE-038",
+		Desc:        "Go template escaping bypassed: an html/template type cast of a non-literal value, or text/template rendering HTML without auto-escaping; trace whether the value comes from the user",
+		Severity:    sdk.SeverityHigh,
+		Confidence:  sdk.ConfidenceHigh,
+		Priority:    "immediate",
+		Remediation: "Render HTML with html/template, which escapes by context, and pass user data as plain strings; cast to template.JS, HTMLAttr, URL, or CSS only for constants or values validated against an allowlist.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goTemplateCast),
+		},
+		Gated: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goTextTemplateHTML),
+		},
+		Gate: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goTextTemplate),
+		},
+		// An escaper on the same line handles the value.
+		Excludes: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`(?i)(escape\w*\(|sanitiz)`),
+		},
+		// Casts bypass escaping outright; text/template output may be
+		// plain text or trusted markup, so AI triage checks those.
+		WeakEvidence: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(`\.(Execute(Template)?|Parse(Files|Glob)?)\(`),
+		},
+		WeakConfidence: sdk.ConfidenceMedium,
+	},
+	{
+		ID:          "TRIAGE-039",
+		Desc:        "Environment variable used as a command or evaluated code: safe only while the environment is trusted; weigh whether CI, a multi-tenant host, or a caller can set it",
+		Severity:    sdk.SeverityLow,
+		Confidence:  sdk.ConfidenceLow,
+		Priority:    "scheduled",
+		Remediation: "Do not run or evaluate environment values directly; map them to a fixed set of allowed commands, or pass them as separate validated arguments rather than a command line.",
+		Patterns: map[string]*regexp.Regexp{
+			".go": regexp.MustCompile(goEnvCommand),
+			".py": regexp.MustCompile(pyEnvCommand),
+			".js": regexp.MustCompile(jsEnvCommand),
+			".ts": regexp.MustCompile(jsEnvCommand),
+		},
+		// Handing the whole environment to the child is not running it.
+		Excludes: map[string]*regexp.Regexp{
+			".py": regexp.MustCompile(`\benv\s*=\s*(dict\()?os\.environ\b`),
+			".js": regexp.MustCompile(`\benv\s*:\s*(\{\s*\.\.\.)?process\.env\b`),
+			".ts": regexp.MustCompile(`\benv\s*:\s*(\{\s*\.\.\.)?process\.env\b`),
+		},
+	},
 }
 
-// supportedExtensions lists file extensions that the triage scanner processes.
+// supportedExtensions lists the source-language extensions the triage scanner
+// processes; configuration formats are listed in configExtensions.
 var supportedExtensions = map[string]bool{
 	".go": true,
 	".py": true,
@@ -108,103 +831,821 @@ func buildServer() *sdk.PluginServer {
 	manifest := sdk.NewManifest("nox/triage-agent", version).
 		Capability("triage-agent", "Prioritizes and classifies code patterns for security review").
 		Tool("scan", "Scan source files to triage and prioritize security patterns for review", true).
+		Tool("retriage", "Run AI triage on a previously produced set of findings without re-scanning", true).
+		Tool("selftest", "Check that every rule fires on its bundled known-vulnerable snippets", true).
+		Tool("demo", "Scan the bundled corpus of known-vulnerable snippets and return the findings", true).
 		Done().
 		Safety(sdk.WithRiskClass(sdk.RiskPassive)).
 		Build()
+	declareInputSchemas(manifest)
 
 	return sdk.NewPluginServer(manifest).
-		HandleTool("scan", handleScan)
+		HandleTool("scan", traced("scan", handleScan)).
+		HandleTool("retriage", traced("retriage", handleRetriage)).
+		HandleTool("selftest", traced("selftest", handleSelftest)).
+		HandleTool("demo", traced("demo", handleDemo))
 }
 
 func handleScan(ctx context.Context, req sdk.ToolRequest) (*pluginv1.InvokeToolResponse, error) {
-	workspaceRoot, _ := req.Input["workspace_root"].(string)
-	if workspaceRoot == "" {
-		workspaceRoot = req.WorkspaceRoot
+	if err := checkInputs("scan", req.Input); err != nil {
+		return nil, err
 	}
+	if err := checkWorkspaceLock(req.Input, req.WorkspaceRoot); err != nil {
+		return nil, err
+	}
+	// Later pages come from the cached first call without scanning again.
+	if token := inputString(req.Input, "page_token"); token != "" {
+		return nextScanPage(token, inputInt(req.Input, "page_size", 0))
+	}
+	runID, runStart := newRunID(), time.Now()
+	roots := resolveScanRoots(req.Input, req.WorkspaceRoot)
 
 	resp := sdk.NewResponse()
 
-	if workspaceRoot == "" {
+	if len(roots) == 0 {
 		return resp.Build(), nil
 	}
+	// The first root holds the configuration file and anchors relative
+	// baseline, diff, and output paths.
+	workspaceRoot := roots[0].Path
 
-	err := filepath.WalkDir(workspaceRoot, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil
+	// Configuration file values sit beneath explicit inputs and above the
+	// environment.
+	cfg, err := loadRunConfig(workspaceRoot, inputString(req.Input, "config_file"))
+	if err != nil {
+		return nil, err
+	}
+	input := cfg.mergeInputs(req.Input)
+	opts := parseScanOptions(input)
+	opts.WorkspaceRoot = workspaceRoot
+	if !opts.Minimal {
+		opts.RunID = runID
+	}
+	opts.Progress = newProgressReporter(runID)
+	opts.Scanned = new(atomic.Int64)
+	pathAdjustments, err := parsePathAdjustments(input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	priorities, err := parsePriorityLevels(input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	sortBy, sortWeights, err := parseSortOrder(input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	sla, err := parsePrioritySLA(input, priorities)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	if err := checkAdjustmentPriorities(pathAdjustments, priorities); err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	pathSeverityRules, err := parsePathSeverityRules(input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	filter, err := parseTriageFilter(input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	if opts.MaxLineLength, err = parseMaxLineLength(input); err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	if opts.LanguageMap, err = parseLanguageMap(input); err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	if opts.ForceLanguage, err = parseForceLanguage(input); err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	if opts.ForceLanguage != "" && len(opts.LanguageMap) > 0 {
+		return nil, newToolError(ErrInvalidInput, "force_language cannot be combined with language_map")
+	}
+	webhook, err := parseWebhookConfig(req.Input, input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+
+	if opts.Encoding != "" && !validEncodings[opts.Encoding] {
+		return nil, newToolError(ErrInvalidInput, "unsupported encoding %q (supported: auto, utf-8, utf-16le, utf-16be)", opts.Encoding)
+	}
+	for _, root := range roots {
+		if info, err := os.Stat(root.Path); err != nil || !info.IsDir() {
+			return nil, newToolError(ErrWorkspaceNotFound, "%s", root.Path)
+		}
+	}
+	if opts.FailOnNew != "" && parseSeverity(opts.FailOnNew) == pluginv1.Severity(0) {
+		return nil, newToolError(ErrInvalidInput, "unknown fail_on_new severity %q", opts.FailOnNew)
+	}
+	if opts.DropBelow != "" && parseSeverity(opts.DropBelow) == pluginv1.Severity(0) {
+		return nil, newToolError(ErrInvalidInput, "unknown drop_below severity %q", opts.DropBelow)
+	}
+	switch opts.OutputFormat {
+	case "", outputFormatNDJSON, outputFormatText, outputFormatCSV:
+	default:
+		return nil, newToolError(ErrInvalidInput, "unsupported output_format %q (supported: ndjson, text, csv)", opts.OutputFormat)
+	}
+	if opts.OutputFormat != "" && opts.OutputFormat != outputFormatNDJSON && opts.OutputFile == "" {
+		return nil, newToolError(ErrInvalidInput, "output_format %s needs an output_file", opts.OutputFormat)
+	}
+	if opts.GroupBy != "" && opts.GroupBy != groupOutputByRule {
+		return nil, newToolError(ErrInvalidInput, "unsupported group_by %q (supported: rule)", opts.GroupBy)
+	}
+	if opts.TriageChanged && opts.BaselineFile == "" {
+		return nil, newToolError(ErrInvalidInput, "triage_changed_only needs a baseline_file")
+	}
+	if opts.Minimal && opts.BaselineFile != "" {
+		return nil, newToolError(ErrInvalidInput, "baseline_file needs fingerprints, which minimal omits")
+	}
+	if opts.Minimal && opts.WriteBaseline != "" {
+		return nil, newToolError(ErrInvalidInput, "generate_baseline needs fingerprints, which minimal omits")
+	}
+	if opts.Minimal && opts.DedupeCopies {
+		return nil, newToolError(ErrInvalidInput, "dedupe_copies needs content hashes, which minimal omits")
+	}
+	if opts.HeadRef != "" && opts.BaseRef == "" {
+		return nil, newToolError(ErrInvalidInput, "head_ref needs a base_ref")
+	}
+	if opts.BaseRef != "" {
+		switch {
+		case len(roots) > 1:
+			return nil, newToolError(ErrInvalidInput, "base_ref takes a single workspace root")
+		case opts.StdinPaths:
+			return nil, newToolError(ErrInvalidInput, "base_ref cannot be combined with paths_from_stdin")
+		case opts.DiffFile != "" || opts.DiffBase != "":
+			return nil, newToolError(ErrInvalidInput, "base_ref cannot be combined with diff_file or diff_base")
+		case opts.Minimal:
+			return nil, newToolError(ErrInvalidInput, "base_ref needs fingerprints, which minimal omits")
 		}
-		if ctx.Err() != nil {
-			return ctx.Err()
+		if opts.HeadRef == "" {
+			opts.HeadRef = "HEAD"
 		}
-		if d.IsDir() {
-			if skippedDirs[d.Name()] {
-				return filepath.SkipDir
+	}
+	switch opts.Source {
+	case "", sourceWorktree:
+	case sourceStaged:
+		switch {
+		case len(roots) > 1:
+			return nil, newToolError(ErrInvalidInput, "source staged takes a single workspace root")
+		case opts.StdinPaths:
+			return nil, newToolError(ErrInvalidInput, "source staged cannot be combined with paths_from_stdin")
+		case opts.DiffFile != "" || opts.DiffBase != "":
+			return nil, newToolError(ErrInvalidInput, "source staged cannot be combined with diff_file or diff_base")
+		case opts.BaseRef != "":
+			return nil, newToolError(ErrInvalidInput, "source staged cannot be combined with base_ref")
+		}
+	default:
+		return nil, newToolError(ErrInvalidInput, "unsupported source %q (supported: worktree, staged)", opts.Source)
+	}
+	if opts.PageSize < 0 {
+		return nil, newToolError(ErrInvalidInput, "page_size must not be negative")
+	}
+	if opts.PageSize > 0 && opts.FlushAfter > 0 {
+		return nil, newToolError(ErrInvalidInput, "page_size cannot be combined with flush_threshold")
+	}
+	if opts.FlushAfter < 0 {
+		return nil, newToolError(ErrInvalidInput, "flush_threshold must not be negative")
+	}
+	if opts.FlushAfter > 0 {
+		switch {
+		case opts.OutputFile == "":
+			return nil, newToolError(ErrInvalidInput, "flush_threshold needs an output_file")
+		case opts.OutputFormat != "" && opts.OutputFormat != outputFormatNDJSON:
+			return nil, newToolError(ErrInvalidInput, "flush_threshold needs ndjson output_format")
+		}
+		for _, name := range flushIncompatible {
+			if v, ok := input[name]; ok && v != false && v != "" {
+				return nil, newToolError(ErrInvalidInput, "flush_threshold cannot be combined with %s, which needs every finding in memory", name)
 			}
-			return nil
 		}
+	}
+	var pathList []string
+	if opts.StdinPaths {
+		if len(roots) > 1 {
+			return nil, newToolError(ErrInvalidInput, "paths_from_stdin takes a single workspace root")
+		}
+		pathList, err = readStdinPaths(ctx)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return nil, newToolError(ErrScanTimeout, "reading paths from stdin: %v", err)
+		case errors.Is(err, context.Canceled):
+			return nil, err
+		case err != nil:
+			return nil, newToolError(ErrInvalidInput, "reading paths from stdin: %v", err)
+		}
+	}
 
-		ext := filepath.Ext(path)
-		if !supportedExtensions[ext] {
-			return nil
+	// Outside a git repository there is no index, and the working tree is
+	// the nearest thing to what would be committed.
+	var stagedFallback string
+	if opts.Source == sourceStaged {
+		staged, ok, err := stagedPaths(ctx, workspaceRoot)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("listing staged files: %w", err)
+		case ok:
+			pathList = staged
+		default:
+			opts.Source = sourceWorktree
+			stagedFallback = fmt.Sprintf("source staged: %s is not in a git repository; scanned the working tree", workspaceRoot)
 		}
+	}
 
-		return scanFile(resp, path, ext)
-	})
-	if err != nil && err != context.Canceled {
-		return nil, fmt.Errorf("walking workspace: %w", err)
+	var baseline map[string]baselineEntry
+	if opts.BaselineFile != "" {
+		var err error
+		if baseline, err = loadBaseline(resolveOutputPath(workspaceRoot, opts.BaselineFile)); err != nil {
+			return nil, newToolError(ErrInvalidInput, "loading baseline_file: %v", err)
+		}
+	}
+
+	if opts.AddedLines, err = loadScanDiff(ctx, roots, &opts); err != nil {
+		return nil, err
+	}
+
+	// Lines too long to match are listed in the diagnostics, so the
+	// coverage lost to max_line_length is visible.
+	var longLines []longLineSkip
+	opts.LongLines = &longLines
+
+	// A commit range scans the exported head_ref in place of the workspace.
+	walked := roots
+	var commits *commitRange
+	if opts.BaseRef != "" {
+		if commits, err = openCommitRange(ctx, workspaceRoot, &opts); err != nil {
+			return nil, err
+		}
+		defer commits.close()
+		walked = []scanRoot{{Path: commits.headDir}}
+	}
+
+	// Files and directories that cannot be read are reported rather than
+	// silently skipped, or fail the scan in strict mode.
+	var unreadable []string
+	skipUnreadable := func(display string, err error) error {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		if opts.Strict {
+			return newToolError(ErrUnreadableFile, "%s: %v", display, err)
+		}
+		unreadable = append(unreadable, fmt.Sprintf("%s: %v", display, err))
+		return nil
+	}
+
+	// Deterministic adjusters also run on findings flushed during the walk.
+	adjusters := append([]Adjuster(nil), registeredAdjusters...)
+	if len(pathAdjustments) > 0 {
+		adjusters = append(adjusters, &pathAdjuster{name: "path", root: workspaceRoot, adjustments: pathAdjustments})
+	}
+	if len(pathSeverityRules) > 0 {
+		adjusters = append(adjusters, &pathAdjuster{name: "path_severity", root: workspaceRoot, adjustments: pathSeverityRules})
+	}
+	opts.Flusher = newFindingFlusher(ctx, resolveOutputPath(workspaceRoot, opts.OutputFile), opts.OutputGzip,
+		opts.FlushAfter, adjusters, parseSeverity(opts.DropBelow), priorities)
+
+	// An identical earlier scan's walk is reused from the result cache; the
+	// phases after the walk run as usual.
+	cache, cacheKey := openResultCache(), ""
+	if cache != nil {
+		if name := resultCacheBypass(&opts); name != "" {
+			log.Printf("result_cache: not used with %s", name)
+		} else if cacheKey, err = resultCacheKey(input, roots, &opts); err != nil {
+			log.Printf("result_cache: not used: %v", err)
+			cacheKey = ""
+		}
+	}
+	var cachedFindings []*pluginv1.Finding
+	var cachedAt time.Time
+	cacheHit := false
+	if cacheKey != "" {
+		var walk cachedWalk
+		if cachedFindings, walk, cachedAt, cacheHit = cache.get(cacheKey); cacheHit {
+			opts.Scanned.Store(walk.Scanned)
+			unreadable, longLines = walk.Unreadable, walk.LongLines
+		}
+	}
+	if !cacheHit {
+		if err := walkRoots(ctx, resp, walked, pathList, &opts, skipUnreadable); err != nil {
+			return nil, err
+		}
+	}
+
+	built := resp.Build()
+	switch {
+	case cacheHit:
+		restampRunID(cachedFindings, runID)
+		built.Findings = cachedFindings
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("result_cache: reused the findings of an identical scan of %d file(s) from %s",
+				opts.Scanned.Load(), cachedAt.UTC().Format(time.RFC3339)))
+	case cacheKey != "" && ctx.Err() == nil:
+		entry := cachedWalk{Scanned: opts.Scanned.Load(), Unreadable: unreadable, LongLines: longLines}
+		if err := cache.put(cacheKey, built.GetFindings(), entry); err != nil {
+			log.Printf("result_cache: storing results: %v", err)
+		}
+	}
+	if err := opts.Flusher.finish(built); err != nil {
+		return nil, fmt.Errorf("writing output_file: %w", err)
+	}
+	flushed := opts.Flusher.flushed()
+	addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, "scan_run_id: "+runID)
+	if stagedFallback != "" {
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING, stagedFallback)
+	}
+
+	// A cancelled scan returns the findings gathered so far, flagged as
+	// partial, rather than an error. The phases after the walk get
+	// cancel_grace_ms to finish; without a grace, those that need the
+	// context, AI triage and the webhook, are skipped.
+	scanCtx := ctx
+	cancelled := false
+	markCancelled := func() {
+		if !cancelled && errors.Is(scanCtx.Err(), context.Canceled) {
+			cancelled = true
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+				"cancelled: true; findings cover only the work finished before cancellation")
+		}
+	}
+	markCancelled()
+	if cancelled && opts.CancelGrace > 0 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeout(context.WithoutCancel(scanCtx), opts.CancelGrace)
+		defer stop()
+	}
+	if len(unreadable) > 0 {
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+			fmt.Sprintf("skipped %d unreadable file(s)", len(unreadable)))
+		for _, u := range unreadable {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING, "unreadable: "+u)
+		}
+	}
+	if len(longLines) > 0 {
+		skipped := 0
+		for _, l := range longLines {
+			skipped += len(l.Lines)
+		}
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+			fmt.Sprintf("skipped matching on %d line(s) longer than max_line_length in %d file(s)", skipped, len(longLines)))
+		for _, l := range longLines {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING, l.String())
+		}
+	}
+	if len(roots) > 1 {
+		sortFindings(built.GetFindings())
+	}
+	if opts.Dedupe {
+		built.Findings = dedupeFindings(built.GetFindings(), priorities)
+	}
+	if commits != nil {
+		total := len(built.GetFindings())
+		built.Findings = commits.introduced(built.GetFindings())
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("commit range %s..%s: %d new finding(s), %d already present at %s",
+				opts.BaseRef, opts.HeadRef, len(built.GetFindings()), total-len(built.GetFindings()), opts.BaseRef))
+		if err := commits.attribute(ctx, built.GetFindings()); err != nil {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING, "commit attribution: "+err.Error())
+		}
+	}
+
+	if baseline != nil {
+		for _, entry := range compareBaseline(built.GetFindings(), baseline) {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+				fmt.Sprintf("resolved: %s %s %s", entry.Fingerprint, entry.RuleID, entry.Location))
+		}
+	}
+	// Copies are collapsed after the baseline comparison, so baselined
+	// findings in vendored copies are not reported as resolved.
+	if opts.DedupeCopies {
+		built.Findings = dedupeCopies(built.GetFindings())
+	}
+
+	// Deterministic adjusters run before the cost estimate so it reflects
+	// the final finding set. Flushed findings were adjusted as written.
+	if !flushed {
+		adjustCtx, adjustSpan := startSpan(ctx, "scan.adjust", attribute.Int("adjusters", len(adjusters)))
+		runAdjusters(adjustCtx, built, adjusters)
+		adjustSpan.End()
+	}
+
+	// Only findings that pass the triage filter are sent to the LLM or
+	// counted in the cost estimate.
+	eligible := filter.apply(built.GetFindings())
+	if (opts.AITriage || opts.EstimateCost) && len(eligible) < len(built.GetFindings()) {
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("ai_triage: %d of %d finding(s) eligible", len(eligible), len(built.GetFindings())))
+	}
+
+	// Cost estimate: report what AI triage would cost instead of running it.
+	if opts.EstimateCost {
+		est, err := estimateTriageCost(eligible, configuredModel(cfg.Settings), cfg.Settings, workspaceRoot)
+		if err != nil {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING, err.Error())
+		}
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, est.String())
+		markCancelled()
+		return built, nil
+	}
+
+	// With triage_changed_only, unchanged findings keep the verdict from the
+	// baseline and only the rest are sent to the LLM.
+	if opts.AITriage && opts.TriageChanged && len(eligible) > 0 {
+		reused := reuseCachedVerdicts(eligible, baseline, opts.ClassifyOnly)
+		eligible = uncachedFindings(eligible)
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("ai_triage: reused %d cached verdict(s) from baseline_file", reused))
 	}
 
 	// AI triage: opt-in LLM-assisted severity adjustment.
-	if aiTriage, _ := req.Input["ai_triage"].(bool); aiTriage {
-		built := resp.Build()
-		if len(built.GetFindings()) > 0 {
-			provider, model, err := resolveProvider()
-			if err != nil {
-				markTriageError(built.GetFindings(), err.Error())
-			} else {
-				aiTriageFindings(ctx, provider, model, built.GetFindings())
+	if opts.AITriage && len(eligible) > 0 && ctx.Err() != nil {
+		log.Printf("ai_triage: skipped; the scan was cancelled")
+	} else if opts.AITriage && len(eligible) > 0 {
+		provider, model, err := resolveProvider(cfg.Settings)
+		if err != nil {
+			markTriageError(eligible, err.Error())
+		} else {
+			tc := newTriageConfig(cfg.Settings)
+			tc.ClassifyOnly = opts.ClassifyOnly
+			tc.Progress = opts.Progress
+			tc.PriorityLevels = priorities
+			tc.SourceRoot = workspaceRoot
+			if file := cfg.Settings.get("NOX_AI_EXAMPLES_FILE"); file != "" {
+				tc.Examples = loadTriageExamples(resolveOutputPath(workspaceRoot, file))
+			}
+			if dir := cfg.Settings.get("NOX_AI_AUDIT_DIR"); dir != "" {
+				tc.Audit = newAuditLog(resolveOutputPath(workspaceRoot, dir), runID, cfg.Settings)
 			}
+			llm := &llmAdjuster{provider: provider, model: model, cfg: tc, filter: filter}
+			triageCtx, triageSpan := startSpan(ctx, "scan.ai_triage",
+				attribute.String("llm.provider", provider.Name()),
+				attribute.String("llm.model", model),
+				attribute.Int("findings", len(eligible)))
+			runAdjusters(triageCtx, built, []Adjuster{llm})
+			triageSpan.SetAttributes(
+				attribute.Int("findings_triaged", llm.stats.Triaged),
+				attribute.Int("errors", llm.stats.Errors))
+			triageSpan.End()
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, llm.stats.String())
+		}
+	}
+
+	// Applied after AI triage so the model can first raise a finding it
+	// considers important above the threshold.
+	if opts.DropBelow != "" {
+		var dropped int
+		if flushed {
+			dropped = opts.Flusher.dropped
+		} else {
+			built.Findings, dropped = dropBelow(built.GetFindings(), parseSeverity(opts.DropBelow))
+		}
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("drop_below: dropped %d finding(s) below %s", dropped, strings.ToLower(opts.DropBelow)))
+	}
+
+	// Due dates follow the final priorities, so they are set after AI
+	// triage and adjustments.
+	if sla != nil {
+		stampDueDates(built.GetFindings(), sla, priorities, runStart)
+	}
+
+	// Ordered and counted after AI triage and adjustments so the final
+	// severities and priorities decide them.
+	orderFindings(built.GetFindings(), sortBy, sortWeights, priorities)
+	counts := countFindings(built.GetFindings(), priorities)
+	if flushed {
+		counts = opts.Flusher.counts
+	}
+	addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, counts.String())
+
+	// Baseline gate: evaluated after AI triage so it sees final severities.
+	if baseline != nil && opts.FailOnNew != "" {
+		if n := countNewAtOrAbove(built.GetFindings(), parseSeverity(opts.FailOnNew)); n > 0 {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_ERROR,
+				fmt.Sprintf("%d new finding(s) at or above %s severity not in baseline", n, strings.ToLower(opts.FailOnNew)))
+		}
+	}
+
+	if opts.AffectedFiles {
+		for _, file := range affectedFiles(workspaceRoot, built.GetFindings()) {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, file.String())
+		}
+	}
+	if opts.GroupBy == groupOutputByRule {
+		for _, section := range ruleSections(workspaceRoot, built.GetFindings()) {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, section.String())
+		}
+	}
+	if commits != nil {
+		for _, section := range commits.sections(built.GetFindings()) {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, section.String())
 		}
-		return built, nil
 	}
 
-	return resp.Build(), nil
+	// A cancelled scan saw only part of the workspace, and a baseline
+	// written from it would report the rest as new next time.
+	if opts.WriteBaseline != "" && cancelled {
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+			"generate_baseline: not written; the scan was cancelled")
+	} else if opts.WriteBaseline != "" {
+		outPath := resolveOutputPath(workspaceRoot, opts.WriteBaseline)
+		n, err := writeBaseline(outPath, workspaceRoot, built.GetFindings())
+		if err != nil {
+			return nil, fmt.Errorf("writing generate_baseline: %w", err)
+		}
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("wrote %d fingerprint(s) to %s", n, outPath))
+	}
+
+	if opts.OutputFile != "" && !flushed {
+		outPath := resolveOutputPath(workspaceRoot, opts.OutputFile)
+		write := func() error { return writeFindingsNDJSON(outPath, built.GetFindings(), opts.OutputGzip) }
+		switch opts.OutputFormat {
+		case outputFormatText:
+			write = func() error { return writeTextReport(outPath, workspaceRoot, built.GetFindings(), opts.OutputGzip) }
+		case outputFormatCSV:
+			write = func() error { return writeCSVReport(outPath, workspaceRoot, built.GetFindings(), opts.OutputGzip) }
+		}
+		_, outputSpan := startSpan(ctx, "scan.output", attribute.String("format", opts.OutputFormat), attribute.Int("findings", len(built.GetFindings())))
+		err := write()
+		endSpan(outputSpan, err)
+		if err != nil {
+			return nil, fmt.Errorf("writing output_file: %w", err)
+		}
+		addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+			fmt.Sprintf("wrote %d finding(s) to %s", len(built.GetFindings()), outPath))
+	}
+	// Webhook delivery failures are reported but never fail the scan.
+	if webhook != nil && ctx.Err() != nil {
+		log.Printf("webhook: skipped; the scan was cancelled")
+	} else if webhook != nil {
+		host := webhookHost(webhook.URL)
+		webhookCtx, webhookSpan := startSpan(ctx, "scan.webhook", attribute.Int("findings", len(built.GetFindings())))
+		attempts, err := postFindings(webhookCtx, webhook, built.GetFindings())
+		webhookSpan.SetAttributes(attribute.Int("attempts", attempts))
+		endSpan(webhookSpan, err)
+		if err != nil {
+			log.Printf("webhook: delivery to %s failed after %d attempt(s): %v", host, attempts, err)
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING,
+				fmt.Sprintf("webhook: delivery to %s failed after %d attempt(s): %v", host, attempts, err))
+		} else {
+			addDiagnostic(built, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+				fmt.Sprintf("webhook: delivered %d finding(s) to %s", len(built.GetFindings()), host))
+		}
+	}
+	if opts.Compact {
+		compactFindings(built.GetFindings())
+	}
+	markCancelled()
+	if opts.PageSize > 0 {
+		run := scanPages.put(runID, built.GetFindings(), opts.PageSize)
+		servePage(built, runID, run, 0, opts.PageSize)
+	}
+
+	return built, nil
 }
 
-func scanFile(resp *sdk.ResponseBuilder, filePath, ext string) error {
-	f, err := os.Open(filePath)
+// walkRoots scans each root in turn, or with paths_from_stdin or source
+// staged the listed paths under it. A cancelled walk stops without an
+// error, leaving the findings gathered so far.
+func walkRoots(ctx context.Context, resp *sdk.ResponseBuilder, roots []scanRoot, pathList []string, opts *scanOptions, skip func(display string, err error) error) (err error) {
+	ctx, span := startSpan(ctx, "scan.walk", attribute.Int("roots", len(roots)))
+	defer func() {
+		span.SetAttributes(attribute.Int64("files_scanned", opts.Scanned.Load()))
+		endSpan(span, err)
+	}()
+
+	for _, root := range roots {
+		switch {
+		case opts.StdinPaths:
+			err = scanPathList(ctx, resp, root, pathList, opts, skip)
+		case opts.Source == sourceStaged:
+			err = scanStaged(ctx, resp, root, pathList, opts, skip)
+		default:
+			err = walkRoot(ctx, resp, root, opts, skip)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return newToolError(ErrScanTimeout, "walking %s: %v", root.Path, err)
+		}
+		if errors.Is(err, ErrUnreadableFile) {
+			return err
+		}
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("walking workspace: %w", err)
+		}
+	}
+	return nil
+}
+
+// diagnosticSource identifies this plugin in response diagnostics.
+const diagnosticSource = "nox/triage-agent"
+
+// addDiagnostic appends a diagnostic message to an already built response.
+func addDiagnostic(resp *pluginv1.InvokeToolResponse, severity pluginv1.DiagnosticSeverity, message string) {
+	resp.Diagnostics = append(resp.Diagnostics, &pluginv1.Diagnostic{
+		Severity: severity,
+		Message:  message,
+		Source:   diagnosticSource,
+	})
+}
+
+// pathDepth returns the number of path separators in path relative to root,
+// so files directly in root have depth 0.
+func pathDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator))
+}
+
+// scanFile matches every rule against one source file. It returns an error
+// when the file cannot be opened, decoded, or read to the end.
+func scanFile(resp *sdk.ResponseBuilder, filePath, ext string, opts *scanOptions) error {
+	relPath, err := filepath.Rel(opts.WorkspaceRoot, filePath)
 	if err != nil {
+		relPath = filePath
+	}
+	// Findings from one of several roots are reported by root-prefixed
+	// relative path so identical layouts stay distinct.
+	findingPath := filePath
+	if opts.RootName != "" {
+		relPath = path.Join(opts.RootName, filepath.ToSlash(relPath))
+		findingPath = relPath
+	}
+	if opts.AddedLines != nil && !opts.AddedLines.hasFile(relPath) {
 		return nil
 	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
 	defer func() { _ = f.Close() }()
 
-	scanner := bufio.NewScanner(f)
+	src, err := decodeSource(f, opts.Encoding)
+	if err != nil {
+		return err
+	}
+	return scanSource(resp, src, findingPath, relPath, ext, opts)
+}
+
+// scanSource matches every rule against decoded source text. findingPath is
+// the location reported on findings and relPath the workspace-relative path
+// used for fingerprints and diff filtering.
+func scanSource(resp *sdk.ResponseBuilder, src io.Reader, findingPath, relPath, ext string, opts *scanOptions) error {
+	defer opts.Progress.fileScanned(relPath)
+	// Flushed once the file's findings carry all their metadata.
+	defer opts.Flusher.flush(resp)
+	if opts.Scanned != nil {
+		opts.Scanned.Add(1)
+	}
+
+	br := bufio.NewReader(src)
+	generated := isGeneratedSource(br)
+	if generated && opts.SkipGenerated {
+		return nil
+	}
+	allowed := readRuleDirectives(br, relPath)
+
+	lines := &lineReader{br: br, limit: opts.MaxLineLength.forExt(ext)}
+	budget := lineBudget{limit: opts.LineBudget}
+	scannedAt := time.Now().UTC().Format(time.RFC3339)
+	// The file's findings are tagged with the number of lines skipped as too
+	// long once the whole file has been read.
+	var emitted []*sdk.FindingBuilder
+	framework := newFileFramework(ext)
+	var skippedLines []int
 	lineNum := 0
-	for scanner.Scan() {
+	// prevLine is the line before the current one, for Follows patterns.
+	var prevLine string
+	// gates holds each rule's Gate pattern for ext, and gateOpen records
+	// those that have matched a line so far.
+	gates := make([]*regexp.Regexp, len(rules))
+	for i := range rules {
+		if gate, _, ok := rules[i].gated(ext); ok {
+			gates[i] = gate
+		}
+	}
+	gateOpen := make([]bool, len(rules))
+	for {
+		line, skipped, err := lines.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
 		lineNum++
-		line := scanner.Text()
+		if skipped {
+			skippedLines = append(skippedLines, lineNum)
+			prevLine = ""
+			continue
+		}
+		framework.observe(line)
+		for i, gate := range gates {
+			if gate != nil && !gateOpen[i] && gate.MatchString(line) {
+				gateOpen[i] = true
+			}
+		}
+		prev := prevLine
+		prevLine = line
+		if opts.AddedLines != nil && !opts.AddedLines.contains(relPath, lineNum) {
+			continue
+		}
+		if opts.MinLineLength > 0 && len(strings.TrimSpace(line)) < opts.MinLineLength {
+			continue
+		}
 
+		budget.reset()
+		lastRule := ""
 		for i := range rules {
+			if budget.exceeded() {
+				log.Printf("triage: %s:%d exceeded the %v line budget after %s; skipping remaining rules", relPath, lineNum, budget.limit, lastRule)
+				break
+			}
 			rule := &rules[i]
-			pattern, ok := rule.Patterns[ext]
-			if !ok {
+			pattern, ok := rule.pattern(ext)
+			if !ok || !allowed.allows(rule.ID) {
 				continue
 			}
-			if pattern.MatchString(line) {
-				resp.Finding(
+			lastRule = rule.ID
+			matched := pattern.MatchString(line)
+			if _, gated, ok := rule.gated(ext); ok && gateOpen[i] && !matched {
+				matched = gated.MatchString(line)
+			}
+			if matched {
+				if exclude, ok := rule.exclude(ext); ok && exclude.MatchString(line) {
+					continue
+				}
+				if follows, ok := rule.follows(ext); ok && !follows.MatchString(line) && (!follows.MatchString(prev) || pattern.MatchString(prev)) {
+					continue
+				}
+				if opts.Minimal {
+					resp.Finding(rule.ID, rule.Severity, rule.confidence(ext, line), rule.Desc).
+						At(findingPath, lineNum, lineNum).
+						Done()
+					continue
+				}
+				fb := resp.Finding(
 					rule.ID,
 					rule.Severity,
-					rule.Confidence,
-					fmt.Sprintf("%s: %s", rule.Desc, strings.TrimSpace(line)),
+					rule.confidence(ext, line),
+					fmt.Sprintf("%s: %s", rule.Desc, strings.TrimSpace(maskSecrets(redactLine(pattern, line)))),
 				).
-					At(filePath, lineNum, lineNum).
+					At(findingPath, lineNum, lineNum).
+					WithFingerprint(findingFingerprint(rule.ID, relPath, line)).
 					WithMetadata("priority", rule.Priority).
-					WithMetadata("language", extToLanguage(ext)).
-					Done()
+					WithMetadata("language", extToLanguage(ext))
+				if rule.Remediation != "" {
+					fb.WithMetadata("remediation", rule.Remediation)
+				}
+				if rule.CustomSeverity != "" {
+					fb.WithMetadata("custom_severity", rule.CustomSeverity)
+				}
+				if generated {
+					fb.WithMetadata("generated", "true")
+				}
+				if opts.DedupeCopies {
+					fb.WithMetadata("content_hash", contentHash(rule.ID, line))
+				}
+				if opts.RunID != "" {
+					fb.WithMetadata("scan_run_id", opts.RunID).
+						WithMetadata("scanned_at", scannedAt).
+						WithMetadata("plugin_version", version)
+				}
+				fb.Done()
+				emitted = append(emitted, fb)
 			}
 		}
 	}
 
-	return scanner.Err()
+	if name := framework.name(opts.Frameworks[ext]); name != "" {
+		for _, fb := range emitted {
+			fb.WithMetadata("framework", name)
+		}
+	}
+	if len(emitted) > 0 {
+		if project := opts.Projects.of(relPath); project != "" {
+			for _, fb := range emitted {
+				fb.WithMetadata(projectKey, project)
+			}
+		}
+	}
+	if len(skippedLines) > 0 {
+		log.Printf("triage: %s: skipped %d line(s) longer than %d bytes", relPath, len(skippedLines), lines.limit)
+		for _, fb := range emitted {
+			fb.WithMetadata("minified_lines_skipped", strconv.Itoa(len(skippedLines)))
+		}
+		if opts.LongLines != nil {
+			*opts.LongLines = append(*opts.LongLines, longLineSkip{Path: relPath, Lines: skippedLines, Limit: lines.limit})
+		}
+	}
+	return nil
 }
 
 func extToLanguage(ext string) string {
@@ -217,6 +1658,10 @@ func extToLanguage(ext string) string {
 		return "javascript"
 	case ".ts":
 		return "typescript"
+	case ".env", ".yaml", ".json", ".toml", ".properties":
+		return strings.TrimPrefix(ext, ".")
+	case ".yml":
+		return "yaml"
 	default:
 		return "unknown"
 	}
@@ -230,6 +1675,21 @@ func run() int {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if err := validateRuleIDs(rules); err != nil {
+		fmt.Fprintf(os.Stderr, "nox-plugin-triage-agent: %v\n", err)
+		return 1
+	}
+	shutdownTracing, err := setupTracing(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nox-plugin-triage-agent: tracing disabled: %v\n", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "nox-plugin-triage-agent: flushing traces: %v\n", err)
+		}
+	}()
 	srv := buildServer()
 	if err := srv.Serve(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "nox-plugin-triage-agent: %v\n", err)
@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
 
 	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
@@ -101,6 +105,906 @@ func TestScanFindsInformationalPattern(t *testing.T) {
 	}
 }
 
+func TestScanFindsPrototypePollution(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-018")
+	if len(found) < 3 {
+		t.Fatalf("expected at least 3 TRIAGE-018 (prototype pollution) findings, got %d", len(found))
+	}
+
+	for _, f := range found {
+		if f.GetSeverity() != sdk.SeverityMedium {
+			t.Errorf("TRIAGE-018 severity should be MEDIUM, got %v", f.GetSeverity())
+		}
+		if lang := f.GetMetadata()["language"]; lang != "javascript" && lang != "typescript" {
+			t.Errorf("TRIAGE-018 should only fire on JS/TS, got language %q", lang)
+		}
+	}
+}
+
+func TestScanAttachesRemediation(t *testing.T) {
+	for _, rule := range rules {
+		if rule.Remediation == "" {
+			t.Errorf("built-in rule %s has no remediation text", rule.ID)
+		}
+	}
+
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+	for _, f := range resp.GetFindings() {
+		if f.GetMetadata()["remediation"] == "" {
+			t.Errorf("%s finding at line %d missing remediation metadata", f.GetRuleId(), f.GetLocation().GetStartLine())
+		}
+	}
+}
+
+func TestScanFindsInsecureCookie(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-019")
+	byFile := make(map[string]int)
+	for _, f := range found {
+		byFile[filepath.Base(f.GetLocation().GetFilePath())]++
+		if f.GetSeverity() != sdk.SeverityMedium {
+			t.Errorf("TRIAGE-019 severity should be MEDIUM, got %v", f.GetSeverity())
+		}
+		if strings.Contains(f.GetMessage(), "secure=True") || strings.Contains(f.GetMessage(), "secure: true") {
+			t.Errorf("cookie set with Secure should not be flagged: %s", f.GetMessage())
+		}
+	}
+	// SESSION_COOKIE_SECURE = False and the bare set_cookie in Python; the
+	// httpOnly: false cookie in JavaScript.
+	if byFile["vuln_app.py"] != 2 {
+		t.Errorf("expected 2 TRIAGE-019 findings in vuln_app.py, got %d", byFile["vuln_app.py"])
+	}
+	if byFile["vuln_app.js"] != 1 {
+		t.Errorf("expected 1 TRIAGE-019 finding in vuln_app.js, got %d", byFile["vuln_app.js"])
+	}
+}
+
+func TestScanFindsAuthEndpointsForRateLimitReview(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-020")
+	byFile := make(map[string]int)
+	for _, f := range found {
+		byFile[filepath.Base(f.GetLocation().GetFilePath())]++
+		if f.GetSeverity() != sdk.SeverityLow {
+			t.Errorf("TRIAGE-020 severity should be LOW, got %v", f.GetSeverity())
+		}
+		if f.GetMetadata()["priority"] != "backlog" {
+			t.Errorf("expected priority=backlog, got %q", f.GetMetadata()["priority"])
+		}
+		if strings.Contains(f.GetMessage(), "tokenLimiter") {
+			t.Errorf("rate-limited route should not be flagged: %s", f.GetMessage())
+		}
+	}
+	// def login() in Python; the /api/login route in JavaScript.
+	if byFile["vuln_app.py"] != 1 {
+		t.Errorf("expected 1 TRIAGE-020 finding in vuln_app.py, got %d", byFile["vuln_app.py"])
+	}
+	if byFile["vuln_app.js"] != 1 {
+		t.Errorf("expected 1 TRIAGE-020 finding in vuln_app.js, got %d", byFile["vuln_app.js"])
+	}
+}
+
+func TestScanFindsHardcodedInternalAddresses(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-021")
+	byFile := make(map[string]int)
+	for _, f := range found {
+		byFile[filepath.Base(f.GetLocation().GetFilePath())]++
+		if f.GetSeverity() != sdk.SeverityLow || f.GetConfidence() != sdk.ConfidenceMedium {
+			t.Errorf("TRIAGE-021 should be LOW/MEDIUM, got %v/%v", f.GetSeverity(), f.GetConfidence())
+		}
+		if f.GetMetadata()["priority"] != "backlog" {
+			t.Errorf("expected priority=backlog, got %q", f.GetMetadata()["priority"])
+		}
+		if strings.Contains(f.GetMessage(), "example") || strings.Contains(f.GetMessage(), "8.8.8.8") {
+			t.Errorf("placeholder or public address should not be flagged: %s", f.GetMessage())
+		}
+	}
+	// The .internal hostname and the 172.20 address in Python; the
+	// template literal in JavaScript, through the language-agnostic pattern.
+	if byFile["vuln_app.py"] != 2 {
+		t.Errorf("expected 2 TRIAGE-021 findings in vuln_app.py, got %d", byFile["vuln_app.py"])
+	}
+	if byFile["vuln_app.js"] != 1 {
+		t.Errorf("expected 1 TRIAGE-021 finding in vuln_app.js, got %d", byFile["vuln_app.js"])
+	}
+}
+
+func TestScanFindsInsecureTempFiles(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-022")
+	byFile := make(map[string]int)
+	for _, f := range found {
+		byFile[filepath.Base(f.GetLocation().GetFilePath())]++
+		if f.GetSeverity() != sdk.SeverityLow {
+			t.Errorf("TRIAGE-022 severity should be LOW, got %v", f.GetSeverity())
+		}
+		if strings.Contains(f.GetMessage(), "mkstemp") {
+			t.Errorf("mkstemp is the safe API and should not be flagged: %s", f.GetMessage())
+		}
+	}
+	// mktemp() and the hardcoded lock path in Python; fs.writeFile in
+	// JavaScript.
+	if byFile["vuln_app.py"] != 2 {
+		t.Errorf("expected 2 TRIAGE-022 findings in vuln_app.py, got %d", byFile["vuln_app.py"])
+	}
+	if byFile["vuln_app.js"] != 1 {
+		t.Errorf("expected 1 TRIAGE-022 finding in vuln_app.js, got %d", byFile["vuln_app.js"])
+	}
+}
+
+func TestScanFindsIDDrivenLookups(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-023")
+	byFile := make(map[string]int)
+	for _, f := range found {
+		byFile[filepath.Base(f.GetLocation().GetFilePath())]++
+		if f.GetSeverity() != sdk.SeverityMedium || f.GetConfidence() != sdk.ConfidenceLow {
+			t.Errorf("TRIAGE-023 should be MEDIUM/LOW, got %v/%v", f.GetSeverity(), f.GetConfidence())
+		}
+		if f.GetMetadata()["priority"] != "scheduled" {
+			t.Errorf("expected priority=scheduled, got %q", f.GetMetadata()["priority"])
+		}
+		if strings.Contains(f.GetMessage(), "owner") {
+			t.Errorf("lookup scoped to the owner should not be flagged: %s", f.GetMessage())
+		}
+	}
+	if byFile["vuln_app.py"] != 1 {
+		t.Errorf("expected 1 TRIAGE-023 finding in vuln_app.py, got %d", byFile["vuln_app.py"])
+	}
+	if byFile["vuln_app.js"] != 1 {
+		t.Errorf("expected 1 TRIAGE-023 finding in vuln_app.js, got %d", byFile["vuln_app.js"])
+	}
+}
+
+func TestScanFindsCleartextHTTPEndpoints(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-024")
+	byFile := make(map[string]int)
+	for _, f := range found {
+		byFile[filepath.Base(f.GetLocation().GetFilePath())]++
+		if f.GetSeverity() != sdk.SeverityLow || f.GetConfidence() != sdk.ConfidenceMedium {
+			t.Errorf("TRIAGE-024 should be LOW/MEDIUM, got %v/%v", f.GetSeverity(), f.GetConfidence())
+		}
+		if strings.Contains(f.GetMessage(), "localhost") || strings.Contains(f.GetMessage(), "w3.org") {
+			t.Errorf("loopback and namespace URLs should not be flagged: %s", f.GetMessage())
+		}
+	}
+	// The payments URL and the internal inventory URL from TRIAGE-021 in
+	// Python; the telemetry endpoint in JavaScript.
+	if byFile["vuln_app.py"] != 2 {
+		t.Errorf("expected 2 TRIAGE-024 findings in vuln_app.py, got %d", byFile["vuln_app.py"])
+	}
+	if byFile["vuln_app.js"] != 1 {
+		t.Errorf("expected 1 TRIAGE-024 finding in vuln_app.js, got %d", byFile["vuln_app.js"])
+	}
+}
+
+func TestScanFindsReDoSPatterns(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScan(t, client, testdataDir(t))
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-025")
+	byFile := make(map[string]int)
+	for _, f := range found {
+		byFile[filepath.Base(f.GetLocation().GetFilePath())]++
+		if f.GetSeverity() != sdk.SeverityMedium || f.GetConfidence() != sdk.ConfidenceMedium {
+			t.Errorf("TRIAGE-025 should be MEDIUM/MEDIUM, got %v/%v", f.GetSeverity(), f.GetConfidence())
+		}
+		if strings.Contains(f.GetMessage(), "safe") || strings.Contains(f.GetMessage(), "SAFE") {
+			t.Errorf("patterns without nested quantifiers should not be flagged: %s", f.GetMessage())
+		}
+	}
+	if byFile["vuln_app.py"] != 1 {
+		t.Errorf("expected 1 TRIAGE-025 finding in vuln_app.py, got %d", byFile["vuln_app.py"])
+	}
+	if byFile["vuln_app.js"] != 1 {
+		t.Errorf("expected 1 TRIAGE-025 finding in vuln_app.js, got %d", byFile["vuln_app.js"])
+	}
+}
+
+func TestScanFindsGoOpenRedirects(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "handler.go"), `package main
+
+import "net/http"
+
+func login(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Location", r.URL.Query().Get("next"))
+	w.Header().Add("Location", "/welcome?from="+r.FormValue("from"))
+	http.Redirect(w, r, r.FormValue("url"), http.StatusFound)
+	next := r.URL.Query().Get("next")
+	http.Redirect(w, r, next, http.StatusFound)
+	http.Redirect(w, r, returnURL, http.StatusSeeOther)
+	// Not flagged.
+	http.Redirect(w, r, "/home", http.StatusFound)
+	w.Header().Set("Location", "/dashboard")
+	w.Header().Set("Content-Type", r.Header.Get("Accept"))
+	http.Redirect(w, r, safeRedirect(next), http.StatusFound)
+	w.Header().Set("Location", next) // checked by isAllowedHost above
+}
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	var lines []int32
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-026") {
+		lines = append(lines, f.GetLocation().GetStartLine())
+		if f.GetSeverity() != sdk.SeverityMedium || f.GetMetadata()["priority"] != "scheduled" {
+			t.Errorf("TRIAGE-026 should be MEDIUM/scheduled, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+	}
+	// Request values in the Location header or http.Redirect, and targets
+	// held in redirect-named variables; literal paths, other headers, and
+	// vetted targets are not flagged.
+	if want := []int32{6, 7, 8, 10, 11}; !slices.Equal(lines, want) {
+		t.Errorf("expected TRIAGE-026 on lines %v, got %v", want, lines)
+	}
+}
+
+func TestScanFindsUnverifiedDownloads(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "setup.py"), `import urllib.request, subprocess
+subprocess.run("curl -fsSL https://get.tool.dev | bash", shell=True)
+path, _ = urllib.request.urlretrieve(PLUGIN_URL)
+exec(open(path).read())
+# Not flagged.
+subprocess.run("curl -fsSL https://get.tool.dev -o install.sh && sha256sum -c install.sh.sha256", shell=True)
+resp = requests.get(MODEL_URL)
+model.eval()
+subprocess.run(["make", "build"])
+`)
+	writeFile(t, filepath.Join(root, "loader.js"), `const res = await fetch(pluginUrl);
+eval(await res.text());
+const remote = await import("https://cdn.example.dev/plugin.mjs");
+// Not flagged.
+const page = await fetch(url);
+const m = pattern.exec(text);
+`)
+	writeFile(t, filepath.Join(root, "update.go"), `package main
+
+func update() {
+	exec.Command("sh", "-c", "wget -qO- https://get.tool.dev | sh").Run()
+	exec.Command("go", "build").Run()
+}
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-028") {
+		if f.GetSeverity() != sdk.SeverityMedium || f.GetMetadata()["priority"] != "scheduled" {
+			t.Errorf("TRIAGE-028 should be MEDIUM/scheduled, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetLocation().GetStartLine())
+	}
+	// Downloads piped to a shell, and execution on the line after a fetch;
+	// checksummed downloads, method calls named eval or exec, and commands
+	// with no download nearby are not flagged.
+	want := map[string][]int32{"setup.py": {2, 4}, "loader.js": {2, 3}, "update.go": {4}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-028 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsDebugSettings(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "settings.py"), `DEBUG = True
+CORS_ALLOW_ALL_ORIGINS = True
+ALLOWED_HOSTS = ["*"]
+app.run(host="0.0.0.0", debug=True)
+# Not flagged.
+DEBUG = os.environ.get("DEBUG") == "1"
+if ENV == "development": DEBUG = True
+`)
+	writeFile(t, filepath.Join(root, "server.js"), `const env = process.env.NODE_ENV || "development";
+app.use(cors());
+res.setHeader("Access-Control-Allow-Origin", "*");
+// Not flagged.
+app.use(cors({ origin: allowedOrigins }));
+`)
+	writeFile(t, filepath.Join(root, "main.go"), `package main
+
+func main() {
+	gin.SetMode(gin.DebugMode)
+	router.Use(cors.New(cors.Config{AllowAllOrigins: true}))
+	gin.SetMode(gin.ReleaseMode)
+}
+`)
+	writeFile(t, filepath.Join(root, "config", "prod.yaml"), "server:\n  debug: true\n  log_level: debug\ncors:\n  allowed_origins: \"*\"\n  allowed_origins_list: [\"https://app.example.org\"]\n")
+	writeFile(t, filepath.Join(root, ".env"), "APP_ENV=development\nAPP_DEBUG=false\n")
+
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-029") {
+		if f.GetSeverity() != sdk.SeverityLow || f.GetMetadata()["priority"] != "backlog" {
+			t.Errorf("TRIAGE-029 should be LOW/backlog, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		rel, _ := filepath.Rel(root, f.GetLocation().GetFilePath())
+		got[filepath.ToSlash(rel)] = append(got[filepath.ToSlash(rel)], f.GetLocation().GetStartLine())
+	}
+	// Settings hardcoded on; values read from the environment, guarded by
+	// a development check, or restricted to listed origins are not flagged.
+	want := map[string][]int32{
+		"settings.py":      {1, 2, 3, 4},
+		"server.js":        {1, 2, 3},
+		"main.go":          {4, 5},
+		"config/prod.yaml": {2, 5},
+		".env":             {1},
+	}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-029 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsTemplateInjection(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "views.py"), `return render_template_string(request.args["tpl"])
+return render_template_string("<h1>%s</h1>" % request.args.get("name"))
+page = jinja2.Environment().from_string(request.form["body"]).render()
+# Not flagged.
+return render_template_string("<h1>{{ name }}</h1>", name=request.args.get("name"))
+page = SandboxedEnvironment().from_string(request.form["body"]).render()
+`)
+	writeFile(t, filepath.Join(root, "render.js"), `const html = ejs.render(req.body.template, { user });
+const fn = _.template("Hello " + req.query.name);
+// Not flagged.
+const page = ejs.render(layout, { name: req.query.name });
+`)
+	writeFile(t, filepath.Join(root, "page.go"), `package main
+
+func page(w http.ResponseWriter, r *http.Request) {
+	t, _ := template.New("page").Funcs(funcs).Parse(r.FormValue("layout"))
+	tmpl.Parse(r.URL.Query().Get("t"))
+	// Not flagged.
+	t.Execute(w, r.URL.Query().Get("name"))
+	when, _ := time.Parse(time.RFC3339, r.FormValue("at"))
+}
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-030") {
+		if f.GetSeverity() != sdk.SeverityHigh || f.GetMetadata()["priority"] != "immediate" {
+			t.Errorf("TRIAGE-030 should be HIGH/immediate, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetLocation().GetStartLine())
+	}
+	// Request values in the template source; request values passed as
+	// template data, sandboxed rendering, and other Parse calls are not
+	// flagged.
+	want := map[string][]int32{"views.py": {1, 2, 3}, "render.js": {1, 2}, "page.go": {4, 5}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-030 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsCertificateVerificationBypass(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "client.go"), `package main
+
+func client() *tls.Config {
+	return &tls.Config{
+		VerifyPeerCertificate: func(raw [][]byte, chains [][]*x509.Certificate) error { return nil },
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return nil
+		},
+	}
+}
+
+// Not flagged.
+func pinned() *tls.Config {
+	return &tls.Config{
+		VerifyPeerCertificate: func(raw [][]byte, _ [][]*x509.Certificate) error {
+			if len(raw) == 0 { return nil }
+			return checkPin(raw[0])
+		},
+	}
+}
+`)
+	writeFile(t, filepath.Join(root, "fetch.py"), `ctx = ssl.create_default_context()
+ctx.check_hostname = False
+ctx.verify_mode = ssl.CERT_NONE
+sock = ssl.wrap_socket(raw, cert_reqs=ssl.CERT_NONE)
+ctx = ssl._create_unverified_context()
+# Not flagged.
+ctx.verify_mode = ssl.CERT_REQUIRED
+`)
+	writeFile(t, filepath.Join(root, "agent.js"), `const a = new https.Agent({ checkServerIdentity: () => {} });
+tls.connect({ host, checkServerIdentity: function (host, cert) { return undefined; } });
+// Not flagged.
+tls.connect({ host, checkServerIdentity: (host, cert) => { if (!pinned(cert)) return new Error("pin"); } });
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-031") {
+		if f.GetSeverity() != sdk.SeverityHigh || f.GetMetadata()["priority"] != "immediate" {
+			t.Errorf("TRIAGE-031 should be HIGH/immediate, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetLocation().GetStartLine())
+	}
+	// A verifier whose body is only "return nil" is flagged on the same
+	// line or the next; one that checks something is not.
+	want := map[string][]int32{"client.go": {5, 7}, "fetch.py": {2, 3, 4, 5}, "agent.js": {1, 2}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-031 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsReflectedDispatch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "rpc.go"), `package main
+
+func rpc(w http.ResponseWriter, r *http.Request) {
+	m := reflect.ValueOf(svc).MethodByName(r.URL.Query().Get("method"))
+	// Not flagged.
+	m = reflect.ValueOf(svc).MethodByName("Health")
+	if allowedMethods[name] { m = reflect.ValueOf(svc).MethodByName(r.FormValue("method")) }
+}
+`)
+	writeFile(t, filepath.Join(root, "views.py"), `handler = getattr(api, request.args.get("action"))
+value = getattr(obj, request.form["field"], None)
+# Not flagged.
+handler = getattr(api, "list_" + kind)
+name = getattr(request.args, "get")
+`)
+	writeFile(t, filepath.Join(root, "rpc.js"), `const out = service[req.body.method](req.body.args);
+handlers[req.params.action]();
+// Not flagged.
+const value = config[req.query.key];
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-032") {
+		if f.GetSeverity() != sdk.SeverityMedium || f.GetMetadata()["priority"] != "scheduled" {
+			t.Errorf("TRIAGE-032 should be MEDIUM/scheduled, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetLocation().GetStartLine())
+	}
+	// Names from the request are flagged; constant names, allowlisted
+	// lookups, and request values that are read but not called are not.
+	want := map[string][]int32{"rpc.go": {4}, "views.py": {1, 2}, "rpc.js": {1, 2}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-032 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsWeakJWTKey(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "auth.go"), `package main
+
+var jwtKey = []byte("my_secret_key")
+
+func sign(claims jwt.Claims) (string, error) {
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("k"))
+	key, err := rsa.GenerateKey(mrand.New(mrand.NewSource(1)), 2048)
+	// Not flagged.
+	s, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	key, err = rsa.GenerateKey(rand.Reader, 2048)
+	secretKey := os.Getenv("JWT_SECRET")
+	return s, err
+}
+`)
+	writeFile(t, filepath.Join(root, "auth.py"), `token = jwt.encode({"sub": user.id, "role": "admin"}, "secret", algorithm="HS256")
+app.config["JWT_SECRET_KEY"] = "change-me"
+SECRET_KEY = os.environ.get("SECRET_KEY", "dev")
+SECRET_KEY = "".join(random.choice(chars) for _ in range(50))
+# Not flagged.
+token = jwt.encode(payload, settings.SECRET_KEY, algorithm="HS256")
+SECRET_KEY = os.environ["SECRET_KEY"]
+signing_key = secrets.token_hex(32)
+`)
+	writeFile(t, filepath.Join(root, "auth.js"), `const token = jwt.sign({ sub: user.id }, "secret", { expiresIn: "1h" });
+const JWT_SECRET = process.env.JWT_SECRET || "dev-secret";
+const signingKey = Math.random().toString(36);
+// Not flagged.
+const token2 = jwt.sign({ sub: user.id }, process.env.JWT_SECRET, { algorithm: "HS256" });
+const jwtSecret = crypto.randomBytes(32).toString("hex");
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-033") {
+		if f.GetSeverity() != sdk.SeverityHigh || f.GetMetadata()["priority"] != "immediate" {
+			t.Errorf("TRIAGE-033 should be HIGH/immediate, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		line := f.GetLocation().GetStartLine()
+		got[file] = append(got[file], line)
+		// Weak random sources are medium confidence, literal secrets high.
+		weak := file == "auth.go" && line == 7 || file == "auth.py" && line == 4 || file == "auth.js" && line == 3
+		want := sdk.ConfidenceHigh
+		if weak {
+			want = sdk.ConfidenceMedium
+		}
+		if f.GetConfidence() != want {
+			t.Errorf("%s:%d: expected confidence %v, got %v", file, line, want, f.GetConfidence())
+		}
+	}
+	want := map[string][]int32{"auth.go": {3, 6, 7}, "auth.py": {1, 2, 3, 4}, "auth.js": {1, 2, 3}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-033 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsWeakCryptoConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "crypt.go"), `package main
+
+func setup(key, password, salt []byte) {
+	c, err := rc4.NewCipher(key)
+	block, err := des.NewTripleDESCipher(key)
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	dk := pbkdf2.Key(password, salt, 1000, 32, sha256.New)
+	// Not flagged.
+	priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	dk = pbkdf2.Key(password, salt, 600000, 32, sha256.New)
+	aead, err := cipher.NewGCM(block)
+}
+`)
+	writeFile(t, filepath.Join(root, "crypt.py"), `cipher = AES.new(key, AES.MODE_ECB)
+enc = Cipher(algorithms.AES(key), modes.ECB()).encryptor()
+enc = Cipher(algorithms.ARC4(key), mode=None).encryptor()
+private_key = rsa.generate_private_key(public_exponent=65537, key_size=1024)
+dk = hashlib.pbkdf2_hmac("sha256", password, salt, 1000)
+kdf = PBKDF2HMAC(algorithm=hashes.SHA256(), length=32, salt=salt, iterations=5000)
+# Not flagged.
+dk = hashlib.pbkdf2_hmac("sha256", password, salt, 600000, 32)
+private_key = rsa.generate_private_key(public_exponent=65537, key_size=4096)
+`)
+	writeFile(t, filepath.Join(root, "crypt.js"), `const c = crypto.createCipheriv("aes-256-ecb", key, null);
+const enc = CryptoJS.AES.encrypt(msg, key, { mode: CryptoJS.mode.ECB });
+const rc = crypto.createCipheriv("rc4", key, "");
+const { publicKey } = crypto.generateKeyPairSync("rsa", { modulusLength: 1024 });
+const dk = crypto.pbkdf2Sync(password, salt, 1000, 32, "sha256");
+// Not flagged.
+const g = crypto.createCipheriv("aes-256-gcm", key, iv);
+const dk2 = crypto.pbkdf2Sync(password, salt, 600000, 32, "sha256");
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	keySizeLines := map[string][]int32{"crypt.go": {6, 7}, "crypt.py": {4, 5, 6}, "crypt.js": {4, 5}}
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-034") {
+		if f.GetSeverity() != sdk.SeverityMedium || f.GetMetadata()["priority"] != "scheduled" {
+			t.Errorf("TRIAGE-034 should be MEDIUM/scheduled, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		line := f.GetLocation().GetStartLine()
+		got[file] = append(got[file], line)
+		// Key sizes and iteration counts are medium confidence.
+		want := sdk.ConfidenceHigh
+		if slices.Contains(keySizeLines[file], line) {
+			want = sdk.ConfidenceMedium
+		}
+		if f.GetConfidence() != want {
+			t.Errorf("%s:%d: expected confidence %v, got %v", file, line, want, f.GetConfidence())
+		}
+	}
+	want := map[string][]int32{"crypt.go": {4, 5, 6, 7}, "crypt.py": {1, 2, 3, 4, 5, 6}, "crypt.js": {1, 2, 3, 4, 5}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-034 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsErrorDetailsInResponse(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "handler.go"), `package main
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "error: %v", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+	w.Write(debug.Stack())
+	c.JSON(500, gin.H{"error": err.Error()})
+	// Not flagged.
+	fmt.Fprintf(os.Stderr, "error: %v", err)
+	http.Error(w, "internal error", http.StatusInternalServerError)
+	log.Printf("handler: %v", err)
+}
+`)
+	writeFile(t, filepath.Join(root, "views.py"), `return traceback.format_exc(), 500
+return jsonify({"error": str(e)}), 500
+resp = make_response(traceback.format_exc(), 500)
+return str(exc), 500
+# Not flagged.
+logger.error(traceback.format_exc())
+return jsonify({"error": "internal error"}), 500
+`)
+	writeFile(t, filepath.Join(root, "server.js"), `res.status(500).send(err.stack);
+res.send(error);
+res.json({ message: err.message });
+res.status(500).json({ error: err });
+ctx.body = err.stack;
+// Not flagged.
+console.error(err.stack);
+res.status(500).json({ error: "internal error" });
+res.send(result);
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-035") {
+		if f.GetSeverity() != sdk.SeverityLow || f.GetConfidence() != sdk.ConfidenceMedium || f.GetMetadata()["priority"] != "scheduled" {
+			t.Errorf("TRIAGE-035 should be LOW/medium/scheduled, got %v/%v/%s", f.GetSeverity(), f.GetConfidence(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetLocation().GetStartLine())
+	}
+	want := map[string][]int32{"handler.go": {4, 5, 6, 7}, "views.py": {1, 2, 3, 4}, "server.js": {1, 2, 3, 4, 5}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-035 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsUnescapedHTML(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "view.go"), `package main
+
+func render(w http.ResponseWriter, r *http.Request, bio string) {
+	data := template.HTML(bio)
+	fmt.Fprintf(w, "<h1>Hello %s</h1>", r.FormValue("name"))
+	attr := template.HTMLAttr(r.FormValue("attr"))
+	// Not flagged.
+	banner := template.HTML("<b>static</b>")
+	safe := template.HTML(html.EscapeString(bio))
+	fmt.Fprintf(w, "hello %s", r.FormValue("name"))
+}
+`)
+	writeFile(t, filepath.Join(root, "views.py"), `html = mark_safe(user.bio)
+return Markup(request.args["q"])
+tpl = render_template_string("<p>{{ comment | safe }}</p>", comment=c)
+env = Environment(autoescape=False)
+# Not flagged.
+html = mark_safe("<br>")
+html = mark_safe(bleach.clean(user.bio))
+tpl = render_template_string("<p>{{ comment }}</p>", comment=c)
+`)
+	writeFile(t, filepath.Join(root, "page.js"), `el.innerHTML = location.hash.slice(1);
+el.innerHTML = `+"`<b>${name}</b>`"+`;
+el.insertAdjacentHTML("beforeend", html);
+document.write(params.get("q"));
+const c = <div dangerouslySetInnerHTML={{ __html: post.body }} />;
+$("#out").html(data);
+res.send("<h1>" + req.query.name + "</h1>");
+// Not flagged.
+el.innerHTML = "";
+el.innerHTML = DOMPurify.sanitize(html);
+el.textContent = location.hash;
+res.send({ name: req.query.name });
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	mediumLines := map[string][]int32{"view.go": {5}, "page.js": {1, 2, 3, 4, 6, 7}}
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-036") {
+		if f.GetSeverity() != sdk.SeverityHigh || f.GetMetadata()["priority"] != "immediate" {
+			t.Errorf("TRIAGE-036 should be HIGH/immediate, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		line := f.GetLocation().GetStartLine()
+		got[file] = append(got[file], line)
+		// Raw HTML sinks are medium confidence, escaping bypasses high.
+		want := sdk.ConfidenceHigh
+		if slices.Contains(mediumLines[file], line) {
+			want = sdk.ConfidenceMedium
+		}
+		if f.GetConfidence() != want {
+			t.Errorf("%s:%d: expected confidence %v, got %v", file, line, want, f.GetConfidence())
+		}
+	}
+	// The template.HTMLAttr cast on line 6 is TRIAGE-038's.
+	want := map[string][]int32{"view.go": {4, 5}, "views.py": {1, 2, 3, 4}, "page.js": {1, 2, 3, 4, 5, 6, 7}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-036 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsFormattedCommand(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "run.go"), `package main
+
+func run(host string) {
+	out, err := exec.Command("sh", "-c", fmt.Sprintf("ping -c 1 %s", host)).Output()
+	cmd := exec.CommandContext(ctx, fmt.Sprintf("/usr/bin/%s", tool))
+	// Not flagged.
+	out, err = exec.Command("ping", "-c", "1", host).Output()
+	msg := fmt.Sprintf("ping %s", host)
+}
+`)
+	writeFile(t, filepath.Join(root, "run.py"), `os.system("ping -c 1 %s" % host)
+subprocess.run(f"tar xf {archive}", shell=True)
+subprocess.check_output(["sh", "-c", "grep {} log".format(pattern)])
+os.popen(f"whois {domain}")
+# Not flagged.
+subprocess.run(["tar", "xf", archive])
+os.system(f"ping -c 1 {shlex.quote(host)}")
+log.info(f"running {cmd}")
+`)
+	writeFile(t, filepath.Join(root, "run.js"), `execSync(`+"`convert ${input} out.png`"+`);
+child_process.exec(`+"`git log ${branch}`"+`, cb);
+exec(util.format("ping %s", host));
+// Not flagged.
+execFile("convert", [input, "out.png"]);
+const m = pattern.exec(`+"`${text}`"+`);
+exec("ls -la");
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-037") {
+		if f.GetSeverity() != sdk.SeverityMedium || f.GetConfidence() != sdk.ConfidenceMedium || f.GetMetadata()["priority"] != "immediate" {
+			t.Errorf("TRIAGE-037 should be MEDIUM/medium/immediate, got %v/%v/%s", f.GetSeverity(), f.GetConfidence(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetLocation().GetStartLine())
+	}
+	want := map[string][]int32{"run.go": {4, 5}, "run.py": {1, 2, 3, 4}, "run.js": {1, 2, 3}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-037 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsGoTemplateBypass(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "text.go"), `package main
+
+import (
+	"net/http"
+	"text/template"
+)
+
+var page = template.Must(template.New("page").Parse("<h1>Hello {{.Name}}</h1>"))
+var files = template.Must(template.ParseFiles("views/index.html"))
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	page.Execute(w, r.URL.Query())
+	// Not flagged.
+	page.Execute(os.Stdout, data)
+	cfg := template.Must(template.New("cfg").Parse("port: {{.Port}}"))
+}
+`)
+	writeFile(t, filepath.Join(root, "html.go"), `package main
+
+import "html/template"
+
+var page = template.Must(template.New("page").Parse("<h1>Hello {{.Name}}</h1>"))
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	page.Execute(w, data)
+	js := template.JS(r.FormValue("callback"))
+	href := template.URL(link)
+	// Not flagged.
+	js = template.JS("init()")
+	js = template.JS(template.JSEscapeString(name))
+}
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-038") {
+		if f.GetSeverity() != sdk.SeverityHigh || f.GetMetadata()["priority"] != "immediate" {
+			t.Errorf("TRIAGE-038 should be HIGH/immediate, got %v/%s", f.GetSeverity(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		line := f.GetLocation().GetStartLine()
+		got[file] = append(got[file], line)
+		// text/template use is medium confidence, casts high.
+		want := sdk.ConfidenceHigh
+		if file == "text.go" {
+			want = sdk.ConfidenceMedium
+		}
+		if f.GetConfidence() != want {
+			t.Errorf("%s:%d: expected confidence %v, got %v", file, line, want, f.GetConfidence())
+		}
+	}
+	want := map[string][]int32{"text.go": {8, 9, 12}, "html.go": {9, 10}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-038 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
+func TestScanFindsEnvCommand(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "env.go"), `package main
+
+func run(ctx context.Context) {
+	out, err := exec.Command(os.Getenv("DEPLOY_CMD")).Output()
+	cmd := exec.CommandContext(ctx, "sh", "-c", os.Getenv("HOOK"))
+	// Not flagged.
+	home := os.Getenv("HOME")
+	out, err = exec.Command("git", "status").Output()
+}
+`)
+	writeFile(t, filepath.Join(root, "env.py"), `os.system(os.environ["BUILD_CMD"])
+subprocess.run(os.getenv("HOOK"), shell=True)
+eval(os.environ.get("EXPR"))
+# Not flagged.
+path = os.environ["PATH"]
+subprocess.run(["make", "build"], env=env)
+ast.literal_eval(os.environ["LIMITS"])
+subprocess.run(cmd, env=os.environ)
+`)
+	writeFile(t, filepath.Join(root, "env.js"), `execSync(process.env.POST_INSTALL);
+child_process.exec(process.env.HOOK_CMD, cb);
+eval(process.env.SNIPPET);
+// Not flagged.
+const port = process.env.PORT;
+execSync("npm test", { env: process.env });
+`)
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	got := make(map[string][]int32)
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-039") {
+		if f.GetSeverity() != sdk.SeverityLow || f.GetConfidence() != sdk.ConfidenceLow || f.GetMetadata()["priority"] != "scheduled" {
+			t.Errorf("TRIAGE-039 should be LOW/low/scheduled, got %v/%v/%s", f.GetSeverity(), f.GetConfidence(), f.GetMetadata()["priority"])
+		}
+		file := filepath.Base(f.GetLocation().GetFilePath())
+		got[file] = append(got[file], f.GetLocation().GetStartLine())
+	}
+	want := map[string][]int32{"env.go": {4, 5}, "env.py": {1, 2, 3}, "env.js": {1, 2, 3}}
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("expected TRIAGE-039 in %s on lines %v, got %v", file, lines, got[file])
+		}
+	}
+}
+
 // TestCleanCodeNoFindings is the false-positive guard: ordinary business
 // logic whose identifiers merely contain "eval"/"exec" as a substring
 // (retrieval, medievalTotal, execute, evaluateScore) — with no request access,
@@ -146,6 +1050,128 @@ func TestScanNoWorkspace(t *testing.T) {
 	}
 }
 
+func TestScanDedupeOneFindingPerLine(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": testdataDir(t),
+		"dedupe":         true,
+	})
+
+	type lineKey struct {
+		file string
+		line int32
+	}
+	seen := make(map[lineKey]bool)
+	for _, f := range resp.GetFindings() {
+		k := lineKey{f.GetLocation().GetFilePath(), f.GetLocation().GetStartLine()}
+		if seen[k] {
+			t.Errorf("duplicate finding at %s:%d after dedupe", k.file, k.line)
+		}
+		seen[k] = true
+		if f.GetMetadata()["matched_rules"] == "" {
+			t.Errorf("finding at %s:%d missing matched_rules metadata", k.file, k.line)
+		}
+	}
+}
+
+func TestScanMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "top.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "a", "mid.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "a", "b", "deep.py"), "eval(x)\n")
+
+	tests := []struct {
+		maxDepth float64
+		want     int
+	}{
+		{0, 1},
+		{1, 2},
+		{2, 3},
+	}
+	client := testClient(t)
+	for _, tt := range tests {
+		resp := invokeScanWithInput(t, client, map[string]any{
+			"workspace_root": root,
+			"max_depth":      tt.maxDepth,
+		})
+		if got := len(findByRule(resp.GetFindings(), "TRIAGE-001")); got != tt.want {
+			t.Errorf("max_depth=%v: got %d TRIAGE-001 findings, want %d", tt.maxDepth, got, tt.want)
+		}
+	}
+}
+
+func TestScanNotRecursive(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "top.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "a", "mid.py"), "eval(x)\n")
+
+	client := testClient(t)
+	for _, tt := range []struct {
+		input map[string]any
+		want  int
+	}{
+		{map[string]any{"workspace_root": root}, 2},
+		{map[string]any{"workspace_root": root, "recursive": true}, 2},
+		{map[string]any{"workspace_root": root, "recursive": false}, 1},
+		{map[string]any{"workspace_root": root, "recursive": false, "max_depth": float64(3)}, 1},
+	} {
+		resp := invokeScanWithInput(t, client, tt.input)
+		if got := len(findByRule(resp.GetFindings(), "TRIAGE-001")); got != tt.want {
+			t.Errorf("%v: got %d TRIAGE-001 findings, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestScanReportsUnreadableFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "ok.py"), "eval(x)\n")
+	if err := os.Symlink(filepath.Join(root, "missing.py"), filepath.Join(root, "broken.py")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	if len(findByRule(resp.GetFindings(), "TRIAGE-001")) != 1 {
+		t.Error("expected the readable file to still be scanned")
+	}
+	var summary, detail bool
+	for _, d := range resp.GetDiagnostics() {
+		switch {
+		case d.GetMessage() == "skipped 1 unreadable file(s)":
+			summary = true
+		case strings.HasPrefix(d.GetMessage(), "unreadable: broken.py"):
+			detail = true
+		}
+	}
+	if !summary || !detail {
+		t.Errorf("expected unreadable file diagnostics, got %v", resp.GetDiagnostics())
+	}
+}
+
+func TestScanMinimal(t *testing.T) {
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": testdataDir(t),
+		"minimal":        true,
+	})
+
+	if len(resp.GetFindings()) == 0 {
+		t.Fatal("expected findings in minimal mode")
+	}
+	for _, f := range resp.GetFindings() {
+		if len(f.GetMetadata()) != 0 {
+			t.Errorf("%s: expected no metadata, got %v", f.GetRuleId(), f.GetMetadata())
+		}
+		if f.GetFingerprint() != "" {
+			t.Errorf("%s: expected no fingerprint", f.GetRuleId())
+		}
+		if f.GetLocation().GetStartLine() == 0 || f.GetSeverity() == pluginv1.Severity_SEVERITY_UNSPECIFIED {
+			t.Errorf("%s: expected location and severity to be kept", f.GetRuleId())
+		}
+	}
+}
+
 func TestScanWithAITriageDisabled(t *testing.T) {
 	client := testClient(t)
 	resp := invokeScan(t, client, testdataDir(t))
@@ -192,6 +1218,53 @@ func TestScanWithAITriageNoProvider(t *testing.T) {
 	}
 }
 
+func BenchmarkScan(b *testing.B) {
+	benchmarkScan(b, false)
+}
+
+func BenchmarkScanMinimal(b *testing.B) {
+	benchmarkScan(b, true)
+}
+
+// benchmarkScan scans a generated workspace of copies of the testdata files,
+// reporting throughput in bytes of source per second.
+func benchmarkScan(b *testing.B, minimal bool) {
+	root := b.TempDir()
+	entries, err := os.ReadDir("testdata")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var size int64
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", e.Name()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := range 20 {
+			path := filepath.Join(root, fmt.Sprintf("pkg%02d", i), e.Name())
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				b.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				b.Fatal(err)
+			}
+			size += int64(len(data))
+		}
+	}
+
+	req := sdk.ToolRequest{Input: map[string]any{"workspace_root": root, "minimal": minimal}}
+	b.SetBytes(size)
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := handleScan(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // --- helpers ---
 
 func testdataDir(t *testing.T) string {
@@ -203,6 +1276,16 @@ func testdataDir(t *testing.T) string {
 	return filepath.Join(filepath.Dir(filename), "testdata")
 }
 
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func testClient(t *testing.T) pluginv1.PluginServiceClient {
 	t.Helper()
 	lis := bufconn.Listen(1024 * 1024)
@@ -238,6 +1321,22 @@ func invokeScan(t *testing.T, client pluginv1.PluginServiceClient, workspaceRoot
 	return resp
 }
 
+func invokeScanWithInput(t *testing.T, client pluginv1.PluginServiceClient, input map[string]any) *pluginv1.InvokeToolResponse {
+	t.Helper()
+	in, err := structpb.NewStruct(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{
+		ToolName: "scan",
+		Input:    in,
+	})
+	if err != nil {
+		t.Fatalf("InvokeTool(scan): %v", err)
+	}
+	return resp
+}
+
 func findByRule(findings []*pluginv1.Finding, ruleID string) []*pluginv1.Finding {
 	var result []*pluginv1.Finding
 	for _, f := range findings {
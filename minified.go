@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxLineLength is the longest line, in bytes, that rules are matched
+// against when max_line_length is not set. Longer lines are almost always
+// minified code.
+const defaultMaxLineLength = 2000
+
+// lineLengthCap is the max_line_length setting. A limit of zero or less
+// matches lines of any length.
+type lineLengthCap struct {
+	Default int
+	// ByLanguage overrides Default per language name, as reported in the
+	// language metadata.
+	ByLanguage map[string]int
+}
+
+// forExt returns the limit for files with the given extension.
+func (c lineLengthCap) forExt(ext string) int {
+	if n, ok := c.ByLanguage[extToLanguage(ext)]; ok {
+		return n
+	}
+	return c.Default
+}
+
+// parseMaxLineLength reads the max_line_length input: a number applied to
+// every file, or an object of language name to limit with an optional
+// "default" key.
+func parseMaxLineLength(input map[string]any) (lineLengthCap, error) {
+	limits := lineLengthCap{Default: defaultMaxLineLength}
+	switch v := input["max_line_length"].(type) {
+	case nil:
+	case float64:
+		limits.Default = int(v)
+	case map[string]any:
+		limits.ByLanguage = make(map[string]int, len(v))
+		for lang, n := range v {
+			f, ok := n.(float64)
+			if !ok {
+				return lineLengthCap{}, fmt.Errorf("max_line_length.%s must be a number", lang)
+			}
+			if lang == "default" {
+				limits.Default = int(f)
+			} else {
+				limits.ByLanguage[lang] = int(f)
+			}
+		}
+	default:
+		return lineLengthCap{}, fmt.Errorf("max_line_length must be a number or an object")
+	}
+	return limits, nil
+}
+
+// longLineSkip records the lines of one file that were not matched because
+// they exceeded max_line_length.
+type longLineSkip struct {
+	Path  string
+	Lines []int
+	Limit int
+}
+
+// maxListedLongLines bounds the line numbers listed per file in the
+// long-line diagnostics.
+const maxListedLongLines = 20
+
+// String formats the skip as a diagnostic message.
+func (s longLineSkip) String() string {
+	n := min(len(s.Lines), maxListedLongLines)
+	nums := make([]string, n)
+	for i, line := range s.Lines[:n] {
+		nums[i] = strconv.Itoa(line)
+	}
+	msg := fmt.Sprintf("long lines: %s: line(s) %s", s.Path, strings.Join(nums, ", "))
+	if more := len(s.Lines) - n; more > 0 {
+		msg += fmt.Sprintf(" and %d more", more)
+	}
+	return msg + fmt.Sprintf(" (over %d bytes)", s.Limit)
+}
+
+// lineReader splits source text into lines like bufio.ScanLines, but
+// discards lines longer than limit instead of buffering them, so minified
+// files with enormous lines do not need to fit in memory.
+type lineReader struct {
+	br    *bufio.Reader
+	limit int
+	buf   []byte
+}
+
+// next returns the next line without its line ending. skipped reports that
+// the line exceeded the limit; its text is then empty. At the end of input
+// it returns io.EOF.
+func (r *lineReader) next() (line string, skipped bool, err error) {
+	r.buf = r.buf[:0]
+	read := false
+	for {
+		chunk, isPrefix, err := r.br.ReadLine()
+		if err == io.EOF && read {
+			break
+		}
+		if err != nil {
+			return "", false, err
+		}
+		read = true
+		if !skipped {
+			if r.limit > 0 && len(r.buf)+len(chunk) > r.limit {
+				skipped = true
+				r.buf = r.buf[:0]
+			} else {
+				r.buf = append(r.buf, chunk...)
+			}
+		}
+		if !isPrefix {
+			break
+		}
+	}
+	// ReadLine keeps a carriage return at the very end of the input, which
+	// bufio.ScanLines drops.
+	return string(bytes.TrimSuffix(r.buf, []byte{'\r'})), skipped, nil
+}
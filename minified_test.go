@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readLines(t *testing.T, src string, limit int) (lines []string, skipped int) {
+	t.Helper()
+	r := &lineReader{br: bufio.NewReaderSize(strings.NewReader(src), 16), limit: limit}
+	for {
+		line, skip, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return lines, skipped
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if skip {
+			skipped++
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	lines, skipped := readLines(t, "a\r\n"+long+"\nb\n\nc\r", 50)
+	want := []string{"a", "", "b", "", "c"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+	if skipped != 1 {
+		t.Errorf("expected 1 skipped line, got %d", skipped)
+	}
+
+	lines, skipped = readLines(t, long+"\n", 0)
+	if skipped != 0 || len(lines) != 1 || lines[0] != long {
+		t.Errorf("a zero limit should keep every line, got %d skipped", skipped)
+	}
+}
+
+func TestParseMaxLineLength(t *testing.T) {
+	c, err := parseMaxLineLength(map[string]any{})
+	if err != nil || c.forExt(".js") != defaultMaxLineLength {
+		t.Errorf("expected the default limit, got %+v, %v", c, err)
+	}
+
+	c, err = parseMaxLineLength(map[string]any{"max_line_length": map[string]any{
+		"default":    float64(500),
+		"javascript": float64(200),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.forExt(".js") != 200 || c.forExt(".py") != 500 {
+		t.Errorf("unexpected limits %+v", c)
+	}
+
+	for _, v := range []any{"long", map[string]any{"go": "x"}} {
+		if _, err := parseMaxLineLength(map[string]any{"max_line_length": v}); err == nil {
+			t.Errorf("expected an error for %v", v)
+		}
+	}
+}
+
+func TestScanSkipsLongLines(t *testing.T) {
+	root := t.TempDir()
+	minified := "var a=1;" + strings.Repeat("eval(x);", 300)
+	writeFile(t, filepath.Join(root, "bundle.js"), minified+"\neval(userInput)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 1 || found[0].GetLocation().GetStartLine() != 2 {
+		t.Fatalf("expected one finding on line 2, got %v", found)
+	}
+	if got := found[0].GetMetadata()["minified_lines_skipped"]; got != "1" {
+		t.Errorf("expected minified_lines_skipped=1, got %q", got)
+	}
+	var listed bool
+	for _, d := range resp.GetDiagnostics() {
+		if d.GetMessage() == "long lines: bundle.js: line(s) 1 (over 2000 bytes)" {
+			listed = true
+		}
+	}
+	if !listed {
+		t.Errorf("expected a diagnostic listing the skipped line, got %v", resp.GetDiagnostics())
+	}
+
+	resp = invokeScanWithInput(t, client, map[string]any{"workspace_root": root, "max_line_length": float64(0)})
+	if n := len(findByRule(resp.GetFindings(), "TRIAGE-001")); n != 2 {
+		t.Errorf("max_line_length 0 should scan every line, got %d findings", n)
+	}
+}
+
+func TestLongLineSkipString(t *testing.T) {
+	lines := make([]int, maxListedLongLines+3)
+	for i := range lines {
+		lines[i] = i + 1
+	}
+	got := longLineSkip{Path: "dist/app.js", Lines: lines, Limit: 500}.String()
+	if !strings.HasPrefix(got, "long lines: dist/app.js: line(s) 1, 2, ") || !strings.HasSuffix(got, ", 20 and 3 more (over 500 bytes)") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestScanSkipsShortLines(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.js"), "        eval(\n            x)\nresult = eval(userInput)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+	if n := len(findByRule(resp.GetFindings(), "TRIAGE-001")); n != 2 {
+		t.Fatalf("expected both lines flagged by default, got %d", n)
+	}
+
+	// The indented fragment is 5 bytes once trimmed, despite its 13-byte
+	// line.
+	resp = invokeScanWithInput(t, client, map[string]any{"workspace_root": root, "min_line_length": float64(6)})
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 1 || found[0].GetLocation().GetStartLine() != 3 {
+		t.Errorf("expected only the line 3 finding, got %v", found)
+	}
+}
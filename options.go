@@ -0,0 +1,244 @@
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// scanOptions holds the per-invocation settings read from the scan tool input.
+type scanOptions struct {
+	WorkspaceRoot string
+	AITriage      bool
+	Dedupe        bool
+	EstimateCost  bool
+
+	// DedupeCopies tags findings with a content_hash and collapses matches
+	// repeated across copies of a file; see dedupeCopies.
+	DedupeCopies bool
+
+	// RunID identifies the scan invocation. When set, findings carry it as
+	// scan_run_id along with scanned_at and plugin_version.
+	RunID string
+
+	// Progress reports each scanned file to registered progress callbacks;
+	// nil reports nothing.
+	Progress *progressReporter
+
+	// RootName prefixes finding paths when several workspace roots are
+	// scanned together; see scanRoot.
+	RootName string
+	// Frameworks holds the framework the root's manifests name, by
+	// extension, for files that import none; see projectFrameworks.
+	Frameworks map[string]string
+	// Projects finds the sub-project of each file in the root; see
+	// projectFinder.
+	Projects *projectFinder
+
+	// StdinPaths scans the files listed on standard input instead of
+	// walking the workspace root; see scanPathList.
+	StdinPaths bool
+
+	// ScanArchives scans the source files inside jar, wheel, and egg
+	// archives found by the walk; see scanArchive.
+	ScanArchives bool
+
+	// MaxDepth bounds how many directories below the workspace root the walk
+	// descends. Negative means unlimited.
+	MaxDepth int
+	// Recursive walks into subdirectories of the workspace root; false
+	// scans only the files directly in it. It defaults to true.
+	Recursive bool
+
+	// BaselineFile lists findings from an earlier scan. When set, findings
+	// are tagged new or existing and missing entries reported as resolved.
+	BaselineFile string
+	// WriteBaseline receives the fingerprints of this scan's findings as a
+	// text baseline; see writeBaseline.
+	WriteBaseline string
+	// ClassifyOnly keeps AI triage from changing severity and priority; see
+	// applyAdjustments.
+	ClassifyOnly bool
+	// TriageChanged reuses the AI verdicts recorded in a JSON baseline for
+	// unchanged findings; see reuseCachedVerdicts.
+	TriageChanged bool
+	// FailOnNew is the severity at or above which new findings produce an
+	// error diagnostic. Empty disables the check.
+	FailOnNew string
+
+	// DropBelow is the severity below which findings are removed from the
+	// result once AI triage has run; empty keeps every finding.
+	DropBelow string
+
+	// OutputFile receives every finding as NDJSON, gzipped when OutputGzip
+	// is set or the name ends in .gz.
+	OutputFile string
+	OutputGzip bool
+	// OutputFormat is the format of OutputFile: outputFormatNDJSON, the
+	// default, outputFormatText, or outputFormatCSV.
+	OutputFormat string
+	// FlushAfter is the flush_threshold: the number of findings past which
+	// they are streamed to OutputFile during the scan; 0 keeps every
+	// finding in memory. Flusher does the streaming and is nil when
+	// FlushAfter is 0.
+	FlushAfter int
+	Flusher    *findingFlusher
+	// PageSize returns the findings this many at a time, caching the rest
+	// for page_token calls; 0 returns them all.
+	PageSize int
+	// Compact strips heavy metadata from the response findings.
+	Compact bool
+	// AffectedFiles adds a ranked index of files with findings as info
+	// diagnostics.
+	AffectedFiles bool
+	// GroupBy, when "rule", adds one info diagnostic per rule listing its
+	// findings' locations; see ruleSections.
+	GroupBy string
+	// Minimal emits only rule ID, severity, confidence, and location, with
+	// the rule description as message: no fingerprint or metadata.
+	Minimal bool
+
+	// CancelGrace is how long the phases after the walk may run once the
+	// scan is cancelled; see handleScan. Zero skips those that need it.
+	CancelGrace time.Duration
+
+	// LineBudget bounds the time spent matching rules against one line;
+	// rules left when it runs out are skipped for that line. Zero disables it.
+	LineBudget time.Duration
+	// MaxLineLength skips matching on longer lines, which are usually
+	// minified code. Set from the max_line_length input.
+	MaxLineLength lineLengthCap
+	// LongLines, when set, collects one entry per file with lines skipped
+	// for MaxLineLength, for the scan's diagnostics.
+	LongLines *[]longLineSkip
+	// MinLineLength skips matching on lines shorter than this many bytes
+	// once leading and trailing whitespace is trimmed. Zero disables it.
+	MinLineLength int
+
+	// LanguageMap assigns files matching a glob to a language ahead of
+	// extension-based detection. Set from the language_map input.
+	LanguageMap []languageMapping
+
+	// ForceLanguage, if set, is the extension key of the pattern set used
+	// for every file regardless of its name or content. Set from the
+	// force_language input.
+	ForceLanguage string
+
+	// Encoding is applied to source files without a byte order mark.
+	Encoding string
+
+	// SkipGenerated drops files whose header marks them as generated code
+	// instead of tagging their findings generated=true.
+	SkipGenerated bool
+
+	// Strict fails the scan on the first file or directory that cannot be
+	// read instead of reporting it in a diagnostic.
+	Strict bool
+
+	// DiffFile and DiffBase restrict findings to lines a unified diff adds,
+	// read from a file or computed with git against a base revision.
+	// AddedLines holds the parsed result; nil scans every line.
+	DiffFile   string
+	DiffBase   string
+	AddedLines addedLines
+
+	// Source is the version of the files scanned: sourceWorktree, the
+	// default, or sourceStaged for their content in the git index.
+	Source string
+
+	// BaseRef and HeadRef select a commit range: head is scanned and only
+	// findings absent at base are reported, attributed to the commit that
+	// introduced them. HeadRef defaults to HEAD.
+	BaseRef string
+	HeadRef string
+
+	// Scanned counts the files scanned, for the walk span; nil counts
+	// nothing.
+	Scanned *atomic.Int64
+}
+
+// parseScanOptions reads the scan tool input into a scanOptions value.
+// Unknown keys and values of the wrong type are ignored.
+func parseScanOptions(input map[string]any) scanOptions {
+	return scanOptions{
+		WorkspaceRoot: inputString(input, "workspace_root"),
+		AITriage:      inputBool(input, "ai_triage"),
+		Dedupe:        inputBool(input, "dedupe"),
+		EstimateCost:  inputBool(input, "estimate_cost"),
+		DedupeCopies:  inputBool(input, "dedupe_copies"),
+		MaxDepth:      inputInt(input, "max_depth", -1),
+		Recursive:     input["recursive"] != false,
+		ScanArchives:  inputBool(input, "scan_archives"),
+		StdinPaths:    inputBool(input, "paths_from_stdin"),
+		MinLineLength: inputInt(input, "min_line_length", 0),
+		LineBudget:    time.Duration(inputInt(input, "line_budget_ms", 0)) * time.Millisecond,
+		CancelGrace:   time.Duration(inputInt(input, "cancel_grace_ms", 0)) * time.Millisecond,
+		Encoding:      strings.ToLower(inputString(input, "encoding")),
+		BaselineFile:  inputString(input, "baseline_file"),
+		WriteBaseline: inputString(input, "generate_baseline"),
+		FailOnNew:     inputString(input, "fail_on_new"),
+		DropBelow:     inputString(input, "drop_below"),
+		TriageChanged: inputBool(input, "triage_changed_only"),
+		ClassifyOnly:  inputBool(input, "classify_only"),
+		OutputFile:    inputString(input, "output_file"),
+		OutputGzip:    inputBool(input, "output_gzip"),
+		OutputFormat:  strings.ToLower(inputString(input, "output_format")),
+		FlushAfter:    inputInt(input, "flush_threshold", 0),
+		PageSize:      inputInt(input, "page_size", 0),
+		Compact:       inputBool(input, "compact"),
+		Minimal:       inputBool(input, "minimal"),
+		AffectedFiles: inputBool(input, "affected_files"),
+		GroupBy:       strings.ToLower(inputString(input, "group_by")),
+		Strict:        inputBool(input, "strict"),
+		SkipGenerated: inputBool(input, "skip_generated"),
+		DiffFile:      inputString(input, "diff_file"),
+		DiffBase:      inputString(input, "diff_base"),
+		BaseRef:       inputString(input, "base_ref"),
+		HeadRef:       inputString(input, "head_ref"),
+		Source:        strings.ToLower(inputString(input, "source")),
+	}
+}
+
+// inputString returns a string input value, or "" if missing or not a string.
+func inputString(input map[string]any, key string) string {
+	s, _ := input[key].(string)
+	return s
+}
+
+// inputStrings returns a list input as strings, skipping elements that are
+// not strings. A single string is treated as a one-element list.
+func inputStrings(input map[string]any, key string) []string {
+	switch v := input[key].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		var out []string
+		for _, e := range v {
+			if s, ok := e.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// inputBool returns a bool input value, or false if missing or not a bool.
+func inputBool(input map[string]any, key string) bool {
+	b, _ := input[key].(bool)
+	return b
+}
+
+// inputInt returns an integer input value, or def if missing or not a number.
+// structpb decodes every JSON number as float64, so fractions are truncated.
+func inputInt(input map[string]any, key string, def int) int {
+	f, ok := input[key].(float64)
+	if !ok {
+		return def
+	}
+	return int(f)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// sort_by values. The default keeps scan order, or location order when
+// several roots are scanned.
+const (
+	sortBySeverity = "severity"
+	sortByPriority = "priority"
+	sortByScore    = "score"
+	sortByAIConf   = "ai_confidence"
+)
+
+// aiConfidenceKey is the metadata key of the AI triage confidence score, a
+// number from 0 to 1, on findings the model scored.
+const aiConfidenceKey = "ai_confidence"
+
+// sortWeights weigh severity against priority for sort_by score.
+type sortWeights struct {
+	Severity float64
+	Priority float64
+}
+
+// defaultSortWeights count severity and priority equally.
+var defaultSortWeights = sortWeights{Severity: 1, Priority: 1}
+
+// parseSortOrder reads the sort_by and sort_weights inputs. Weights are only
+// accepted with sort_by score.
+func parseSortOrder(input map[string]any) (string, sortWeights, error) {
+	weights := defaultSortWeights
+	by := strings.ToLower(inputString(input, "sort_by"))
+	switch by {
+	case "", sortBySeverity, sortByPriority, sortByScore, sortByAIConf:
+	default:
+		return "", weights, fmt.Errorf("unsupported sort_by %q (supported: severity, priority, score, ai_confidence)", by)
+	}
+	raw, ok := input["sort_weights"].(map[string]any)
+	if !ok {
+		return by, weights, nil
+	}
+	if by != sortByScore {
+		return "", weights, fmt.Errorf("sort_weights needs sort_by score")
+	}
+	for key, v := range raw {
+		w, ok := v.(float64)
+		if !ok || w < 0 {
+			return "", weights, fmt.Errorf("sort_weights.%s must be a non-negative number", key)
+		}
+		switch key {
+		case "severity":
+			weights.Severity = w
+		case "priority":
+			weights.Priority = w
+		default:
+			return "", weights, fmt.Errorf("sort_weights: unknown key %q (supported: severity, priority)", key)
+		}
+	}
+	return by, weights, nil
+}
+
+// severityScore maps info through critical onto 0 through 1. Unspecified
+// severities score 0.
+func severityScore(s pluginv1.Severity) float64 {
+	if s == pluginv1.Severity_SEVERITY_UNSPECIFIED {
+		return 0
+	}
+	return float64(sdk.SeverityInfo-s) / float64(sdk.SeverityInfo-sdk.SeverityCritical)
+}
+
+// confidenceScore is the AI triage confidence score of f when it has a
+// valid one, and otherwise its rule confidence mapped from low through high
+// onto 0 through 1. Unspecified confidences score 0.
+func confidenceScore(f *pluginv1.Finding) float64 {
+	if v, err := strconv.ParseFloat(f.GetMetadata()[aiConfidenceKey], 64); err == nil && v >= 0 && v <= 1 {
+		return v
+	}
+	if f.GetConfidence() == pluginv1.Confidence_CONFIDENCE_UNSPECIFIED {
+		return 0
+	}
+	return float64(sdk.ConfidenceLow-f.GetConfidence()) / float64(sdk.ConfidenceLow-sdk.ConfidenceHigh)
+}
+
+// priorityScore maps the least through the most urgent level onto 0 through
+// 1. Priorities that are not levels score 0.
+func (l priorityLevels) priorityScore(p string) float64 {
+	if l == nil {
+		l = defaultPriorityLevels
+	}
+	rank, ok := l.rank(p)
+	if !ok {
+		return 0
+	}
+	if len(l) == 1 {
+		return 1
+	}
+	return float64(len(l)-1-rank) / float64(len(l)-1)
+}
+
+// orderFindings sorts findings most urgent first by the sort_by key: severity
+// then priority, priority then severity, or the weighted sum of their scores;
+// or most confident first, then by severity and priority. Ties keep location
+// order.
+func orderFindings(findings []*pluginv1.Finding, by string, weights sortWeights, levels priorityLevels) {
+	if by == "" {
+		return
+	}
+	sortFindings(findings)
+	score := func(f *pluginv1.Finding) float64 {
+		return weights.Severity*severityScore(f.GetSeverity()) +
+			weights.Priority*levels.priorityScore(f.GetMetadata()["priority"])
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		pa, pb := a.GetMetadata()["priority"], b.GetMetadata()["priority"]
+		switch by {
+		case sortBySeverity:
+			if a.GetSeverity() != b.GetSeverity() {
+				return severityMoreSevere(a.GetSeverity(), b.GetSeverity())
+			}
+			return levels.less(pa, pb)
+		case sortByPriority:
+			if levels.less(pa, pb) || levels.less(pb, pa) {
+				return levels.less(pa, pb)
+			}
+			return severityMoreSevere(a.GetSeverity(), b.GetSeverity())
+		case sortByAIConf:
+			if ca, cb := confidenceScore(a), confidenceScore(b); ca != cb {
+				return ca > cb
+			}
+			if a.GetSeverity() != b.GetSeverity() {
+				return severityMoreSevere(a.GetSeverity(), b.GetSeverity())
+			}
+			return levels.less(pa, pb)
+		default:
+			return score(a) > score(b)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func orderingFixture() []*pluginv1.Finding {
+	finding := func(rule string, sev pluginv1.Severity, priority string, line int32) *pluginv1.Finding {
+		return &pluginv1.Finding{
+			RuleId:   rule,
+			Severity: sev,
+			Location: &pluginv1.Location{FilePath: "app.py", StartLine: line},
+			Metadata: map[string]string{"priority": priority},
+		}
+	}
+	return []*pluginv1.Finding{
+		finding("low-scheduled", pluginv1.Severity_SEVERITY_LOW, "scheduled", 1),
+		finding("high-backlog", pluginv1.Severity_SEVERITY_HIGH, "backlog", 2),
+		finding("high-immediate", pluginv1.Severity_SEVERITY_HIGH, "immediate", 3),
+		finding("info-informational", pluginv1.Severity_SEVERITY_INFO, "informational", 4),
+		finding("medium-immediate", pluginv1.Severity_SEVERITY_MEDIUM, "immediate", 5),
+	}
+}
+
+func ruleOrder(findings []*pluginv1.Finding) []string {
+	var ids []string
+	for _, f := range findings {
+		ids = append(ids, f.GetRuleId())
+	}
+	return ids
+}
+
+func TestOrderFindings(t *testing.T) {
+	cases := []struct {
+		by      string
+		weights sortWeights
+		want    []string
+	}{
+		{"", defaultSortWeights, []string{"low-scheduled", "high-backlog", "high-immediate", "info-informational", "medium-immediate"}},
+		{sortBySeverity, defaultSortWeights, []string{"high-immediate", "high-backlog", "medium-immediate", "low-scheduled", "info-informational"}},
+		{sortByPriority, defaultSortWeights, []string{"high-immediate", "medium-immediate", "low-scheduled", "high-backlog", "info-informational"}},
+		{sortByScore, defaultSortWeights, []string{"high-immediate", "medium-immediate", "high-backlog", "low-scheduled", "info-informational"}},
+		{sortByScore, sortWeights{Severity: 1, Priority: 2}, []string{"high-immediate", "medium-immediate", "low-scheduled", "high-backlog", "info-informational"}},
+		{sortByScore, sortWeights{Severity: 1, Priority: 0}, []string{"high-backlog", "high-immediate", "medium-immediate", "low-scheduled", "info-informational"}},
+	}
+	for _, c := range cases {
+		findings := orderingFixture()
+		orderFindings(findings, c.by, c.weights, nil)
+		if got := ruleOrder(findings); !slices.Equal(got, c.want) {
+			t.Errorf("sort_by %q %+v: got %v, want %v", c.by, c.weights, got, c.want)
+		}
+	}
+}
+
+func TestOrderFindingsByAIConfidence(t *testing.T) {
+	findings := orderingFixture()
+	// AI scores where triaged; the rest fall back to rule confidence.
+	findings[0].Metadata[aiConfidenceKey] = "0.95"
+	findings[1].Metadata[aiConfidenceKey] = "0.3"
+	findings[2].Confidence = pluginv1.Confidence_CONFIDENCE_MEDIUM
+	findings[3].Confidence = pluginv1.Confidence_CONFIDENCE_HIGH
+	findings[4].Metadata[aiConfidenceKey] = "not a number"
+	findings[4].Confidence = pluginv1.Confidence_CONFIDENCE_MEDIUM
+
+	orderFindings(findings, sortByAIConf, defaultSortWeights, nil)
+	want := []string{"info-informational", "low-scheduled", "high-immediate", "medium-immediate", "high-backlog"}
+	if got := ruleOrder(findings); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOrderFindingsCustomLevels(t *testing.T) {
+	findings := orderingFixture()
+	levels := priorityLevels{"backlog", "scheduled", "immediate", "informational"}
+	orderFindings(findings, sortByPriority, defaultSortWeights, levels)
+	want := []string{"high-backlog", "low-scheduled", "high-immediate", "medium-immediate", "info-informational"}
+	if got := ruleOrder(findings); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	by, weights, err := parseSortOrder(map[string]any{
+		"sort_by":      "Score",
+		"sort_weights": map[string]any{"priority": 3.0},
+	})
+	if err != nil || by != sortByScore || weights != (sortWeights{Severity: 1, Priority: 3}) {
+		t.Errorf("got %q %+v %v", by, weights, err)
+	}
+
+	for _, input := range []map[string]any{
+		{"sort_by": "rule"},
+		{"sort_by": "severity", "sort_weights": map[string]any{"severity": 1.0}},
+		{"sort_by": "score", "sort_weights": map[string]any{"severity": -1.0}},
+		{"sort_by": "score", "sort_weights": map[string]any{"priority": "high"}},
+		{"sort_by": "score", "sort_weights": map[string]any{"confidence": 1.0}},
+	} {
+		if _, _, err := parseSortOrder(input); err == nil {
+			t.Errorf("%v: expected an error", input)
+		}
+	}
+}
+
+func TestScanSortBySeverity(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.py"), "token = jwt.encode(payload, key)\n")
+	writeFile(t, filepath.Join(root, "b.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"sort_by":        "severity",
+	})
+
+	findings := resp.GetFindings()
+	if len(findings) < 2 {
+		t.Fatalf("expected findings in both files, got %d", len(findings))
+	}
+	for i := 1; i < len(findings); i++ {
+		if severityMoreSevere(findings[i].GetSeverity(), findings[i-1].GetSeverity()) {
+			t.Errorf("finding %d (%v) sorted after a less severe one (%v)", i, findings[i].GetSeverity(), findings[i-1].GetSeverity())
+		}
+	}
+	if got := filepath.Base(findings[0].GetLocation().GetFilePath()); got != "b.py" {
+		t.Errorf("expected the eval finding first, got %s", got)
+	}
+}
+
+func TestScanSortInvalid(t *testing.T) {
+	client := testClient(t)
+	for _, extra := range []map[string]any{
+		{"sort_by": "file"},
+		{"sort_weights": map[string]any{"severity": 2}},
+	} {
+		fields := map[string]any{"workspace_root": t.TempDir()}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+		if got := status.Code(err); got != codes.InvalidArgument || !strings.Contains(err.Error(), "sort_") {
+			t.Errorf("%v: expected an invalid argument error, got %v", extra, err)
+		}
+	}
+}
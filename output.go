@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// heavyMetadataKeys lists the metadata removed from findings in compact mode.
+// They stay in output_file, which is written before compaction.
+var heavyMetadataKeys = []string{
+	"remediation",
+	"ai_triage_reason",
+}
+
+// resolveOutputPath makes a relative output path relative to the workspace
+// root. The result is cleaned, so it names the file withinRoot checks.
+func resolveOutputPath(workspaceRoot, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(workspaceRoot, path)
+}
+
+// Formats of output_file, chosen by the output_format input.
+const (
+	outputFormatNDJSON = "ndjson"
+	outputFormatText   = "text"
+	outputFormatCSV    = "csv"
+)
+
+// outputFile is an open output_file. Callers write through the embedded
+// buffered writer and must call close.
+type outputFile struct {
+	*bufio.Writer
+	f  *os.File
+	zw *gzip.Writer
+}
+
+// createOutputFile creates path for buffered writing. The output is
+// gzip-compressed when gz is set or path ends in ".gz".
+func createOutputFile(path string, gz bool) (*outputFile, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	out := &outputFile{f: f}
+	var w io.Writer = f
+	if gz || strings.HasSuffix(path, ".gz") {
+		out.zw = gzip.NewWriter(f)
+		w = out.zw
+	}
+	out.Writer = bufio.NewWriter(w)
+	return out, nil
+}
+
+// close flushes buffered output and closes the file, returning the first
+// error.
+func (o *outputFile) close() error {
+	err := o.Flush()
+	if o.zw != nil {
+		if cerr := o.zw.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeOutputFile creates path and passes write a buffered writer for it.
+// The output is gzip-compressed when gz is set or path ends in ".gz".
+func writeOutputFile(path string, gz bool, write func(*bufio.Writer) error) error {
+	out, err := createOutputFile(path, gz)
+	if err != nil {
+		return err
+	}
+	if err := write(out.Writer); err != nil {
+		_ = out.close()
+		return err
+	}
+	return out.close()
+}
+
+// writeFindingsNDJSON writes one JSON-encoded finding per line to path.
+func writeFindingsNDJSON(path string, findings []*pluginv1.Finding, gz bool) error {
+	return writeOutputFile(path, gz, func(bw *bufio.Writer) error {
+		return writeNDJSON(bw, findings)
+	})
+}
+
+// writeNDJSON writes one JSON-encoded finding per line to bw.
+func writeNDJSON(bw *bufio.Writer, findings []*pluginv1.Finding) error {
+	for _, finding := range findings {
+		data, err := protojson.Marshal(finding)
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportSeverities lists the standard severities in the order the text
+// report totals them.
+var reportSeverities = []pluginv1.Severity{
+	sdk.SeverityCritical,
+	sdk.SeverityHigh,
+	sdk.SeverityMedium,
+	sdk.SeverityLow,
+	sdk.SeverityInfo,
+}
+
+// writeTextReport writes findings to path as a plain-text report for people
+// and for committing as a snapshot: a header with totals, then one
+// "file:line [SEVERITY] RULE-ID message" line per finding, grouped by
+// workspace-relative file with a blank line between files. Findings are
+// sorted by file, line, rule, and message, and the report holds nothing
+// that changes between runs, such as times or run IDs, so unchanged code
+// gives an identical report.
+func writeTextReport(path, root string, findings []*pluginv1.Finding, gz bool) error {
+	type entry struct {
+		file     string
+		line     int32
+		severity string
+		ruleID   string
+		message  string
+	}
+	entries := make([]entry, 0, len(findings))
+	counts := make(map[pluginv1.Severity]int)
+	files := make(map[string]bool)
+	for _, f := range findings {
+		file, line := findingLocation(f)
+		file = relativeFindingPath(root, file)
+		files[file] = true
+		counts[f.GetSeverity()]++
+		entries = append(entries, entry{
+			file:     file,
+			line:     line,
+			severity: strings.ToUpper(strings.TrimPrefix(severityLabel(f), "SEVERITY_")),
+			ruleID:   f.GetRuleId(),
+			message:  strings.Join(strings.Fields(f.GetMessage()), " "),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		if a.line != b.line {
+			return a.line < b.line
+		}
+		if a.ruleID != b.ruleID {
+			return a.ruleID < b.ruleID
+		}
+		return a.message < b.message
+	})
+
+	return writeOutputFile(path, gz, func(bw *bufio.Writer) error {
+		fmt.Fprintf(bw, "# Triage report: %d finding(s) in %d file(s)\n", len(entries), len(files))
+		totals := make([]string, len(reportSeverities))
+		for i, sev := range reportSeverities {
+			totals[i] = fmt.Sprintf("%s %d", severityName(sev), counts[sev])
+		}
+		fmt.Fprintf(bw, "# By severity: %s\n", strings.Join(totals, ", "))
+		for i, e := range entries {
+			if i == 0 || e.file != entries[i-1].file {
+				fmt.Fprintln(bw)
+			}
+			fmt.Fprintf(bw, "%s:%d [%s] %s %s\n", e.file, e.line, e.severity, e.ruleID, e.message)
+		}
+		return nil
+	})
+}
+
+// csvColumns is the header row of a CSV report.
+var csvColumns = []string{"rule_id", "severity", "priority", "file", "line", "message", "classification", "ai_reason"}
+
+// writeCSVReport writes findings to path as CSV for spreadsheets: a header
+// row, then one row per finding in the order given, with the file relative
+// to root. Fields are quoted as RFC 4180 requires, and fields a spreadsheet
+// would read as a formula are prefixed with a single quote, since messages
+// and AI reasons carry text from the scanned code and the model.
+func writeCSVReport(path, root string, findings []*pluginv1.Finding, gz bool) error {
+	return writeOutputFile(path, gz, func(bw *bufio.Writer) error {
+		w := csv.NewWriter(bw)
+		if err := w.Write(csvColumns); err != nil {
+			return err
+		}
+		for _, f := range findings {
+			file, line := findingLocation(f)
+			row := []string{
+				f.GetRuleId(),
+				strings.ToLower(strings.TrimPrefix(severityLabel(f), "SEVERITY_")),
+				f.GetMetadata()["priority"],
+				relativeFindingPath(root, file),
+				fmt.Sprint(line),
+				strings.Join(strings.Fields(f.GetMessage()), " "),
+				f.GetMetadata()["ai_classification"],
+				f.GetMetadata()["ai_triage_reason"],
+			}
+			for i, field := range row {
+				row[i] = csvSafe(field)
+			}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+}
+
+// csvSafe prefixes a field that spreadsheets would evaluate as a formula
+// with a single quote, so it is shown as text.
+func csvSafe(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+// compactFindings strips heavy metadata from findings in place to keep the
+// gRPC response small.
+func compactFindings(findings []*pluginv1.Finding) {
+	for _, f := range findings {
+		for _, key := range heavyMetadataKeys {
+			delete(f.Metadata, key)
+		}
+	}
+}
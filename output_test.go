@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestScanOutputFileCompact(t *testing.T) {
+	outDir := t.TempDir()
+	outPath := filepath.Join(outDir, "findings.ndjson.gz")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": testdataDir(t),
+		"output_file":    outPath,
+		"compact":        true,
+	})
+
+	if len(resp.GetFindings()) == 0 {
+		t.Fatal("expected findings in the response")
+	}
+	for _, f := range resp.GetFindings() {
+		if _, ok := f.GetMetadata()["remediation"]; ok {
+			t.Fatal("compact response should not carry remediation metadata")
+		}
+	}
+
+	file, err := os.Open(outPath)
+	if err != nil {
+		t.Fatalf("opening output file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("output file should be gzipped: %v", err)
+	}
+
+	var written int
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		var f pluginv1.Finding
+		if err := protojson.Unmarshal(scanner.Bytes(), &f); err != nil {
+			t.Fatalf("line %d is not a finding: %v", written+1, err)
+		}
+		if f.GetMetadata()["remediation"] == "" {
+			t.Errorf("output file finding %s lost its remediation metadata", f.GetRuleId())
+		}
+		written++
+	}
+	if written != len(resp.GetFindings()) {
+		t.Errorf("output file has %d findings, response has %d", written, len(resp.GetFindings()))
+	}
+}
+
+func TestScanOutputFormatText(t *testing.T) {
+	root := testdataDir(t)
+	outDir := t.TempDir()
+	client := testClient(t)
+
+	var reports []string
+	var resp *pluginv1.InvokeToolResponse
+	for i := range 2 {
+		outPath := filepath.Join(outDir, fmt.Sprintf("report%d.txt", i))
+		resp = invokeScanWithInput(t, client, map[string]any{
+			"workspace_root": root,
+			"output_file":    outPath,
+			"output_format":  "text",
+		})
+		data, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, string(data))
+	}
+	if reports[0] != reports[1] {
+		t.Errorf("reports of the same tree should be identical:\n%s\n---\n%s", reports[0], reports[1])
+	}
+
+	lines := strings.Split(strings.TrimSuffix(reports[0], "\n"), "\n")
+	n := len(resp.GetFindings())
+	if !strings.HasPrefix(lines[0], fmt.Sprintf("# Triage report: %d finding(s) in ", n)) || !strings.HasPrefix(lines[1], "# By severity: critical ") {
+		t.Fatalf("expected a header with totals, got:\n%s", reports[0])
+	}
+
+	var body []string
+	for _, line := range lines[2:] {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/") || !strings.Contains(line, " [") || !strings.Contains(line, "] TRIAGE-") {
+			t.Errorf("expected file:line [SEVERITY] RULE-ID message, got %q", line)
+		}
+		body = append(body, line)
+	}
+	if len(body) != n {
+		t.Errorf("report has %d finding lines, response has %d", len(body), n)
+	}
+	files := make([]string, len(body))
+	for i, line := range body {
+		files[i], _, _ = strings.Cut(line, ":")
+	}
+	if !sort.StringsAreSorted(files) {
+		t.Errorf("expected findings sorted by file, got %v", files)
+	}
+}
+
+func TestScanOutputFormatCSV(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input, \"a,b\")\n")
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"output_file":    "out.csv",
+		"output_format":  "csv",
+	})
+
+	f, err := os.Open(filepath.Join(root, "out.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("expected valid CSV: %v", err)
+	}
+	if !slices.Equal(records[0], csvColumns) {
+		t.Errorf("expected header %v, got %v", csvColumns, records[0])
+	}
+	if len(records)-1 != len(resp.GetFindings()) || len(resp.GetFindings()) == 0 {
+		t.Fatalf("expected a row per finding, got %d rows for %d findings", len(records)-1, len(resp.GetFindings()))
+	}
+	for i, row := range records[1:] {
+		want := resp.GetFindings()[i]
+		if row[0] != want.GetRuleId() || row[3] != "app.py" || row[4] != "1" || row[2] != want.GetMetadata()["priority"] {
+			t.Errorf("row %d: got %v for %s", i, row, want.GetRuleId())
+		}
+		if !strings.Contains(row[5], `"a,b"`) {
+			t.Errorf("row %d: expected the message with its comma and quotes intact, got %q", i, row[5])
+		}
+	}
+}
+
+func TestCSVSafe(t *testing.T) {
+	for in, want := range map[string]string{
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"-1":                "'-1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"TRIAGE-001":        "TRIAGE-001",
+		"":                  "",
+	} {
+		if got := csvSafe(in); got != want {
+			t.Errorf("csvSafe(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestScanOutputFormatInvalid(t *testing.T) {
+	client := testClient(t)
+	for _, extra := range []map[string]any{
+		{"output_format": "xml", "output_file": "out.xml"},
+		{"output_format": "text"},
+		{"output_format": "csv"},
+	} {
+		fields := map[string]any{"workspace_root": t.TempDir()}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+		if got := status.Code(err); got != codes.InvalidArgument {
+			t.Errorf("%v: expected InvalidArgument, got %v", extra, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// Limits on the scan results kept for later pages. Results are held in
+// memory, for pageCacheTTL after the last page was fetched and for at most
+// pageCacheRuns scans at a time.
+const (
+	pageCacheTTL  = 15 * time.Minute
+	pageCacheRuns = 8
+)
+
+// pagedRun is the full result of a paginated scan.
+type pagedRun struct {
+	findings []*pluginv1.Finding
+	pageSize int
+	expires  time.Time
+}
+
+// pageCache holds paginated scan results by run ID, so later pages are
+// served without scanning again.
+type pageCache struct {
+	mu   sync.Mutex
+	runs map[string]*pagedRun
+	now  func() time.Time
+}
+
+// scanPages caches the results of every paginated scan in the process.
+var scanPages = &pageCache{runs: make(map[string]*pagedRun), now: time.Now}
+
+// put stores a scan's findings, dropping expired runs and, past
+// pageCacheRuns, the runs closest to expiring.
+func (c *pageCache) put(runID string, findings []*pluginv1.Finding, pageSize int) *pagedRun {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for id, run := range c.runs {
+		if now.After(run.expires) {
+			delete(c.runs, id)
+		}
+	}
+	for len(c.runs) >= pageCacheRuns {
+		oldest := ""
+		for id, run := range c.runs {
+			if oldest == "" || run.expires.Before(c.runs[oldest].expires) {
+				oldest = id
+			}
+		}
+		delete(c.runs, oldest)
+	}
+	run := &pagedRun{findings: findings, pageSize: pageSize, expires: now.Add(pageCacheTTL)}
+	c.runs[runID] = run
+	return run
+}
+
+// get returns a cached run and extends its expiry.
+func (c *pageCache) get(runID string) (*pagedRun, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	run, ok := c.runs[runID]
+	if !ok || c.now().After(run.expires) {
+		delete(c.runs, runID)
+		return nil, false
+	}
+	run.expires = c.now().Add(pageCacheTTL)
+	return run, true
+}
+
+// pageToken names the page of a run starting at offset. Hosts treat it as
+// opaque.
+func pageToken(runID string, offset int) string {
+	return fmt.Sprintf("%s:%d", runID, offset)
+}
+
+// parsePageToken splits a page token into its run ID and offset.
+func parsePageToken(token string) (runID string, offset int, err error) {
+	runID, off, ok := strings.Cut(token, ":")
+	if ok {
+		offset, err = strconv.Atoi(off)
+	}
+	if !ok || err != nil || runID == "" || offset < 0 {
+		return "", 0, fmt.Errorf("malformed page_token %q", token)
+	}
+	return runID, offset, nil
+}
+
+// servePage replaces the findings in resp with the page of run starting at
+// offset, adding a diagnostic with the range returned and, unless it is the
+// last page, one with the token of the next.
+func servePage(resp *pluginv1.InvokeToolResponse, runID string, run *pagedRun, offset, pageSize int) {
+	total := len(run.findings)
+	start := min(offset, total)
+	end := min(start+pageSize, total)
+	resp.Findings = run.findings[start:end]
+	addDiagnostic(resp, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+		fmt.Sprintf("page: findings %d-%d of %d", min(start+1, end), end, total))
+	if end < total {
+		addDiagnostic(resp, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, "next_page_token: "+pageToken(runID, end))
+	}
+}
+
+// nextScanPage answers a scan call with a page_token from the cached run,
+// using pageSize when positive and otherwise the run's page size.
+func nextScanPage(token string, pageSize int) (*pluginv1.InvokeToolResponse, error) {
+	runID, offset, err := parsePageToken(token)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	run, ok := scanPages.get(runID)
+	if !ok {
+		return nil, newToolError(ErrInvalidInput, "page_token %q has expired or names an unknown scan; scan again", token)
+	}
+	if pageSize <= 0 {
+		pageSize = run.pageSize
+	}
+	resp := &pluginv1.InvokeToolResponse{}
+	addDiagnostic(resp, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, "scan_run_id: "+runID)
+	servePage(resp, runID, run, offset, pageSize)
+	return resp, nil
+}
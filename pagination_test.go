@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// diagnosticValue returns the text after prefix in the first diagnostic
+// starting with it.
+func diagnosticValue(resp *pluginv1.InvokeToolResponse, prefix string) (string, bool) {
+	for _, d := range resp.GetDiagnostics() {
+		if v, ok := strings.CutPrefix(d.GetMessage(), prefix); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func findingKeys(findings []*pluginv1.Finding) []string {
+	var keys []string
+	for _, f := range findings {
+		keys = append(keys, fmt.Sprintf("%s %s:%d", f.GetRuleId(), filepath.Base(f.GetLocation().GetFilePath()), f.GetLocation().GetStartLine()))
+	}
+	return keys
+}
+
+func TestScanPagination(t *testing.T) {
+	root := t.TempDir()
+	for i := range 5 {
+		writeFile(t, filepath.Join(root, fmt.Sprintf("app%d.py", i)), "result = eval(user_input)\n")
+	}
+	client := testClient(t)
+	all := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+
+	var pages [][]string
+	resp := invokeScanWithInput(t, client, map[string]any{"workspace_root": root, "page_size": 2})
+	if _, ok := diagnosticValue(resp, "counts: "); !ok {
+		t.Error("expected the scan's diagnostics on the first page")
+	}
+	for {
+		pages = append(pages, findingKeys(resp.GetFindings()))
+		token, ok := diagnosticValue(resp, "next_page_token: ")
+		if !ok {
+			break
+		}
+		if len(pages) > 10 {
+			t.Fatal("too many pages")
+		}
+		resp = invokeScanWithInput(t, client, map[string]any{"workspace_root": root, "page_token": token})
+	}
+
+	if len(pages) != 3 || len(pages[0]) != 2 || len(pages[2]) != 1 {
+		t.Errorf("expected pages of 2, 2, and 1 finding(s), got %v", pages)
+	}
+	if got, want := slices.Concat(pages...), findingKeys(all.GetFindings()); !slices.Equal(got, want) {
+		t.Errorf("pages should concatenate to the full result:\ngot  %v\nwant %v", got, want)
+	}
+	if r, _ := diagnosticValue(resp, "page: "); r != "findings 5-5 of 5" {
+		t.Errorf("unexpected page diagnostic on the last page: %q", r)
+	}
+}
+
+func TestPageCacheExpiry(t *testing.T) {
+	now := time.Now()
+	cache := &pageCache{runs: make(map[string]*pagedRun), now: func() time.Time { return now }}
+	cache.put("old", nil, 1)
+	for i := range pageCacheRuns - 1 {
+		now = now.Add(time.Minute)
+		cache.put(fmt.Sprint(i), nil, 1)
+	}
+	now = now.Add(time.Minute)
+	if _, ok := cache.get("old"); !ok {
+		t.Fatal("expected the run kept within its TTL")
+	}
+	// Fetching a page extends the run's expiry, so adding another run
+	// evicts the one closest to expiring instead.
+	cache.put("new", nil, 1)
+	if _, ok := cache.get("old"); !ok {
+		t.Error("expected the recently fetched run kept")
+	}
+	if _, ok := cache.get("0"); ok {
+		t.Error("expected the run closest to expiring evicted")
+	}
+	now = now.Add(pageCacheTTL + time.Second)
+	if _, ok := cache.get("new"); ok {
+		t.Error("expected the run expired")
+	}
+}
+
+func TestScanPageTokenInvalid(t *testing.T) {
+	client := testClient(t)
+	for _, extra := range []map[string]any{
+		{"page_token": "no-offset"},
+		{"page_token": "unknown-run:10"},
+		{"page_size": -1},
+		{"page_size": 10, "output_file": "out.ndjson", "flush_threshold": 10},
+	} {
+		fields := map[string]any{"workspace_root": t.TempDir()}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+		if got := status.Code(err); got != codes.InvalidArgument || !strings.Contains(err.Error(), "page_") {
+			t.Errorf("%v: expected an invalid argument error, got %v", extra, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nox-hq/nox/sdk"
+)
+
+// pathListSource is where paths_from_stdin reads the path list. Tests
+// replace it.
+var pathListSource io.Reader = os.Stdin
+
+// stdinPathsRead records that a scan has taken the path list. Standard
+// input can be read only once, so a later scan setting paths_from_stdin
+// fails instead of scanning a list meant for another call.
+var stdinPathsRead atomic.Bool
+
+// errStdinPathsRead is returned by readStdinPaths after the first call.
+var errStdinPathsRead = errors.New("standard input was already read by an earlier scan in this process")
+
+// readStdinPaths returns the newline-delimited path list from standard input.
+// Only the first call in the process reads it. The read runs in a goroutine
+// so that a cancelled scan returns at once; the goroutine is then abandoned
+// until the input ends.
+func readStdinPaths(ctx context.Context) ([]string, error) {
+	if !stdinPathsRead.CompareAndSwap(false, true) {
+		return nil, errStdinPathsRead
+	}
+	type result struct {
+		paths []string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		paths, err := readPathList(pathListSource)
+		done <- result{paths, err}
+	}()
+	select {
+	case r := <-done:
+		return r.paths, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readPathList reads one path per line, as printed by git diff --name-only,
+// ignoring blank lines and surrounding whitespace.
+func readPathList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if p := strings.TrimSpace(scanner.Text()); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// scanPathList scans exactly the listed files, resolving relative paths
+// against root. Paths that are missing, outside root, directories, or in an
+// unsupported language are logged and skipped; other errors go to skip as in
+// walkRoot.
+func scanPathList(ctx context.Context, resp *sdk.ResponseBuilder, root scanRoot, paths []string, opts *scanOptions, skip func(display string, err error) error) error {
+	rootOpts := *opts
+	rootOpts.WorkspaceRoot = root.Path
+	rootOpts.RootName = root.Name
+	rootOpts.Frameworks = projectFrameworks(root.Path)
+	rootOpts.Projects = newProjectFinder(root.Path, root.Name)
+
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		abs := filepath.Clean(p)
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(root.Path, abs)
+		}
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+
+		rel, err := filepath.Rel(root.Path, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			log.Printf("triage: skipping %s: outside the workspace root", p)
+			continue
+		}
+		info, err := os.Stat(abs)
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Printf("triage: skipping %s: no such file", p)
+			continue
+		}
+		if err != nil {
+			if err := skip(root.displayPath(abs), err); err != nil {
+				return err
+			}
+			continue
+		}
+		if info.IsDir() {
+			log.Printf("triage: skipping %s: is a directory", p)
+			continue
+		}
+		if opts.ScanArchives && isArchive(abs) {
+			if err := scanArchive(resp, abs, &rootOpts, skip); err != nil {
+				if err := skip(root.displayPath(abs), err); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		ext := opts.fileLanguage(rel, abs)
+		if ext == "" {
+			log.Printf("triage: skipping %s: unsupported language", p)
+			continue
+		}
+
+		if err := scanFile(resp, abs, ext, &rootOpts); err != nil {
+			if err := skip(root.displayPath(abs), err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
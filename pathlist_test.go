@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadPathList(t *testing.T) {
+	paths, err := readPathList(strings.NewReader("a.py\n\n  pkg/b.go  \r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(paths, ",") != "a.py,pkg/b.go" {
+		t.Errorf("unexpected paths %q", paths)
+	}
+}
+
+func TestScanPathsFromStdin(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "changed.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "untouched.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "pkg", "changed.js"), "eval(userInput)\n")
+	writeFile(t, filepath.Join(root, "README.md"), "eval(x)\n")
+
+	saved := pathListSource
+	t.Cleanup(func() {
+		pathListSource = saved
+		stdinPathsRead.Store(false)
+	})
+	stdinPathsRead.Store(false)
+	pathListSource = strings.NewReader("changed.py\npkg/changed.js\nREADME.md\ndeleted.py\n../outside.py\npkg\nchanged.py\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root":   root,
+		"paths_from_stdin": true,
+	})
+
+	files := make(map[string]int)
+	for _, f := range resp.GetFindings() {
+		rel, _ := filepath.Rel(root, f.GetLocation().GetFilePath())
+		files[filepath.ToSlash(rel)]++
+	}
+	if files["changed.py"] != 1 || files["pkg/changed.js"] != 1 || len(files) != 2 {
+		t.Errorf("expected exactly the listed supported files to be scanned once, got %v", files)
+	}
+}
+
+func TestReadStdinPathsOnce(t *testing.T) {
+	saved := pathListSource
+	t.Cleanup(func() {
+		pathListSource = saved
+		stdinPathsRead.Store(false)
+	})
+	stdinPathsRead.Store(false)
+	pathListSource = strings.NewReader("a.py\n")
+
+	if paths, err := readStdinPaths(context.Background()); err != nil || len(paths) != 1 {
+		t.Fatalf("first read: got %v, %v", paths, err)
+	}
+	if _, err := readStdinPaths(context.Background()); !errors.Is(err, errStdinPathsRead) {
+		t.Errorf("second read: expected errStdinPathsRead, got %v", err)
+	}
+}
+
+func TestReadStdinPathsCancelled(t *testing.T) {
+	saved := pathListSource
+	r, w := io.Pipe()
+	t.Cleanup(func() {
+		_ = w.Close()
+		pathListSource = saved
+		stdinPathsRead.Store(false)
+	})
+	stdinPathsRead.Store(false)
+	pathListSource = r
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := readStdinPaths(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the read to stop at the deadline, got %v", err)
+	}
+}
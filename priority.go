@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// priorityLevels lists the priorities findings may carry, from most to least
+// urgent. Teams with their own taxonomy set it with the priority_levels
+// input; everyone else gets defaultPriorityLevels.
+type priorityLevels []string
+
+// defaultPriorityLevels are the priorities the built-in rules assign.
+var defaultPriorityLevels = priorityLevels{"immediate", "scheduled", "backlog", "informational"}
+
+// parsePriorityLevels reads the priority_levels input, a list of names from
+// most to least urgent. Names are case-insensitive and must be unique; an
+// absent or empty list selects the defaults.
+func parsePriorityLevels(input map[string]any) (priorityLevels, error) {
+	names := inputStrings(input, "priority_levels")
+	if len(names) == 0 {
+		return defaultPriorityLevels, nil
+	}
+	levels := make(priorityLevels, 0, len(names))
+	for i, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("priority_levels[%d] is empty", i)
+		}
+		if _, ok := levels.rank(name); ok {
+			return nil, fmt.Errorf("priority_levels: %q is listed twice", name)
+		}
+		levels = append(levels, name)
+	}
+	return levels, nil
+}
+
+// rank returns the position of priority p, 0 being the most urgent, and
+// whether p is one of the levels. A nil priorityLevels ranks by the defaults.
+func (l priorityLevels) rank(p string) (int, bool) {
+	if l == nil {
+		l = defaultPriorityLevels
+	}
+	for i, name := range l {
+		if strings.EqualFold(name, p) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// valid reports whether p is one of the levels.
+func (l priorityLevels) valid(p string) bool {
+	_, ok := l.rank(p)
+	return ok
+}
+
+// canonical returns p as spelled in the levels, or p unchanged if it is not
+// one of them.
+func (l priorityLevels) canonical(p string) string {
+	if i, ok := l.rank(p); ok {
+		if l == nil {
+			return defaultPriorityLevels[i]
+		}
+		return l[i]
+	}
+	return p
+}
+
+// less reports whether priority a is more urgent than b. Priorities that are
+// not levels, such as those of built-in rules under a custom taxonomy, sort
+// after the levels, alphabetically.
+func (l priorityLevels) less(a, b string) bool {
+	ra, okA := l.rank(a)
+	rb, okB := l.rank(b)
+	switch {
+	case okA && okB:
+		return ra < rb
+	case okA != okB:
+		return okA
+	default:
+		return a < b
+	}
+}
+
+// String returns the levels as a comma-separated list.
+func (l priorityLevels) String() string {
+	if l == nil {
+		l = defaultPriorityLevels
+	}
+	return strings.Join(l, ", ")
+}
+
+// quoted returns the levels as a comma-separated list of JSON strings, as
+// they are listed in the triage system prompt.
+func (l priorityLevels) quoted() string {
+	if l == nil {
+		l = defaultPriorityLevels
+	}
+	parts := make([]string, len(l))
+	for i, name := range l {
+		parts[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkAdjustmentPriorities returns an error for the first severity
+// adjustment whose priority is not one of the levels.
+func checkAdjustmentPriorities(adjustments []pathAdjustment, levels priorityLevels) error {
+	for i, adj := range adjustments {
+		if adj.Priority != "" && !levels.valid(adj.Priority) {
+			return fmt.Errorf("severity_adjustments[%d]: unknown priority %q (priority_levels: %s)", i, adj.Priority, levels)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParsePriorityLevels(t *testing.T) {
+	levels, err := parsePriorityLevels(map[string]any{})
+	if err != nil || levels.String() != "immediate, scheduled, backlog, informational" {
+		t.Errorf("expected the defaults, got %v, %v", levels, err)
+	}
+
+	levels, err = parsePriorityLevels(map[string]any{"priority_levels": []any{"P0", " p1 ", "p2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels.String() != "p0, p1, p2" {
+		t.Errorf("expected trimmed lower-case names, got %v", levels)
+	}
+	if !levels.less("P0", "p2") || levels.less("p2", "p1") {
+		t.Error("expected the configured order")
+	}
+	if !levels.less("p2", "immediate") {
+		t.Error("names outside the levels should sort after them")
+	}
+	if levels.canonical("P1") != "p1" {
+		t.Errorf("expected the configured spelling, got %q", levels.canonical("P1"))
+	}
+
+	for _, bad := range [][]any{{"p0", "P0"}, {"p0", " "}} {
+		if _, err := parsePriorityLevels(map[string]any{"priority_levels": bad}); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestApplyAdjustmentsPriorityLevels(t *testing.T) {
+	cfg := &triageConfig{PriorityLevels: priorityLevels{"p0", "p1", "p2", "p3", "p4"}}
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1},
+			Metadata: map[string]string{"priority": "scheduled"}},
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 2},
+			Metadata: map[string]string{"priority": "scheduled"}},
+	}
+	adjustments := []triageAdjustment{
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 1, AdjustedPriority: "P1", Classification: "true_positive"},
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 2, AdjustedSeverity: "low", AdjustedPriority: "backlog", Classification: "false_positive"},
+	}
+
+	applyAdjustments(findings, adjustments, cfg)
+
+	if got := findings[0].GetMetadata()["priority"]; got != "p1" {
+		t.Errorf("expected the configured level, got %q", got)
+	}
+	md := findings[1].GetMetadata()
+	if md["priority"] != "scheduled" {
+		t.Errorf("a priority outside the levels should not be applied, got %q", md["priority"])
+	}
+	if md["ai_suggested_priority"] != "backlog" || !strings.Contains(md["ai_priority_rejected"], "p0, p1, p2, p3, p4") {
+		t.Errorf("the rejected priority should be recorded, got %v", md)
+	}
+	if findings[1].GetSeverity() != sdk.SeverityLow {
+		t.Errorf("the severity change is still applied, got %v", findings[1].GetSeverity())
+	}
+
+	// Without a configuration the defaults are enforced.
+	f := &pluginv1.Finding{RuleId: "TRIAGE-001", Location: &pluginv1.Location{FilePath: "b.py", StartLine: 1}}
+	applyAdjustments([]*pluginv1.Finding{f}, []triageAdjustment{{RuleID: "TRIAGE-001", File: "b.py", Line: 1, AdjustedPriority: "urgent"}}, nil)
+	if f.GetMetadata()["ai_priority_rejected"] == "" {
+		t.Errorf("expected urgent to be rejected against the defaults, got %v", f.GetMetadata())
+	}
+}
+
+func TestTriagePromptListsPriorityLevels(t *testing.T) {
+	var system string
+	provider := funcProvider(func(_ context.Context, req plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+		system = req.Messages[0].Content
+		return plannerllm.CompletionResponse{Message: plannerllm.Message{Content: "[]"}}, nil
+	})
+	findings := []*pluginv1.Finding{{RuleId: "TRIAGE-001", Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1}}}
+
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{BatchSize: 10, PriorityLevels: priorityLevels{"p0", "p1"}})
+
+	if !strings.Contains(system, `(one of: "p0", "p1")`) || strings.Contains(system, "informational") {
+		t.Errorf("expected the configured levels in the system prompt, got:\n%s", system)
+	}
+	if (*triageConfig)(nil).systemPrompt() != triageSystemPrompt {
+		t.Error("the default prompt should be unchanged")
+	}
+}
+
+func TestScanPriorityLevels(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(request.args['code'])\n")
+	client := testClient(t)
+
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root":  root,
+		"dedupe":          true,
+		"priority_levels": []any{"scheduled", "immediate"},
+	})
+	if len(resp.GetFindings()) != 1 {
+		t.Fatalf("expected one deduped finding, got %d", len(resp.GetFindings()))
+	}
+	if md := resp.GetFindings()[0].GetMetadata(); md["priority"] != "scheduled" {
+		t.Errorf("dedupe should pick the first configured level, got %v", md)
+	}
+
+	input, err := structpb.NewStruct(map[string]any{
+		"workspace_root":  root,
+		"priority_levels": []any{"p0", "p1"},
+		"severity_adjustments": []any{
+			map[string]any{"path": "*.py", "priority": "immediate"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+	if got := status.Code(err); got != codes.InvalidArgument || !strings.Contains(err.Error(), `unknown priority "immediate"`) {
+		t.Errorf("expected an adjustment priority outside the levels to be rejected, got %v (%v)", got, err)
+	}
+}
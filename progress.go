@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/nox-hq/nox-plugin-triage-agent/progress"
+)
+
+// progressReporter tracks the counts of one run and sends events to the
+// callbacks registered with the progress package. A nil reporter, used when
+// no callback is registered, reports nothing.
+type progressReporter struct {
+	runID string
+
+	mu    sync.Mutex
+	files int
+}
+
+// newProgressReporter returns a reporter for a run, or nil if no callback is
+// registered.
+func newProgressReporter(runID string) *progressReporter {
+	if !progress.Active() {
+		return nil
+	}
+	return &progressReporter{runID: runID}
+}
+
+// fileScanned reports that the file at relPath has been scanned.
+func (p *progressReporter) fileScanned(relPath string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.files++
+	files := p.files
+	p.mu.Unlock()
+	progress.Emit(progress.Event{RunID: p.runID, Phase: progress.PhaseScan, Path: relPath, FilesScanned: files})
+}
+
+// batchTriaged reports that done of total AI triage batches, holding sent
+// findings, have completed.
+func (p *progressReporter) batchTriaged(done, total, sent int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	files := p.files
+	p.mu.Unlock()
+	progress.Emit(progress.Event{
+		RunID:        p.runID,
+		Phase:        progress.PhaseTriage,
+		FilesScanned: files,
+		BatchesDone:  done,
+		BatchesTotal: total,
+		FindingsSent: sent,
+	})
+}
@@ -0,0 +1,81 @@
+// Package progress lets programs that link the triage plugin observe its
+// scans and AI triage runs as they happen. Register a callback from an init
+// function of a package the binary imports; the plugin reports to it after
+// each scanned file and each completed AI triage batch.
+package progress
+
+import "sync"
+
+// Phases reported in Event.
+const (
+	PhaseScan   = "scan"
+	PhaseTriage = "triage"
+)
+
+// Event describes one step of a scan or retriage run.
+type Event struct {
+	// RunID identifies the scan the event belongs to, so callbacks can tell
+	// concurrent scans apart. It is empty for retriage.
+	RunID string
+	// Phase is PhaseScan after each file and PhaseTriage after each AI
+	// triage batch.
+	Phase string
+	// Path is the workspace-relative path of the file just scanned; empty
+	// for triage events.
+	Path string
+	// FilesScanned counts the files scanned so far in this run.
+	FilesScanned int
+	// BatchesDone and BatchesTotal count AI triage batches, and
+	// FindingsSent the findings in the batches completed so far.
+	BatchesDone  int
+	BatchesTotal int
+	FindingsSent int
+}
+
+// Func receives progress events. Calls are serialized across all runs, so
+// it need not be safe for concurrent use, but it should return quickly since
+// the scan waits for it.
+type Func func(Event)
+
+var (
+	mu    sync.Mutex
+	funcs []*Func
+)
+
+// Register adds a callback and returns a function that removes it.
+func Register(fn Func) (unregister func()) {
+	if fn == nil {
+		return func() {}
+	}
+	entry := &fn
+	mu.Lock()
+	defer mu.Unlock()
+	funcs = append(funcs, entry)
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, f := range funcs {
+			if f == entry {
+				funcs = append(funcs[:i:i], funcs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Active reports whether any callback is registered, so runs can skip
+// tracking progress nobody observes.
+func Active() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(funcs) > 0
+}
+
+// Emit calls every registered callback with ev, one at a time.
+func Emit(ev Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, fn := range funcs {
+		(*fn)(ev)
+	}
+}
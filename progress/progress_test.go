@@ -0,0 +1,25 @@
+package progress
+
+import "testing"
+
+func TestRegister(t *testing.T) {
+	var got []string
+	unregister := Register(func(ev Event) { got = append(got, ev.Path) })
+	other := Register(func(Event) {})
+	if !Active() {
+		t.Fatal("expected a registered callback to make progress active")
+	}
+
+	Emit(Event{Phase: PhaseScan, Path: "a.py"})
+	unregister()
+	Emit(Event{Phase: PhaseScan, Path: "b.py"})
+	if len(got) != 1 || got[0] != "a.py" {
+		t.Errorf("expected only the event before unregistering, got %v", got)
+	}
+
+	other()
+	if Active() {
+		t.Error("expected no callbacks after unregistering both")
+	}
+	Register(nil)()
+}
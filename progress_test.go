@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nox-hq/nox-plugin-triage-agent/progress"
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// withProgressFunc registers fn for the duration of the test.
+func withProgressFunc(t *testing.T, fn progress.Func) {
+	t.Helper()
+	t.Cleanup(progress.Register(fn))
+}
+
+func TestNilProgressReporter(t *testing.T) {
+	if newProgressReporter("run") != nil {
+		t.Fatal("expected no reporter without a registered callback")
+	}
+	var p *progressReporter
+	p.fileScanned("a.py")
+	p.batchTriaged(1, 1, 1)
+}
+
+func TestProgressDuringConcurrentScans(t *testing.T) {
+	var inFlight atomic.Int32
+	var overlapped atomic.Bool
+	files := make(map[string]int)
+	withProgressFunc(t, func(ev progress.Event) {
+		if inFlight.Add(1) > 1 {
+			overlapped.Store(true)
+		}
+		defer inFlight.Add(-1)
+		if ev.Phase == progress.PhaseScan {
+			files[ev.RunID] = ev.FilesScanned
+		}
+	})
+
+	root := t.TempDir()
+	for _, name := range []string{"a.py", "b.py", "c.js"} {
+		writeFile(t, filepath.Join(root, name), "eval(x)\n")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := handleScan(context.Background(), sdk.ToolRequest{Input: map[string]any{"workspace_root": root}}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlapped.Load() {
+		t.Error("callbacks should never run concurrently")
+	}
+	if len(files) != 4 {
+		t.Fatalf("expected events from 4 runs, got %d", len(files))
+	}
+	for run, n := range files {
+		if n != 3 {
+			t.Errorf("run %s reported %d file(s), want 3", run, n)
+		}
+	}
+}
+
+func TestProgressReportsTriageBatches(t *testing.T) {
+	var events []progress.Event
+	withProgressFunc(t, func(ev progress.Event) { events = append(events, ev) })
+
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1}},
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 2}},
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 3}},
+	}
+	cfg := &triageConfig{BatchSize: 2, Progress: newProgressReporter("run")}
+	aiTriageFindings(context.Background(), &mockProvider{response: "[]"}, "mock-model", findings, cfg)
+
+	if len(events) != 2 {
+		t.Fatalf("expected one event per batch, got %d", len(events))
+	}
+	last := events[1]
+	if last.Phase != progress.PhaseTriage || last.BatchesDone != 2 || last.BatchesTotal != 2 || last.FindingsSent != 3 {
+		t.Errorf("unexpected final event %+v", last)
+	}
+}
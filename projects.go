@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// projectKey is the metadata key naming the sub-project a finding is in.
+const projectKey = "project"
+
+// projectMarkers are the manifests whose directory is the root of a
+// sub-project, such as one service in a monorepo.
+var projectMarkers = []string{"go.mod", "package.json", "pyproject.toml"}
+
+// projectFinder finds the sub-project of files in one workspace root: the
+// nearest directory at or above the file, up to the root, that holds a
+// project marker. Each directory is checked once per scan.
+type projectFinder struct {
+	root string
+	name string // the root's name when there are several, as in RootName
+	dirs map[string]string
+}
+
+func newProjectFinder(root, name string) *projectFinder {
+	return &projectFinder{root: root, name: name, dirs: make(map[string]string)}
+}
+
+// of returns the project of the file at relPath, a slash-separated path
+// relative to the root as used for fingerprints, or "" when no directory up
+// to the root has a marker. The project is the directory's path relative to
+// the root, "." for the root itself, prefixed by the root's name when there
+// are several. A file inside an archive belongs to the archive's project.
+func (p *projectFinder) of(relPath string) string {
+	if p == nil {
+		return ""
+	}
+	relPath, _, _ = strings.Cut(relPath, archiveSeparator)
+	if p.name != "" {
+		relPath = strings.TrimPrefix(relPath, p.name+"/")
+	}
+	project := p.dir(path.Dir(filepath.ToSlash(relPath)))
+	if project == "" || p.name == "" {
+		return project
+	}
+	return path.Join(p.name, project)
+}
+
+// dir returns the project of the slash-separated directory rel.
+func (p *projectFinder) dir(rel string) string {
+	if project, ok := p.dirs[rel]; ok {
+		return project
+	}
+	project := ""
+	if hasProjectMarker(filepath.Join(p.root, filepath.FromSlash(rel))) {
+		project = rel
+	} else if rel != "." && rel != "/" && !strings.HasPrefix(rel, "..") {
+		project = p.dir(path.Dir(rel))
+	}
+	p.dirs[rel] = project
+	return project
+}
+
+// hasProjectMarker reports whether dir holds one of projectMarkers.
+func hasProjectMarker(dir string) bool {
+	for _, name := range projectMarkers {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScanTagsProject(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/mono\n")
+	writeFile(t, filepath.Join(root, "tools", "gen.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "services", "api", "go.mod"), "module example.com/api\n")
+	writeFile(t, filepath.Join(root, "services", "api", "internal", "h.go"), "package internal\n\nvar tlsCfg = &tls.Config{InsecureSkipVerify: true}\n")
+	writeFile(t, filepath.Join(root, "services", "web", "package.json"), "{}\n")
+	writeFile(t, filepath.Join(root, "services", "web", "src", "app.js"), "const r = eval(userInput);\n")
+	writeFile(t, filepath.Join(root, "services", "ml", "pyproject.toml"), "[project]\nname = \"ml\"\n")
+	writeFile(t, filepath.Join(root, "services", "ml", "train.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+
+	want := map[string]string{
+		filepath.Join("tools", "gen.py"):                     ".",
+		filepath.Join("services", "api", "internal", "h.go"): "services/api",
+		filepath.Join("services", "web", "src", "app.js"):    "services/web",
+		filepath.Join("services", "ml", "train.py"):          "services/ml",
+	}
+	seen := map[string]bool{}
+	for _, f := range resp.GetFindings() {
+		rel, err := filepath.Rel(root, f.GetLocation().GetFilePath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		project, ok := want[rel]
+		if !ok {
+			continue
+		}
+		seen[rel] = true
+		if got := f.GetMetadata()[projectKey]; got != project {
+			t.Errorf("%s: expected project %q, got %q", rel, project, got)
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("expected findings in %d files, got %v", len(want), seen)
+	}
+}
+
+func TestScanNoProjectWithoutMarker(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app", "main.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+
+	if len(resp.GetFindings()) == 0 {
+		t.Fatal("expected findings")
+	}
+	for _, f := range resp.GetFindings() {
+		if project, ok := f.GetMetadata()[projectKey]; ok {
+			t.Errorf("expected no project without a marker, got %q", project)
+		}
+	}
+}
+
+func TestProjectFinderMultiRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "svc", "package.json"), "{}\n")
+
+	p := newProjectFinder(root, "backend")
+	if got := p.of("backend/svc/lib/a.js"); got != "backend/svc" {
+		t.Errorf("expected backend/svc, got %q", got)
+	}
+	if got := p.of("backend/svc/bundle.zip!/inner.js"); got != "backend/svc" {
+		t.Errorf("expected an archive entry in the archive's project, got %q", got)
+	}
+	if got := p.of("backend/other/b.js"); got != "" {
+		t.Errorf("expected no project, got %q", got)
+	}
+	if got := (*projectFinder)(nil).of("a.js"); got != "" {
+		t.Errorf("expected a nil finder to give no project, got %q", got)
+	}
+}
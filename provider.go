@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 	"strings"
 
@@ -9,64 +8,84 @@ import (
 	"go.klarlabs.de/agent/contrib/planner-llm/providers"
 )
 
-// resolveProvider creates an LLM provider from NOX_AI_* environment variables.
-// Returns an error if the required API key is not set.
-func resolveProvider() (plannerllm.Provider, string, error) {
-	providerName := strings.ToLower(os.Getenv("NOX_AI_PROVIDER"))
+// defaultModels holds the model used for each provider when NOX_AI_MODEL is unset.
+var defaultModels = map[string]string{
+	"openai":    "gpt-4o",
+	"anthropic": "claude-sonnet-4-5-20250514",
+	"gemini":    "gemini-pro",
+	"ollama":    "llama3",
+	"cohere":    "command-r-plus",
+	"bedrock":   "anthropic.claude-3-sonnet-20240229-v1:0",
+	"copilot":   "gpt-4o",
+}
+
+// configuredModel returns the model AI triage would use, from NOX_AI_MODEL or
+// the configured provider's default. It needs no credentials.
+func configuredModel(s settings) string {
+	if model := s.get("NOX_AI_MODEL"); model != "" {
+		return model
+	}
+	providerName := strings.ToLower(s.get("NOX_AI_PROVIDER"))
 	if providerName == "" {
 		providerName = "openai"
 	}
+	return defaultModels[providerName]
+}
 
-	apiKey := os.Getenv("NOX_AI_API_KEY")
-	model := os.Getenv("NOX_AI_MODEL")
-	baseURL := os.Getenv("NOX_AI_BASE_URL")
+// resolveProvider creates an LLM provider from NOX_AI_* settings, which come
+// from the configuration file or the environment. Returns an error if the
+// required API key is not set.
+func resolveProvider(s settings) (plannerllm.Provider, string, error) {
+	providerName := strings.ToLower(s.get("NOX_AI_PROVIDER"))
+	if providerName == "" {
+		providerName = "openai"
+	}
+
+	apiKey := s.get("NOX_AI_API_KEY")
+	model := s.get("NOX_AI_MODEL")
+	baseURL := s.get("NOX_AI_BASE_URL")
+	if model == "" {
+		model = defaultModels[providerName]
+	}
+	route, err := newProviderRoute(s, baseURL)
+	if err != nil {
+		return nil, "", newToolError(ErrProviderConfig, "%v", err)
+	}
 
 	switch providerName {
 	case "openai":
 		if apiKey == "" {
-			return nil, "", fmt.Errorf("NOX_AI_API_KEY is required for openai provider")
-		}
-		if model == "" {
-			model = "gpt-4o"
+			return nil, "", newToolError(ErrProviderConfig, "NOX_AI_API_KEY is required for openai provider")
 		}
 		p := providers.NewOpenAIProvider(providers.OpenAIConfig{
 			APIKey:  apiKey,
 			BaseURL: baseURL,
 			Model:   model,
 		})
-		return p, model, nil
+		return routeProvider(p, route), model, nil
 
 	case "anthropic":
 		if apiKey == "" {
-			return nil, "", fmt.Errorf("NOX_AI_API_KEY is required for anthropic provider")
-		}
-		if model == "" {
-			model = "claude-sonnet-4-5-20250514"
+			return nil, "", newToolError(ErrProviderConfig, "NOX_AI_API_KEY is required for anthropic provider")
 		}
 		p := providers.NewAnthropicProvider(providers.AnthropicConfig{
 			APIKey:  apiKey,
 			BaseURL: baseURL,
 			Model:   model,
 		})
-		return p, model, nil
+		return routeProvider(p, route), model, nil
 
 	case "gemini":
 		if apiKey == "" {
-			return nil, "", fmt.Errorf("NOX_AI_API_KEY is required for gemini provider")
-		}
-		if model == "" {
-			model = "gemini-pro"
+			return nil, "", newToolError(ErrProviderConfig, "NOX_AI_API_KEY is required for gemini provider")
 		}
 		p := providers.NewGeminiProvider(providers.GeminiConfig{
 			APIKey: apiKey,
 			Model:  model,
 		})
-		return p, model, nil
+		return routeProvider(p, route), model, nil
 
 	case "ollama":
-		if model == "" {
-			model = "llama3"
-		}
 		url := baseURL
 		if url == "" {
 			url = "http://localhost:11434"
@@ -75,21 +94,18 @@ func resolveProvider() (plannerllm.Provider, string, error) {
 			BaseURL: url,
 			Model:   model,
 		})
-		return p, model, nil
+		return routeProvider(p, route), model, nil
 
 	case "cohere":
 		if apiKey == "" {
-			return nil, "", fmt.Errorf("NOX_AI_API_KEY is required for cohere provider")
-		}
-		if model == "" {
-			model = "command-r-plus"
+			return nil, "", newToolError(ErrProviderConfig, "NOX_AI_API_KEY is required for cohere provider")
 		}
 		p := providers.NewCohereProvider(providers.CohereConfig{
 			APIKey:  apiKey,
 			BaseURL: baseURL,
 			Model:   model,
 		})
-		return p, model, nil
+		return routeProvider(p, route), model, nil
 
 	case "bedrock":
 		accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
@@ -97,10 +113,7 @@ func resolveProvider() (plannerllm.Provider, string, error) {
 		sessionToken := os.Getenv("AWS_SESSION_TOKEN")
 		region := os.Getenv("AWS_REGION")
 		if accessKey == "" || secretKey == "" {
-			return nil, "", fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for bedrock provider")
-		}
-		if model == "" {
-			model = "anthropic.claude-3-sonnet-20240229-v1:0"
+			return nil, "", newToolError(ErrProviderConfig, "AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for bedrock provider")
 		}
 		p := providers.NewBedrockProvider(providers.BedrockConfig{
 			Region:          region,
@@ -109,7 +122,7 @@ func resolveProvider() (plannerllm.Provider, string, error) {
 			SessionToken:    sessionToken,
 			Model:           model,
 		})
-		return p, model, nil
+		return routeProvider(p, route), model, nil
 
 	case "copilot":
 		token := apiKey
@@ -117,19 +130,16 @@ func resolveProvider() (plannerllm.Provider, string, error) {
 			token = os.Getenv("GITHUB_TOKEN")
 		}
 		if token == "" {
-			return nil, "", fmt.Errorf("NOX_AI_API_KEY or GITHUB_TOKEN is required for copilot provider")
-		}
-		if model == "" {
-			model = "gpt-4o"
+			return nil, "", newToolError(ErrProviderConfig, "NOX_AI_API_KEY or GITHUB_TOKEN is required for copilot provider")
 		}
 		p := providers.NewCopilotProvider(providers.CopilotConfig{
 			Token:   token,
 			BaseURL: baseURL,
 			Model:   model,
 		})
-		return p, model, nil
+		return routeProvider(p, route), model, nil
 
 	default:
-		return nil, "", fmt.Errorf("unsupported provider: %s (supported: openai, anthropic, gemini, ollama, cohere, bedrock, copilot)", providerName)
+		return nil, "", newToolError(ErrProviderConfig, "unsupported provider: %s (supported: openai, anthropic, gemini, ollama, cohere, bedrock, copilot)", providerName)
 	}
 }
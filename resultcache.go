@@ -0,0 +1,300 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Environment variables configuring the result cache. Like the workspace
+// lock they are read from the environment only, never the configuration
+// file: a workspace that could point the cache at a directory it controls
+// could plant results for its own content.
+const (
+	resultCacheEnv      = "NOX_TRIAGE_RESULT_CACHE"
+	resultCacheTTLEnv   = "NOX_TRIAGE_RESULT_CACHE_TTL"
+	resultCacheMaxMBEnv = "NOX_TRIAGE_RESULT_CACHE_MAX_MB"
+)
+
+// Defaults for the result cache's expiry and size bound.
+const (
+	defaultResultCacheTTL   = 24 * time.Hour
+	defaultResultCacheMaxMB = 256
+)
+
+// resultCache stores the findings of a workspace walk on disk, keyed by a
+// hash of everything the walk depends on, so an identical scan skips the
+// walk. Entries expire ttl after they were written, and the oldest are
+// removed once the directory holds more than maxBytes.
+type resultCache struct {
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+}
+
+// cachedWalk is one cache entry: what the walk produced, including the
+// counts and skipped files its diagnostics report.
+type cachedWalk struct {
+	Findings   []json.RawMessage `json:"findings"`
+	Scanned    int64             `json:"scanned"`
+	Unreadable []string          `json:"unreadable,omitempty"`
+	LongLines  []longLineSkip    `json:"long_lines,omitempty"`
+}
+
+// openResultCache returns the cache configured by NOX_TRIAGE_RESULT_CACHE,
+// or nil when it is unset.
+func openResultCache() *resultCache {
+	dir := os.Getenv(resultCacheEnv)
+	if dir == "" {
+		return nil
+	}
+	ttl, err := time.ParseDuration(os.Getenv(resultCacheTTLEnv))
+	if err != nil || ttl <= 0 {
+		ttl = defaultResultCacheTTL
+	}
+	maxMB, err := strconv.Atoi(os.Getenv(resultCacheMaxMBEnv))
+	if err != nil || maxMB <= 0 {
+		maxMB = defaultResultCacheMaxMB
+	}
+	return &resultCache{dir: dir, ttl: ttl, maxBytes: int64(maxMB) << 20}
+}
+
+// resultCacheBypass names the input that keeps a scan out of the result
+// cache, because the walk reads state the key does not cover (git history,
+// the index, stdin) or has side effects (flushing output), or "" if the
+// scan can be cached.
+func resultCacheBypass(opts *scanOptions) string {
+	switch {
+	case opts.BaseRef != "":
+		return "base_ref"
+	case opts.Source == sourceStaged:
+		return "source"
+	case opts.StdinPaths:
+		return "paths_from_stdin"
+	case opts.DiffBase != "":
+		return "diff_base"
+	case opts.FlushAfter > 0:
+		return "flush_threshold"
+	}
+	return ""
+}
+
+// resultCacheKey hashes what a walk's findings depend on: the plugin
+// version, the rules, the scan input, the path and content of every file
+// the walk scans and of the manifests that tag findings with a framework or
+// project, plus diff_file when set. Files the walk passes over are left out,
+// so changing them does not invalidate the entry. File contents are hashed
+// rather than modification times, so fresh checkouts of the same commit
+// share entries.
+func resultCacheKey(input map[string]any, roots []scanRoot, opts *scanOptions) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "version %s\n", version)
+	for i := range rules {
+		fmt.Fprintf(h, "rule %s %s %v %v %v %v %v\n", rules[i].ID, rules[i].Severity, rules[i].Confidence,
+			rules[i].Patterns, rules[i].Excludes, rules[i].Follows, rules[i].WeakEvidence)
+	}
+	in, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "input %s\n", in)
+	if opts.DiffFile != "" {
+		if err := hashFile(h, "diff_file", resolveOutputPath(opts.WorkspaceRoot, opts.DiffFile)); err != nil {
+			return "", err
+		}
+	}
+	for _, root := range roots {
+		fmt.Fprintf(h, "root %s %s\n", root.Path, root.Name)
+		err := filepath.WalkDir(root.Path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if opts.skipsDir(root.Path, path, d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+				return nil
+			}
+			rel, _ := filepath.Rel(root.Path, path)
+			if !isManifest(d.Name()) && !(opts.ScanArchives && isArchive(path)) && opts.fileLanguage(rel, path) == "" {
+				return nil
+			}
+			return hashFile(h, filepath.ToSlash(rel), path)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isManifest reports whether name is a framework manifest or project
+// marker, which shape the metadata of other files' findings.
+func isManifest(name string) bool {
+	if slices.Contains(projectMarkers, name) {
+		return true
+	}
+	for _, names := range frameworkManifests {
+		if slices.Contains(names, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashFile writes name and the SHA-256 of the file at path to h.
+func hashFile(h io.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	fh := sha256.New()
+	if _, err := io.Copy(fh, f); err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "file %q %x\n", name, fh.Sum(nil))
+	return nil
+}
+
+func (c *resultCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get returns the findings of the unexpired entry for key, the rest of the
+// entry, and when it was written.
+func (c *resultCache) get(key string) ([]*pluginv1.Finding, cachedWalk, time.Time, bool) {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, cachedWalk{}, time.Time{}, false
+	}
+	if time.Since(info.ModTime()) > c.ttl {
+		_ = os.Remove(path)
+		return nil, cachedWalk{}, time.Time{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, cachedWalk{}, time.Time{}, false
+	}
+	var entry cachedWalk
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Printf("result_cache: ignoring %s: %v", path, err)
+		return nil, cachedWalk{}, time.Time{}, false
+	}
+	findings := make([]*pluginv1.Finding, 0, len(entry.Findings))
+	for _, raw := range entry.Findings {
+		f := new(pluginv1.Finding)
+		if err := protojson.Unmarshal(raw, f); err != nil {
+			log.Printf("result_cache: ignoring %s: %v", path, err)
+			return nil, cachedWalk{}, time.Time{}, false
+		}
+		findings = append(findings, f)
+	}
+	return findings, entry, info.ModTime(), true
+}
+
+// put stores a walk's findings and the rest of entry under key, then prunes
+// the cache.
+func (c *resultCache) put(key string, findings []*pluginv1.Finding, entry cachedWalk) error {
+	entry.Findings = make([]json.RawMessage, 0, len(findings))
+	for _, f := range findings {
+		raw, err := protojson.Marshal(f)
+		if err != nil {
+			return err
+		}
+		entry.Findings = append(entry.Findings, raw)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	// Written under a temporary name and renamed, so concurrent scans never
+	// read a partial entry.
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return c.prune()
+}
+
+// prune removes expired entries, then the oldest until the entries fit in
+// maxBytes.
+func (c *resultCache) prune() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	var entries []fs.FileInfo
+	var total int64
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) > c.ttl {
+			_ = os.Remove(filepath.Join(c.dir, d.Name()))
+			continue
+		}
+		entries = append(entries, info)
+		total += info.Size()
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime().Before(entries[j].ModTime()) })
+	var errs []error
+	for _, info := range entries {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		total -= info.Size()
+	}
+	return errors.Join(errs...)
+}
+
+// restampRunID sets the scan_run_id of findings read from the cache to the
+// current run's. scanned_at keeps the time of the scan that produced them.
+func restampRunID(findings []*pluginv1.Finding, runID string) {
+	for _, f := range findings {
+		if _, ok := f.GetMetadata()["scan_run_id"]; ok {
+			f.Metadata["scan_run_id"] = runID
+		}
+	}
+}
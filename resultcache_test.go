@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// cacheDiagnostic returns the result_cache diagnostic of resp, or "".
+func cacheDiagnostic(resp *pluginv1.InvokeToolResponse) string {
+	for _, d := range resp.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "result_cache:") {
+			return d.GetMessage()
+		}
+	}
+	return ""
+}
+
+func TestScanResultCache(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(resultCacheEnv, t.TempDir())
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	first := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+	if msg := cacheDiagnostic(first); msg != "" {
+		t.Errorf("expected the first scan to miss, got %q", msg)
+	}
+
+	second := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+	if msg := cacheDiagnostic(second); !strings.HasPrefix(msg, "result_cache: reused") {
+		t.Fatalf("expected an identical scan to hit the cache, got %q", msg)
+	}
+	if len(second.GetFindings()) != len(first.GetFindings()) || len(first.GetFindings()) == 0 {
+		t.Fatalf("expected the cached findings, got %d of %d", len(second.GetFindings()), len(first.GetFindings()))
+	}
+	for i, f := range second.GetFindings() {
+		want := first.GetFindings()[i]
+		if f.GetRuleId() != want.GetRuleId() || f.GetFingerprint() != want.GetFingerprint() {
+			t.Errorf("finding %d: got %s %s, want %s %s", i, f.GetRuleId(), f.GetFingerprint(), want.GetRuleId(), want.GetFingerprint())
+		}
+		if f.GetMetadata()["scan_run_id"] == want.GetMetadata()["scan_run_id"] {
+			t.Errorf("expected cached findings to carry the new run's ID")
+		}
+	}
+
+	// Changed content or inputs miss.
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\nresult = exec(code)\n")
+	if msg := cacheDiagnostic(invokeScanWithInput(t, client, map[string]any{"workspace_root": root})); msg != "" {
+		t.Errorf("expected changed content to miss, got %q", msg)
+	}
+	if msg := cacheDiagnostic(invokeScanWithInput(t, client, map[string]any{"workspace_root": root, "minimal": true})); msg != "" {
+		t.Errorf("expected changed inputs to miss, got %q", msg)
+	}
+}
+
+func TestScanResultCacheKeepsWalkDiagnostics(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(resultCacheEnv, t.TempDir())
+	writeFile(t, filepath.Join(root, "bundle.js"), "var a=1;"+strings.Repeat("eval(x);", 300)+"\neval(userInput)\n")
+
+	client := testClient(t)
+	input := map[string]any{"workspace_root": root}
+	first := invokeScanWithInput(t, client, input)
+	second := invokeScanWithInput(t, client, input)
+	if msg := cacheDiagnostic(second); !strings.HasPrefix(msg, "result_cache: reused the findings of an identical scan of 1 file(s)") {
+		t.Fatalf("expected a hit counting the scanned file, got %q", msg)
+	}
+	longLines := func(resp *pluginv1.InvokeToolResponse) []string {
+		var out []string
+		for _, d := range resp.GetDiagnostics() {
+			if strings.HasPrefix(d.GetMessage(), "long lines:") {
+				out = append(out, d.GetMessage())
+			}
+		}
+		return out
+	}
+	if got, want := longLines(second), longLines(first); len(want) != 1 || len(got) != 1 || got[0] != want[0] {
+		t.Errorf("expected the cached scan to report %v, got %v", want, got)
+	}
+}
+
+func TestResultCacheKeyIgnoresUnscannedFiles(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(resultCacheEnv, t.TempDir())
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "node_modules", "dep.js"), "eval(x)\n")
+	writeFile(t, filepath.Join(root, "sub", "lib.py"), "eval(x)\n")
+
+	client := testClient(t)
+	input := map[string]any{"workspace_root": root, "recursive": false}
+	invokeScanWithInput(t, client, input)
+
+	// Files the walk passes over do not affect the key.
+	writeFile(t, filepath.Join(root, "node_modules", "dep.js"), "exec(y)\n")
+	writeFile(t, filepath.Join(root, "sub", "lib.py"), "exec(y)\n")
+	if msg := cacheDiagnostic(invokeScanWithInput(t, client, input)); !strings.HasPrefix(msg, "result_cache: reused") {
+		t.Errorf("expected changes to unscanned files to hit, got %q", msg)
+	}
+
+	// A project marker shapes findings without being scanned.
+	writeFile(t, filepath.Join(root, "go.mod"), "module example\n")
+	if msg := cacheDiagnostic(invokeScanWithInput(t, client, input)); msg != "" {
+		t.Errorf("expected a new project marker to miss, got %q", msg)
+	}
+}
+
+func TestScanResultCacheDisabled(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(resultCacheEnv, "")
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	for range 2 {
+		if msg := cacheDiagnostic(invokeScanWithInput(t, client, map[string]any{"workspace_root": root})); msg != "" {
+			t.Errorf("expected no cache, got %q", msg)
+		}
+	}
+}
+
+func TestResultCacheExpiryAndSize(t *testing.T) {
+	c := &resultCache{dir: t.TempDir(), ttl: time.Hour, maxBytes: 1 << 20}
+	finding := &pluginv1.Finding{RuleId: "TRIAGE-001", Message: strings.Repeat("x", 400<<10)}
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.put(key, []*pluginv1.Finding{finding}, cachedWalk{}); err != nil {
+			t.Fatal(err)
+		}
+		// Entries are ordered by write time.
+		old := time.Now().Add(-time.Duration(3-len(key)) * time.Minute)
+		_ = os.Chtimes(c.path(key), old, old)
+	}
+	if _, _, _, ok := c.get("a"); ok {
+		t.Error("expected the oldest entry evicted past the size bound")
+	}
+	for _, key := range []string{"b", "c"} {
+		if findings, _, _, ok := c.get(key); !ok || len(findings) != 1 || findings[0].GetRuleId() != "TRIAGE-001" {
+			t.Errorf("expected entry %s kept, got %v", key, findings)
+		}
+	}
+
+	expired := time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(c.path("b"), expired, expired)
+	if _, _, _, ok := c.get("b"); ok {
+		t.Error("expected an expired entry to miss")
+	}
+	if _, err := os.Stat(c.path("b")); !os.IsNotExist(err) {
+		t.Errorf("expected the expired entry removed, got %v", err)
+	}
+}
+
+func TestResultCacheBypass(t *testing.T) {
+	for name, opts := range map[string]scanOptions{
+		"base_ref":         {BaseRef: "main"},
+		"source":           {Source: sourceStaged},
+		"paths_from_stdin": {StdinPaths: true},
+		"diff_base":        {DiffBase: "main"},
+		"flush_threshold":  {FlushAfter: 10},
+		"":                 {},
+	} {
+		if got := resultCacheBypass(&opts); got != name {
+			t.Errorf("got %q, want %q", got, name)
+		}
+	}
+}
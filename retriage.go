@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// handleRetriage runs AI triage over a previously produced finding set without
+// re-scanning the workspace. The findings input is either a JSON array string
+// or an array of finding objects, in the same shape scan returns them.
+func handleRetriage(ctx context.Context, req sdk.ToolRequest) (*pluginv1.InvokeToolResponse, error) {
+	if err := checkInputs("retriage", req.Input); err != nil {
+		return nil, err
+	}
+	findings, err := decodeFindings(req.Input["findings"])
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "decoding findings: %v", err)
+	}
+
+	resp := &pluginv1.InvokeToolResponse{Findings: findings}
+	if len(findings) == 0 {
+		return resp, nil
+	}
+
+	if err := checkWorkspaceLock(req.Input, req.WorkspaceRoot); err != nil {
+		return nil, err
+	}
+	cfg, err := loadRunConfig(req.WorkspaceRoot, inputString(req.Input, "config_file"))
+	if err != nil {
+		return nil, err
+	}
+	input := cfg.mergeInputs(req.Input)
+	filter, err := parseTriageFilter(input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	priorities, err := parsePriorityLevels(input)
+	if err != nil {
+		return nil, newToolError(ErrInvalidInput, "%v", err)
+	}
+	findings = filter.apply(findings)
+	if len(findings) == 0 {
+		return resp, nil
+	}
+
+	provider, model, err := resolveProvider(cfg.Settings)
+	if err != nil {
+		markTriageError(findings, err.Error())
+		return resp, nil
+	}
+	if m := inputString(req.Input, "model"); m != "" {
+		model = m
+	}
+	tc := newTriageConfig(cfg.Settings)
+	tc.ClassifyOnly = inputBool(input, "classify_only")
+	tc.Progress = newProgressReporter("")
+	tc.PriorityLevels = priorities
+	tc.SourceRoot = req.WorkspaceRoot
+	if file := cfg.Settings.get("NOX_AI_EXAMPLES_FILE"); file != "" {
+		if req.WorkspaceRoot != "" {
+			file = resolveOutputPath(req.WorkspaceRoot, file)
+		}
+		tc.Examples = loadTriageExamples(file)
+	}
+	if dir := cfg.Settings.get("NOX_AI_AUDIT_DIR"); dir != "" {
+		if req.WorkspaceRoot != "" {
+			dir = resolveOutputPath(req.WorkspaceRoot, dir)
+		}
+		tc.Audit = newAuditLog(dir, newRunID(), cfg.Settings)
+	}
+	stats := aiTriageFindings(ctx, provider, model, findings, tc)
+	addDiagnostic(resp, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO, stats.String())
+
+	return resp, nil
+}
+
+// decodeFindings converts a findings payload into protobuf findings. A nil
+// payload yields no findings.
+func decodeFindings(payload any) ([]*pluginv1.Finding, error) {
+	var raw []json.RawMessage
+	switch v := payload.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		if err := json.Unmarshal([]byte(v), &raw); err != nil {
+			return nil, fmt.Errorf("findings must be a JSON array: %w", err)
+		}
+	case []any:
+		for _, item := range v {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			raw = append(raw, data)
+		}
+	default:
+		return nil, fmt.Errorf("findings must be a JSON array, got %T", payload)
+	}
+
+	findings := make([]*pluginv1.Finding, 0, len(raw))
+	for i, data := range raw {
+		f := &pluginv1.Finding{}
+		if err := protojson.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("finding[%d]: %w", i, err)
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDecodeFindingsFromString(t *testing.T) {
+	payload := `[{"rule_id":"TRIAGE-001","severity":"SEVERITY_HIGH","location":{"file_path":"app.py","start_line":7},"metadata":{"priority":"immediate"}}]`
+	findings, err := decodeFindings(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	f := findings[0]
+	if f.GetRuleId() != "TRIAGE-001" || f.GetLocation().GetStartLine() != 7 {
+		t.Errorf("unexpected finding: %v", f)
+	}
+	if f.GetMetadata()["priority"] != "immediate" {
+		t.Errorf("expected priority metadata to round-trip, got %q", f.GetMetadata()["priority"])
+	}
+}
+
+func TestDecodeFindingsInvalid(t *testing.T) {
+	if _, err := decodeFindings("not json"); err == nil {
+		t.Error("expected error for invalid JSON string")
+	}
+	if _, err := decodeFindings(42.0); err == nil {
+		t.Error("expected error for non-array payload")
+	}
+}
+
+func TestRetriageNoProvider(t *testing.T) {
+	client := testClient(t)
+	t.Setenv("NOX_AI_API_KEY", "")
+	t.Setenv("NOX_AI_PROVIDER", "")
+
+	input, err := structpb.NewStruct(map[string]any{
+		"findings": []any{
+			map[string]any{
+				"ruleId":   "TRIAGE-002",
+				"severity": "SEVERITY_MEDIUM",
+				"location": map[string]any{"filePath": "api.py", "startLine": 12},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{
+		ToolName: "retriage",
+		Input:    input,
+	})
+	if err != nil {
+		t.Fatalf("InvokeTool(retriage): %v", err)
+	}
+	if len(resp.GetFindings()) != 1 {
+		t.Fatalf("expected the input finding back, got %d", len(resp.GetFindings()))
+	}
+	if resp.GetFindings()[0].GetMetadata()["ai_triage_error"] == "" {
+		t.Error("expected ai_triage_error metadata when no provider is configured")
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// scanRoot is one directory walked by a scan. When several roots are scanned
+// together, Name prefixes their finding paths to keep them distinct; it is
+// empty for a single root.
+type scanRoot struct {
+	Path string
+	Name string
+}
+
+// resolveScanRoots returns the directories a scan walks: workspace_roots if
+// given, otherwise workspace_root, otherwise the host workspace root. It
+// returns nil when none is set.
+func resolveScanRoots(input map[string]any, hostRoot string) []scanRoot {
+	paths := inputStrings(input, "workspace_roots")
+	if len(paths) == 0 {
+		root := inputString(input, "workspace_root")
+		if root == "" {
+			root = hostRoot
+		}
+		if root == "" {
+			return nil
+		}
+		return []scanRoot{{Path: root}}
+	}
+	if len(paths) == 1 {
+		return []scanRoot{{Path: paths[0]}}
+	}
+
+	roots := make([]scanRoot, 0, len(paths))
+	seen := make(map[string]int)
+	for _, p := range paths {
+		name := filepath.Base(filepath.Clean(p))
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s-%d", name, n)
+		}
+		roots = append(roots, scanRoot{Path: p, Name: name})
+	}
+	return roots
+}
+
+// lockWorkspaceEnv names the environment variable with which a host pins
+// scans to the workspace root it provides.
+const lockWorkspaceEnv = "NOX_TRIAGE_LOCK_WORKSPACE"
+
+// lockedPathInputs are the inputs naming files the plugin reads or writes,
+// which the workspace lock confines to the host workspace root.
+var lockedPathInputs = []string{"config_file", "diff_file", "baseline_file", "generate_baseline", "output_file"}
+
+// checkWorkspaceLock returns ErrWorkspaceLocked if NOX_TRIAGE_LOCK_WORKSPACE
+// is set and input names a root other than hostRoot, or a file outside it;
+// NOX_AI_EXAMPLES_FILE must also lie inside hostRoot. The lock is read from
+// the environment only, never the configuration file, which lives in the
+// workspace a caller may control.
+func checkWorkspaceLock(input map[string]any, hostRoot string) error {
+	if locked, _ := strconv.ParseBool(os.Getenv(lockWorkspaceEnv)); !locked {
+		return nil
+	}
+	requested := inputStrings(input, "workspace_roots")
+	if root := inputString(input, "workspace_root"); root != "" {
+		requested = append(requested, root)
+	}
+	for _, p := range requested {
+		if hostRoot == "" || filepath.Clean(p) != filepath.Clean(hostRoot) {
+			return newToolError(ErrWorkspaceLocked, "%s is set; scans may only read the host workspace root, not %s", lockWorkspaceEnv, p)
+		}
+	}
+	outside := func(p string) bool { return p != "" && (hostRoot == "" || !withinRoot(hostRoot, p)) }
+	for _, key := range lockedPathInputs {
+		if p := inputString(input, key); outside(p) {
+			return newToolError(ErrWorkspaceLocked, "%s is set; %s %s is outside the host workspace root", lockWorkspaceEnv, key, p)
+		}
+	}
+	if p := os.Getenv("NOX_AI_EXAMPLES_FILE"); outside(p) {
+		return newToolError(ErrWorkspaceLocked, "%s is set; NOX_AI_EXAMPLES_FILE %s is outside the host workspace root", lockWorkspaceEnv, p)
+	}
+	return nil
+}
+
+// displayPath returns path relative to the root, prefixed by the root name
+// when it has one.
+func (r scanRoot) displayPath(p string) string {
+	rel, err := filepath.Rel(r.Path, p)
+	if err != nil {
+		rel = p
+	}
+	return path.Join(r.Name, filepath.ToSlash(rel))
+}
+
+// skipsDir reports whether the walk of root passes over the directory at
+// path, named name: a skipped directory, any subdirectory when not
+// recursive, or one at max_depth.
+func (o *scanOptions) skipsDir(root, path, name string) bool {
+	if skippedDirs[name] {
+		return true
+	}
+	if path == root {
+		return false
+	}
+	return !o.Recursive || (o.MaxDepth >= 0 && pathDepth(root, path) >= o.MaxDepth)
+}
+
+// walkRoot scans every file below root whose language detectLanguage
+// recognizes, and with scan_archives the source inside jar, wheel, and egg
+// archives. Errors reading a file or directory are passed to skip, which
+// decides whether the walk continues.
+func walkRoot(ctx context.Context, resp *sdk.ResponseBuilder, root scanRoot, opts *scanOptions, skip func(display string, err error) error) error {
+	rootOpts := *opts
+	rootOpts.WorkspaceRoot = root.Path
+	rootOpts.RootName = root.Name
+	rootOpts.Frameworks = projectFrameworks(root.Path)
+	rootOpts.Projects = newProjectFinder(root.Path, root.Name)
+
+	return filepath.WalkDir(root.Path, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return skip(root.displayPath(path), err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if opts.skipsDir(root.Path, path, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if opts.ScanArchives && isArchive(path) {
+			if err := scanArchive(resp, path, &rootOpts, skip); err != nil {
+				return skip(root.displayPath(path), err)
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root.Path, path)
+		if err != nil {
+			rel = path
+		}
+		ext := opts.fileLanguage(rel, path)
+		if ext == "" {
+			return nil
+		}
+
+		if err := scanFile(resp, path, ext, &rootOpts); err != nil {
+			return skip(root.displayPath(path), err)
+		}
+		return nil
+	})
+}
+
+// loadScanDiff returns the added lines for the scan. diff_file is read
+// relative to the first root and its paths must match finding paths; with
+// diff_base, git runs in each root and paths get the root's name prefix.
+func loadScanDiff(ctx context.Context, roots []scanRoot, opts *scanOptions) (addedLines, error) {
+	if opts.DiffFile != "" || len(roots) == 1 {
+		return loadDiff(ctx, roots[0].Path, opts)
+	}
+	if opts.DiffBase == "" {
+		return nil, nil
+	}
+	merged := make(addedLines)
+	for _, root := range roots {
+		added, err := loadDiff(ctx, root.Path, opts)
+		if err != nil {
+			return nil, err
+		}
+		for p, lines := range added {
+			merged[path.Join(root.Name, p)] = lines
+		}
+	}
+	return merged, nil
+}
+
+// sortFindings orders findings by file path, line, and rule ID.
+func sortFindings(findings []*pluginv1.Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		fi, li := findingLocation(findings[i])
+		fj, lj := findingLocation(findings[j])
+		if fi != fj {
+			return fi < fj
+		}
+		if li != lj {
+			return li < lj
+		}
+		return findings[i].GetRuleId() < findings[j].GetRuleId()
+	})
+}
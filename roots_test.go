@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestResolveScanRoots(t *testing.T) {
+	roots := resolveScanRoots(map[string]any{
+		"workspace_roots": []any{"/src/app", "/vendor/app", "/src/lib"},
+	}, "/host")
+	want := []string{"app", "app-2", "lib"}
+	if len(roots) != len(want) {
+		t.Fatalf("got %d roots, want %d", len(roots), len(want))
+	}
+	for i, r := range roots {
+		if r.Name != want[i] {
+			t.Errorf("root %d name = %q, want %q", i, r.Name, want[i])
+		}
+	}
+
+	if roots := resolveScanRoots(map[string]any{}, "/host"); len(roots) != 1 || roots[0].Name != "" {
+		t.Errorf("expected the host root unnamed, got %+v", roots)
+	}
+	if roots := resolveScanRoots(map[string]any{}, ""); roots != nil {
+		t.Errorf("expected no roots, got %+v", roots)
+	}
+}
+
+func TestScanMultipleRoots(t *testing.T) {
+	base := t.TempDir()
+	svcA := filepath.Join(base, "svc-a")
+	svcB := filepath.Join(base, "svc-b")
+	writeFile(t, filepath.Join(svcA, "main.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(svcB, "main.py"), "eval(x)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_roots": []any{svcB, svcA},
+		"dedupe":          true,
+	})
+
+	found := findByRule(resp.GetFindings(), "TRIAGE-001")
+	if len(found) != 2 {
+		t.Fatalf("expected one TRIAGE-001 finding per root, got %d", len(found))
+	}
+	if got := found[0].GetLocation().GetFilePath(); got != "svc-a/main.py" {
+		t.Errorf("first finding path = %q, want svc-a/main.py", got)
+	}
+	if got := found[1].GetLocation().GetFilePath(); got != "svc-b/main.py" {
+		t.Errorf("second finding path = %q, want svc-b/main.py", got)
+	}
+	if found[0].GetFingerprint() == found[1].GetFingerprint() {
+		t.Error("expected distinct fingerprints for identical files in different roots")
+	}
+}
+
+func TestScanLockedWorkspace(t *testing.T) {
+	t.Setenv(lockWorkspaceEnv, "true")
+	host := t.TempDir()
+	other := t.TempDir()
+	writeFile(t, filepath.Join(host, "main.py"), "eval(x)\n")
+	writeFile(t, filepath.Join(other, "main.py"), "eval(x)\n")
+	client := testClient(t)
+
+	invoke := func(fields map[string]any) (*pluginv1.InvokeToolResponse, error) {
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input, WorkspaceRoot: host})
+	}
+
+	for _, fields := range []map[string]any{
+		{"workspace_root": other},
+		{"workspace_roots": []any{host, other}},
+	} {
+		if _, err := invoke(fields); status.Code(err) != codes.PermissionDenied || !strings.Contains(err.Error(), lockWorkspaceEnv) {
+			t.Errorf("%v: expected PermissionDenied naming %s, got %v", fields, lockWorkspaceEnv, err)
+		}
+	}
+	// Files named by input must lie inside the host root too.
+	writeFile(t, filepath.Join(other, "triage.yaml"), "dedupe: true\n")
+	for _, fields := range []map[string]any{
+		{"config_file": filepath.Join(other, "triage.yaml")},
+		{"config_file": "../" + filepath.Base(other) + "/triage.yaml"},
+		{"diff_file": filepath.Join(other, "changes.diff")},
+		{"baseline_file": "../baseline.json"},
+		{"generate_baseline": filepath.Join(other, "baseline.txt")},
+		{"output_file": "../findings.ndjson"},
+	} {
+		if _, err := invoke(fields); status.Code(err) != codes.PermissionDenied || !strings.Contains(err.Error(), "outside the host workspace root") {
+			t.Errorf("%v: expected PermissionDenied, got %v", fields, err)
+		}
+	}
+	t.Setenv("NOX_AI_EXAMPLES_FILE", filepath.Join(other, "examples.json"))
+	if _, err := invoke(map[string]any{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("NOX_AI_EXAMPLES_FILE outside the root: expected PermissionDenied, got %v", err)
+	}
+	t.Setenv("NOX_AI_EXAMPLES_FILE", "")
+
+	writeFile(t, filepath.Join(host, "triage.yaml"), "dedupe: true\n")
+	for _, fields := range []map[string]any{
+		{},
+		{"workspace_root": host + string(filepath.Separator)},
+		{"config_file": "triage.yaml", "output_file": filepath.Join(host, "findings.ndjson")},
+	} {
+		resp, err := invoke(fields)
+		if err != nil {
+			t.Fatalf("%v: %v", fields, err)
+		}
+		if len(findByRule(resp.GetFindings(), "TRIAGE-001")) != 1 {
+			t.Errorf("%v: expected the host root scanned", fields)
+		}
+	}
+}
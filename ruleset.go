@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validateRuleIDs reports rule IDs that appear more than once in set. Rule
+// IDs key AI triage adjustments, severity adjusters, and per-rule output, so
+// a duplicate would make those resolve to whichever rule happens to match
+// first.
+func validateRuleIDs(set []triageRule) error {
+	counts := make(map[string]int, len(set))
+	for _, rule := range set {
+		counts[rule.ID]++
+	}
+	var dups []string
+	for id, n := range counts {
+		if n > 1 {
+			dups = append(dups, fmt.Sprintf("%s (%d rules)", id, n))
+		}
+	}
+	if len(dups) == 0 {
+		return nil
+	}
+	sort.Strings(dups)
+	return fmt.Errorf("duplicate rule IDs: %s", strings.Join(dups, ", "))
+}
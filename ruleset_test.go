@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestBuiltinRuleIDsUnique(t *testing.T) {
+	if err := validateRuleIDs(rules); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateRuleIDsListsDuplicates(t *testing.T) {
+	set := []triageRule{{ID: "B"}, {ID: "A"}, {ID: "B"}, {ID: "C"}, {ID: "A"}, {ID: "A"}}
+	err := validateRuleIDs(set)
+	if err == nil {
+		t.Fatal("expected an error for duplicate IDs")
+	}
+	if want := "duplicate rule IDs: A (3 rules), B (2 rules)"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
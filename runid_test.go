@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewRunID(t *testing.T) {
+	a, b := newRunID(), newRunID()
+	if !uuidV4.MatchString(a) {
+		t.Errorf("%q is not a version 4 UUID", a)
+	}
+	if a == b {
+		t.Error("run IDs should differ")
+	}
+}
+
+func TestScanRunMetadata(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "b.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	first := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+	second := invokeScanWithInput(t, client, map[string]any{"workspace_root": root})
+
+	runID := first.GetFindings()[0].GetMetadata()["scan_run_id"]
+	if !uuidV4.MatchString(runID) {
+		t.Fatalf("expected a scan_run_id, got %q", runID)
+	}
+	for _, f := range first.GetFindings() {
+		md := f.GetMetadata()
+		if md["scan_run_id"] != runID {
+			t.Errorf("findings from one scan should share a run ID, got %q and %q", md["scan_run_id"], runID)
+		}
+		if _, err := time.Parse(time.RFC3339, md["scanned_at"]); err != nil {
+			t.Errorf("scanned_at: %v", err)
+		}
+		if md["plugin_version"] != version {
+			t.Errorf("plugin_version = %q, want %q", md["plugin_version"], version)
+		}
+	}
+	if second.GetFindings()[0].GetMetadata()["scan_run_id"] == runID {
+		t.Error("each scan should get a new run ID")
+	}
+
+	found := false
+	for _, d := range first.GetDiagnostics() {
+		if d.GetMessage() == "scan_run_id: "+runID {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the run ID in a diagnostic")
+	}
+}
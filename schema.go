@@ -0,0 +1,172 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// inputSpec declares one tool input for the manifest's input schema.
+type inputSpec struct {
+	Name string
+	// Types are JSON Schema type names; inputs that accept several shapes,
+	// such as a string or a list of strings, list each.
+	Types       []string
+	Default     any
+	Enum        []string
+	Description string
+}
+
+// scanInputs declares every input of the scan tool. Inputs read by the scan
+// must be listed here so hosts can discover them.
+var scanInputs = []inputSpec{
+	{Name: "workspace_root", Types: []string{"string"}, Description: "Directory to scan; defaults to the host workspace"},
+	{Name: "workspace_roots", Types: []string{"array"}, Description: "Several directories to scan as one combined result"},
+	{Name: "config_file", Types: []string{"string"}, Default: defaultConfigFile, Description: "Configuration file, relative to the workspace root, supplying defaults for these inputs"},
+	{Name: "ai_triage", Types: []string{"boolean"}, Default: false, Description: "Send findings to the configured LLM for severity adjustment"},
+	{Name: "estimate_cost", Types: []string{"boolean"}, Default: false, Description: "Report the estimated tokens and cost of AI triage instead of running it"},
+	{Name: "triage_min_severity", Types: []string{"string"}, Description: "Only send findings at or above this severity to the LLM"},
+	{Name: "triage_min_confidence", Types: []string{"string"}, Enum: []string{"high", "medium", "low"}, Description: "Only send findings at or above this confidence to the LLM"},
+	{Name: "triage_rules", Types: []string{"array", "string"}, Description: "Only send findings from these rule IDs to the LLM"},
+	{Name: "triage_changed_only", Types: []string{"boolean"}, Default: false, Description: "Reuse AI verdicts from baseline_file for unchanged findings"},
+	{Name: "classify_only", Types: []string{"boolean"}, Default: false, Description: "Record the AI classification without changing severity or priority"},
+	{Name: "priority_levels", Types: []string{"array"}, Description: "Priority names from most to least urgent, replacing immediate, scheduled, backlog, informational"},
+	{Name: "dedupe", Types: []string{"boolean"}, Default: false, Description: "Collapse findings on the same line into the most severe rule"},
+	{Name: "dedupe_copies", Types: []string{"boolean"}, Default: false, Description: "Collapse findings repeated across copies of a file"},
+	{Name: "max_depth", Types: []string{"integer"}, Default: -1, Description: "Maximum directory depth below the workspace root; negative is unlimited"},
+	{Name: "recursive", Types: []string{"boolean"}, Default: true, Description: "Walk into subdirectories of the workspace root; false scans only top-level files"},
+	{Name: "min_line_length", Types: []string{"integer"}, Default: 0, Description: "Skip rule matching on lines shorter than this after trimming whitespace; 0 disables it"},
+	{Name: "max_line_length", Types: []string{"integer", "object"}, Default: defaultMaxLineLength, Description: "Skip rule matching on longer lines, or an object of per-language limits"},
+	{Name: "line_budget_ms", Types: []string{"integer"}, Default: 0, Description: "Time budget for matching all rules against one line; 0 disables it"},
+	{Name: "encoding", Types: []string{"string"}, Default: "auto", Enum: []string{"auto", "utf-8", "utf-16le", "utf-16be"}, Description: "Encoding for files without a byte order mark"},
+	{Name: "language_map", Types: []string{"object"}, Description: "Path glob to language name for files with non-standard names"},
+	{Name: "force_language", Types: []string{"string"}, Description: "Scan every file with this language's patterns regardless of extension"},
+	{Name: "scan_archives", Types: []string{"boolean"}, Default: false, Description: "Also scan the source inside jar, wheel, and egg archives"},
+	{Name: "paths_from_stdin", Types: []string{"boolean"}, Default: false, Description: "Scan the files listed on standard input instead of walking the workspace"},
+	{Name: "skip_generated", Types: []string{"boolean"}, Default: false, Description: "Skip files marked as generated code"},
+	{Name: "strict", Types: []string{"boolean"}, Default: false, Description: "Fail the scan when a file or directory cannot be read"},
+	{Name: "diff_file", Types: []string{"string"}, Description: "Unified diff whose added lines are the only ones scanned"},
+	{Name: "diff_base", Types: []string{"string"}, Description: "Git revision to diff the working tree against"},
+	{Name: "source", Types: []string{"string"}, Default: sourceWorktree, Enum: []string{sourceWorktree, sourceStaged}, Description: "Files to scan: the working tree, or the staged content in the git index"},
+	{Name: "base_ref", Types: []string{"string"}, Description: "Git revision starting a commit range; head_ref is scanned and only findings not present at base_ref are reported, attributed to the commit that introduced them"},
+	{Name: "head_ref", Types: []string{"string"}, Description: "Git revision ending the commit range started by base_ref (default HEAD)"},
+	{Name: "baseline_file", Types: []string{"string"}, Description: "Baseline of earlier findings, relative to the workspace root"},
+	{Name: "generate_baseline", Types: []string{"string"}, Description: "Write the fingerprints of this scan's findings to this path as a text baseline"},
+	{Name: "fail_on_new", Types: []string{"string"}, Description: "Add an error diagnostic when new findings reach this severity"},
+	{Name: "drop_below", Types: []string{"string"}, Description: "Remove findings below this severity from the result after AI triage, e.g. low to drop info findings in CI"},
+	{Name: "severity_adjustments", Types: []string{"array"}, Description: "Deterministic severity and priority overrides by path and rule"},
+	{Name: "path_severity_rules", Types: []string{"array"}, Description: "Severity remapping by path glob"},
+	{Name: "minimal", Types: []string{"boolean"}, Default: false, Description: "Emit only rule ID, severity, confidence, and location"},
+	{Name: "page_size", Types: []string{"integer"}, Default: 0, Description: "Return findings in pages of this many, with a next_page_token diagnostic; 0 returns them all"},
+	{Name: "page_token", Types: []string{"string"}, Description: "Token from a next_page_token diagnostic; returns that page of a cached scan without scanning again"},
+	{Name: "compact", Types: []string{"boolean"}, Default: false, Description: "Omit heavy metadata from the response"},
+	{Name: "affected_files", Types: []string{"boolean"}, Default: false, Description: "Add a ranked diagnostic per file with findings"},
+	{Name: "sort_by", Types: []string{"string"}, Enum: []string{sortBySeverity, sortByPriority, sortByScore, sortByAIConf}, Description: "Order findings most urgent first by severity, priority, or a weighted score of both, or most confident first by AI triage confidence"},
+	{Name: "sort_weights", Types: []string{"object"}, Description: "Weights of severity and priority for sort_by score, e.g. {\"severity\": 1, \"priority\": 2}"},
+	{Name: "group_by", Types: []string{"string"}, Enum: []string{groupOutputByRule}, Description: "Add a diagnostic per rule listing its findings"},
+	{Name: "output_file", Types: []string{"string"}, Description: "Write every finding as NDJSON to this path"},
+	{Name: "output_gzip", Types: []string{"boolean"}, Default: false, Description: "Gzip output_file"},
+	{Name: "output_format", Types: []string{"string"}, Default: outputFormatNDJSON, Enum: []string{outputFormatNDJSON, outputFormatText, outputFormatCSV}, Description: "Format of output_file: NDJSON findings, a stable plain-text report, or CSV for spreadsheets"},
+	{Name: "flush_threshold", Types: []string{"integer"}, Default: 0, Description: "Stream findings to output_file during the scan once more than this many are found, keeping only this many in the response; 0 disables it"},
+	{Name: "webhook_url", Types: []string{"string"}, Description: "POST the findings as JSON to this http(s) URL after the scan"},
+	{Name: "webhook_auth", Types: []string{"string"}, Description: "Authorization header for webhook_url; defaults to NOX_WEBHOOK_AUTH"},
+	{Name: "webhook_retries", Types: []string{"integer"}, Default: defaultWebhookRetries, Description: "Retries for failed webhook deliveries"},
+	{Name: "strict_inputs", Types: []string{"boolean"}, Default: false, Description: "Reject inputs not declared in this schema"},
+	{Name: "cancel_grace_ms", Types: []string{"integer"}, Default: 0, Description: "Time the phases after the walk may run once the scan is cancelled"},
+	{Name: "priority_sla", Types: []string{"object"}, Description: "SLA per priority, e.g. {\"immediate\": \"1d\", \"scheduled\": \"7d\"}; findings get a due_date of the scan start plus their priority's SLA"},
+}
+
+// retriageInputs declares the inputs of the retriage tool.
+var retriageInputs = []inputSpec{
+	{Name: "findings", Types: []string{"string", "array"}, Description: "Findings to triage, as returned by scan"},
+	{Name: "model", Types: []string{"string"}, Description: "LLM model overriding NOX_AI_MODEL"},
+	{Name: "classify_only", Types: []string{"boolean"}, Default: false, Description: "Record the AI classification without changing severity or priority"},
+	{Name: "priority_levels", Types: []string{"array"}, Description: "Priority names from most to least urgent, replacing immediate, scheduled, backlog, informational"},
+	{Name: "config_file", Types: []string{"string"}, Default: defaultConfigFile, Description: "Configuration file supplying AI settings"},
+	{Name: "triage_min_severity", Types: []string{"string"}, Description: "Only send findings at or above this severity to the LLM"},
+	{Name: "triage_min_confidence", Types: []string{"string"}, Enum: []string{"high", "medium", "low"}, Description: "Only send findings at or above this confidence to the LLM"},
+	{Name: "triage_rules", Types: []string{"array", "string"}, Description: "Only send findings from these rule IDs to the LLM"},
+	{Name: "strict_inputs", Types: []string{"boolean"}, Default: false, Description: "Reject inputs not declared in this schema"},
+}
+
+// toolInputs maps tool names to their declared inputs.
+var toolInputs = map[string][]inputSpec{
+	"scan":     scanInputs,
+	"retriage": retriageInputs,
+	"demo":     demoInputs,
+}
+
+// inputSchema renders specs as a JSON Schema object.
+func inputSchema(specs []inputSpec) *structpb.Struct {
+	props := make(map[string]any, len(specs))
+	for _, spec := range specs {
+		prop := map[string]any{"description": spec.Description}
+		if len(spec.Types) == 1 {
+			prop["type"] = spec.Types[0]
+		} else {
+			types := make([]any, len(spec.Types))
+			for i, t := range spec.Types {
+				types[i] = t
+			}
+			prop["type"] = types
+		}
+		if spec.Default != nil {
+			prop["default"] = spec.Default
+		}
+		if len(spec.Enum) > 0 {
+			enum := make([]any, len(spec.Enum))
+			for i, e := range spec.Enum {
+				enum[i] = e
+			}
+			prop["enum"] = enum
+		}
+		props[spec.Name] = prop
+	}
+	schema, err := structpb.NewStruct(map[string]any{
+		"type":       "object",
+		"properties": props,
+	})
+	if err != nil {
+		// Every value above is a JSON type, so this is a programming error.
+		panic("input schema: " + err.Error())
+	}
+	return schema
+}
+
+// declareInputSchemas sets the input schema of every tool in the manifest
+// that declares its inputs.
+func declareInputSchemas(manifest *pluginv1.GetManifestResponse) {
+	for _, c := range manifest.GetCapabilities() {
+		for _, tool := range c.GetTools() {
+			if specs, ok := toolInputs[tool.GetName()]; ok {
+				tool.InputSchema = inputSchema(specs)
+			}
+		}
+	}
+}
+
+// checkInputs returns an error naming the keys of input that tool does not
+// declare when input sets strict_inputs. Without it unknown keys are ignored,
+// so a misspelled input silently falls back to its default.
+func checkInputs(tool string, input map[string]any) error {
+	if !inputBool(input, "strict_inputs") {
+		return nil
+	}
+	known := make(map[string]bool, len(toolInputs[tool]))
+	for _, spec := range toolInputs[tool] {
+		known[spec.Name] = true
+	}
+	var unknown []string
+	for key := range input {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return newToolError(ErrInvalidInput, "unknown %s input(s): %s", tool, strings.Join(unknown, ", "))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func TestManifestDeclaresInputSchemas(t *testing.T) {
+	client := testClient(t)
+	manifest, err := client.GetManifest(context.Background(), &pluginv1.GetManifestRequest{ApiVersion: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tools := make(map[string]*pluginv1.ToolDef)
+	for _, c := range manifest.GetCapabilities() {
+		for _, tool := range c.GetTools() {
+			tools[tool.GetName()] = tool
+		}
+	}
+	props := tools["scan"].GetInputSchema().GetFields()["properties"].GetStructValue().GetFields()
+	if len(props) != len(scanInputs) {
+		t.Fatalf("scan schema has %d properties, want %d", len(props), len(scanInputs))
+	}
+	encoding := props["encoding"].GetStructValue().GetFields()
+	if encoding["type"].GetStringValue() != "string" || encoding["default"].GetStringValue() != "auto" {
+		t.Errorf("unexpected encoding schema %v", encoding)
+	}
+	if n := len(encoding["enum"].GetListValue().GetValues()); n != 4 {
+		t.Errorf("expected 4 encodings in the enum, got %d", n)
+	}
+	if types := props["max_line_length"].GetStructValue().GetFields()["type"].GetListValue().GetValues(); len(types) != 2 {
+		t.Errorf("max_line_length should accept two types, got %v", types)
+	}
+	if tools["retriage"].GetInputSchema() == nil {
+		t.Error("retriage should declare its inputs")
+	}
+	if tools["selftest"].GetInputSchema() != nil {
+		t.Error("selftest takes no inputs")
+	}
+}
+
+// TestScanInputsMatchREADME keeps the declared scan inputs and the README's
+// Inputs table in step.
+func TestScanInputsMatchREADME(t *testing.T) {
+	data, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, table, _ := strings.Cut(string(data), "### Inputs\n")
+	table, _, _ = strings.Cut(table, "\n\n#")
+
+	documented := make(map[string]bool)
+	for _, line := range strings.Split(table, "\n") {
+		if cells := strings.Split(line, "|"); len(cells) > 2 && strings.HasPrefix(strings.TrimSpace(cells[1]), "`") {
+			documented[strings.Trim(strings.TrimSpace(cells[1]), "`")] = true
+		}
+	}
+	declared := make(map[string]bool)
+	for _, spec := range scanInputs {
+		declared[spec.Name] = true
+		if !documented[spec.Name] {
+			t.Errorf("input %s is declared but not in the README", spec.Name)
+		}
+	}
+	for name := range documented {
+		if !declared[name] {
+			t.Errorf("input %s is in the README but not declared", name)
+		}
+	}
+}
+
+func TestCheckInputs(t *testing.T) {
+	input := map[string]any{"workspace_rot": "/src", "ai_triage": true, "dedup": true}
+	if err := checkInputs("scan", input); err != nil {
+		t.Errorf("unknown inputs should be ignored without strict_inputs: %v", err)
+	}
+
+	input["strict_inputs"] = true
+	err := checkInputs("scan", input)
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "unknown scan input(s): dedup, workspace_rot") {
+		t.Errorf("error should name the unknown keys, got %q", err)
+	}
+
+	if err := checkInputs("retriage", map[string]any{"strict_inputs": true, "findings": "[]", "model": "m"}); err != nil {
+		t.Errorf("declared retriage inputs should pass: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// configExtensions are the configuration formats scanned alongside source
+// code, where hardcoded secrets most often leak. Only rules with a pattern
+// for the format apply to them; anyExtension patterns are written for source
+// code and are not used.
+var configExtensions = map[string]bool{
+	".env":        true,
+	".yaml":       true,
+	".yml":        true,
+	".json":       true,
+	".toml":       true,
+	".properties": true,
+}
+
+// envTemplateSuffixes mark dotenv files that document the variables rather
+// than hold them, such as .env.example.
+var envTemplateSuffixes = []string{".example", ".sample", ".template", ".dist", ".defaults"}
+
+// envFileExt returns ".env" for dotenv files with a suffix, such as
+// .env.local or .env.production, whose extension is not .env, and "" for
+// other names, including templates.
+func envFileExt(name string) string {
+	base := path.Base(name)
+	if !strings.HasPrefix(base, ".env.") {
+		return ""
+	}
+	for _, suffix := range envTemplateSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return ""
+		}
+	}
+	return ".env"
+}
+
+// configPatterns returns a Patterns or Excludes map that applies re to every
+// configuration format.
+func configPatterns(re *regexp.Regexp) map[string]*regexp.Regexp {
+	m := make(map[string]*regexp.Regexp, len(configExtensions))
+	for ext := range configExtensions {
+		m[ext] = re
+	}
+	return m
+}
+
+// secretGroup names the pattern group whose text is masked in finding
+// messages, so a rule can point at a credential without repeating it.
+const secretGroup = "secret"
+
+// redactedValue replaces the secretGroup text in finding messages.
+const redactedValue = "****"
+
+// redactLine returns line with the text of re's secretGroup, if re has one
+// and it matched, replaced by redactedValue.
+func redactLine(re *regexp.Regexp, line string) string {
+	i := re.SubexpIndex(secretGroup)
+	if i < 0 {
+		return line
+	}
+	loc := re.FindStringSubmatchIndex(line)
+	if loc == nil || loc[2*i] < 0 {
+		return line
+	}
+	return line[:loc[2*i]] + redactedValue + line[loc[2*i+1]:]
+}
+
+// secretDetectors find credentials in any line of source, whichever rule
+// matched it, so the text of every finding and of the AI prompt can be
+// masked before it leaves the process. Each masks its secretGroup.
+var secretDetectors = []*regexp.Regexp{
+	// A string literal assigned to a credential-named variable, key, or
+	// argument.
+	regexp.MustCompile(`(?i)(passw(or)?d|pwd|secret|token|api[_.-]?key|access[_.-]?key|private[_.-]?key|auth[_.-]?key|credentials?)[\w.-]*["']?\s*(:=|[:=]|=>)\s*[bru]?["'](?P<` + secretGroup + `>[^\s"']{8,})["']`),
+	// Well-known token formats: AWS access key IDs, GitHub, Slack, and
+	// OpenAI-style API keys, and JWTs.
+	regexp.MustCompile(`(?P<` + secretGroup + `>\b(AKIA|ASIA)[0-9A-Z]{16}\b|\bgh[pousr]_[A-Za-z0-9]{36,}|\bgithub_pat_\w{22,}|\bxox[abposr]-[A-Za-z0-9-]{10,}|\bsk-[A-Za-z0-9_-]{20,}|\beyJ[\w-]{10,}\.eyJ[\w-]{10,}\.[\w-]{10,})`),
+	// The password in a URL's userinfo.
+	regexp.MustCompile(`://[^/\s:@]+:(?P<` + secretGroup + `>[^/\s@]+)@`),
+	// A bearer token in an Authorization value.
+	regexp.MustCompile(`(?i)\bbearer\s+(?P<` + secretGroup + `>[\w.~+/-]{16,}=*)`),
+}
+
+// highEntropyLiteral matches quoted strings that may be random keys; those
+// whose entropy reaches minSecretEntropy are masked.
+var highEntropyLiteral = regexp.MustCompile(`["'` + "`" + `]([A-Za-z0-9+/=_-]{20,})["'` + "`" + `]`)
+
+// minSecretEntropy is the Shannon entropy, in bits per character, from which
+// a quoted string of letters and digits is taken for a key. Identifiers and
+// words stay below it; 20 random base64 characters are near 4.
+const minSecretEntropy = 3.5
+
+// maskSecrets returns text with every credential the secret detectors find
+// replaced by redactedValue.
+func maskSecrets(text string) string {
+	for _, re := range secretDetectors {
+		i := re.SubexpIndex(secretGroup)
+		text = re.ReplaceAllStringFunc(text, func(m string) string {
+			loc := re.FindStringSubmatchIndex(m)
+			if loc == nil || loc[2*i] < 0 {
+				return m
+			}
+			return m[:loc[2*i]] + redactedValue + m[loc[2*i+1]:]
+		})
+	}
+	return highEntropyLiteral.ReplaceAllStringFunc(text, func(m string) string {
+		literal := m[1 : len(m)-1]
+		if !strings.ContainsAny(literal, "0123456789") || shannonEntropy(literal) < minSecretEntropy {
+			return m
+		}
+		return m[:1] + redactedValue + m[len(m)-1:]
+	})
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// selftestSnippets holds one known-vulnerable snippet per rule and language,
+// named <rule ID><extension>.txt so the Go toolchain does not compile them.
+//
+//go:embed selftest
+var selftestSnippets embed.FS
+
+// selftestResult is the outcome of checking one rule against one language.
+type selftestResult struct {
+	RuleID string
+	Ext    string
+	Err    error
+}
+
+// String renders the result as reported in selftest diagnostics.
+func (r selftestResult) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("fail: %s %s: %v", r.RuleID, r.Ext, r.Err)
+	}
+	return fmt.Sprintf("pass: %s %s", r.RuleID, r.Ext)
+}
+
+// runSelftest scans each embedded snippet and checks that its rule fires.
+// Every language a rule has a pattern for, including through anyExtension,
+// must have a snippet.
+func runSelftest() []selftestResult {
+	var results []selftestResult
+	for i := range rules {
+		rule := &rules[i]
+		for _, ext := range rule.extensions() {
+			results = append(results, selftestResult{
+				RuleID: rule.ID,
+				Ext:    ext,
+				Err:    checkSnippet(rule.ID, ext),
+			})
+		}
+	}
+	return results
+}
+
+// checkSnippet scans the snippet for ruleID in the given language and
+// returns an error unless the rule fires.
+func checkSnippet(ruleID, ext string) error {
+	name := ruleID + ext + ".txt"
+	data, err := selftestSnippets.ReadFile("selftest/" + name)
+	if err != nil {
+		return fmt.Errorf("no snippet")
+	}
+
+	resp := sdk.NewResponse()
+	if err := scanSource(resp, bytes.NewReader(data), name, name, ext, &scanOptions{MaxDepth: -1}); err != nil {
+		return err
+	}
+	for _, f := range resp.Build().GetFindings() {
+		if f.GetRuleId() == ruleID {
+			return nil
+		}
+	}
+	return fmt.Errorf("rule did not fire")
+}
+
+// handleSelftest runs the rule set against the embedded snippets and reports
+// pass or fail per rule and language as diagnostics, with failures as errors.
+func handleSelftest(_ context.Context, _ sdk.ToolRequest) (*pluginv1.InvokeToolResponse, error) {
+	resp := &pluginv1.InvokeToolResponse{}
+	failed := 0
+	results := runSelftest()
+	for _, r := range results {
+		severity := pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO
+		if r.Err != nil {
+			severity = pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_ERROR
+			failed++
+		}
+		addDiagnostic(resp, severity, r.String())
+	}
+	addDiagnostic(resp, pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_INFO,
+		fmt.Sprintf("selftest: %d passed, %d failed", len(results)-failed, failed))
+	return resp, nil
+}
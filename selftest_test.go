@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func TestSelftestAllRulesPass(t *testing.T) {
+	results := runSelftest()
+	if len(results) == 0 {
+		t.Fatal("expected selftest results")
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Error(r.String())
+		}
+	}
+}
+
+func TestSelftestDetectsMissingSnippet(t *testing.T) {
+	if err := checkSnippet("TRIAGE-999", ".go"); err == nil {
+		t.Error("expected a missing snippet to fail")
+	}
+}
+
+func TestSelftestTool(t *testing.T) {
+	client := testClient(t)
+	resp, err := client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "selftest"})
+	if err != nil {
+		t.Fatalf("InvokeTool(selftest): %v", err)
+	}
+	diags := resp.GetDiagnostics()
+	if len(diags) == 0 {
+		t.Fatal("expected selftest diagnostics")
+	}
+	summary := diags[len(diags)-1].GetMessage()
+	if !strings.HasSuffix(summary, " 0 failed") {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// customSeverities maps severity labels outside the five standard levels onto
+// the nearest standard severity. The finding's Severity field carries the
+// mapped value and the label itself is kept in custom_severity metadata.
+var customSeverities = map[string]pluginv1.Severity{
+	"blocker": sdk.SeverityCritical,
+	"trivial": sdk.SeverityInfo,
+}
+
+// isCustomSeverity reports whether label is one of the custom severity labels.
+func isCustomSeverity(label string) bool {
+	_, ok := customSeverities[strings.ToLower(label)]
+	return ok
+}
+
+// severityLabel returns the custom severity label of a finding if it has one,
+// otherwise the name of its standard severity.
+func severityLabel(f *pluginv1.Finding) string {
+	if label := f.GetMetadata()["custom_severity"]; label != "" {
+		return label
+	}
+	return f.GetSeverity().String()
+}
+
+// setCustomSeverity records label as the finding's custom severity, or clears
+// any previous custom label when label is a standard severity.
+func setCustomSeverity(f *pluginv1.Finding, label string) {
+	if f.Metadata == nil {
+		f.Metadata = make(map[string]string)
+	}
+	if isCustomSeverity(label) {
+		f.Metadata["custom_severity"] = strings.ToLower(label)
+		return
+	}
+	delete(f.Metadata, "custom_severity")
+}
+
+// dropBelow removes the findings less severe than min, keeping the order of
+// the rest, and returns them with the number removed. Findings with no
+// severity are kept.
+func dropBelow(findings []*pluginv1.Finding, min pluginv1.Severity) ([]*pluginv1.Finding, int) {
+	kept := findings[:0]
+	for _, f := range findings {
+		if sev := f.GetSeverity(); sev != pluginv1.Severity_SEVERITY_UNSPECIFIED && severityMoreSevere(min, sev) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, len(findings) - len(kept)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDropBelow(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{RuleId: "info", Severity: pluginv1.Severity_SEVERITY_INFO},
+		{RuleId: "high", Severity: pluginv1.Severity_SEVERITY_HIGH},
+		{RuleId: "low", Severity: pluginv1.Severity_SEVERITY_LOW},
+		{RuleId: "unset"},
+		{RuleId: "medium", Severity: pluginv1.Severity_SEVERITY_MEDIUM},
+	}
+
+	kept, dropped := dropBelow(findings, pluginv1.Severity_SEVERITY_MEDIUM)
+	if want := []string{"high", "unset", "medium"}; !slices.Equal(ruleOrder(kept), want) || dropped != 2 {
+		t.Errorf("got %v (%d dropped), want %v", ruleOrder(kept), dropped, want)
+	}
+}
+
+func TestScanDropBelow(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "auth", "tokens.py"), "token = jwt.encode(payload, key)\n")
+	writeFile(t, filepath.Join(root, "app.py"), "token = jwt.encode(payload, key)\nresult = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"drop_below":     "low",
+		// Stands in for AI triage raising a finding before the cut.
+		"path_severity_rules": []any{
+			map[string]any{"path": "auth/**", "severity": "+1"},
+		},
+	})
+
+	var kept []string
+	for _, f := range resp.GetFindings() {
+		rel, _ := filepath.Rel(root, f.GetLocation().GetFilePath())
+		kept = append(kept, filepath.ToSlash(rel)+":"+f.GetRuleId())
+		if f.GetSeverity() == pluginv1.Severity_SEVERITY_INFO {
+			t.Errorf("expected info findings dropped, got %s", kept[len(kept)-1])
+		}
+	}
+	if !slices.Contains(kept, "auth/tokens.py:TRIAGE-004") || !slices.Contains(kept, "app.py:TRIAGE-001") {
+		t.Errorf("expected the raised and the high findings kept, got %v", kept)
+	}
+	found := false
+	for _, d := range resp.GetDiagnostics() {
+		found = found || d.GetMessage() == "drop_below: dropped 1 finding(s) below low"
+	}
+	if !found {
+		t.Errorf("expected a drop_below diagnostic, got %v", resp.GetDiagnostics())
+	}
+}
+
+func TestScanDropBelowInvalid(t *testing.T) {
+	client := testClient(t)
+	input, err := structpb.NewStruct(map[string]any{"workspace_root": t.TempDir(), "drop_below": "noise"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "drop_below") {
+		t.Errorf("expected an invalid argument error naming drop_below, got %v", err)
+	}
+}
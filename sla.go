@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// prioritySLA maps a priority level to the time a finding of that priority
+// has to be fixed in, from the priority_sla input. Priorities without an
+// entry get no due date.
+type prioritySLA map[string]time.Duration
+
+// parsePrioritySLA reads the priority_sla input, an object from priority
+// names to durations such as "24h" or "7d". Keys must be priority levels,
+// matched case-insensitively, and durations positive. An absent input
+// returns nil.
+func parsePrioritySLA(input map[string]any, levels priorityLevels) (prioritySLA, error) {
+	v, ok := input["priority_sla"]
+	if !ok {
+		return nil, nil
+	}
+	raw, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("priority_sla must be an object mapping priorities to durations")
+	}
+	sla := make(prioritySLA, len(raw))
+	for key, v := range raw {
+		if !levels.valid(key) {
+			return nil, fmt.Errorf("priority_sla: unknown priority %q (priority_levels: %s)", key, levels)
+		}
+		s, _ := v.(string)
+		d, err := parseSLADuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("priority_sla.%s: %v", key, err)
+		}
+		sla[levels.canonical(key)] = d
+	}
+	return sla, nil
+}
+
+// parseSLADuration parses a Go duration, or a whole number of days with a
+// "d" suffix, since SLAs are usually stated in days.
+func parseSLADuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid duration %q (use e.g. 24h or 7d)", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
+
+// stampDueDates sets the due_date metadata of each finding whose priority
+// has an SLA to start plus the SLA, as RFC 3339 in UTC. start is the time
+// the scan run began, so every finding of a run is measured from the same
+// instant whichever file it came from.
+func stampDueDates(findings []*pluginv1.Finding, sla prioritySLA, levels priorityLevels, start time.Time) {
+	for _, f := range findings {
+		d, ok := sla[levels.canonical(f.GetMetadata()["priority"])]
+		if !ok {
+			continue
+		}
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata["due_date"] = start.Add(d).UTC().Format(time.RFC3339)
+	}
+}
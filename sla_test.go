@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParsePrioritySLA(t *testing.T) {
+	sla, err := parsePrioritySLA(map[string]any{
+		"priority_sla": map[string]any{"Immediate": "1d", "scheduled": "36h"},
+	}, defaultPriorityLevels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sla["immediate"] != 24*time.Hour || sla["scheduled"] != 36*time.Hour || len(sla) != 2 {
+		t.Errorf("got %v", sla)
+	}
+
+	if sla, err := parsePrioritySLA(map[string]any{}, defaultPriorityLevels); sla != nil || err != nil {
+		t.Errorf("absent input: got %v, %v", sla, err)
+	}
+
+	for _, raw := range []any{
+		"1d",
+		map[string]any{"urgent": "1d"},
+		map[string]any{"immediate": "soon"},
+		map[string]any{"immediate": "0d"},
+		map[string]any{"immediate": "-2h"},
+		map[string]any{"immediate": 1.0},
+	} {
+		if _, err := parsePrioritySLA(map[string]any{"priority_sla": raw}, defaultPriorityLevels); err == nil {
+			t.Errorf("%v: expected an error", raw)
+		}
+	}
+}
+
+func TestStampDueDates(t *testing.T) {
+	findings := orderingFixture()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stampDueDates(findings, prioritySLA{"immediate": 24 * time.Hour, "scheduled": 7 * 24 * time.Hour}, nil, start)
+
+	want := map[string]string{
+		"low-scheduled":      "2026-03-08T12:00:00Z",
+		"high-backlog":       "",
+		"high-immediate":     "2026-03-02T12:00:00Z",
+		"info-informational": "",
+		"medium-immediate":   "2026-03-02T12:00:00Z",
+	}
+	for _, f := range findings {
+		if got := f.GetMetadata()["due_date"]; got != want[f.GetRuleId()] {
+			t.Errorf("%s: due_date %q, want %q", f.GetRuleId(), got, want[f.GetRuleId()])
+		}
+	}
+}
+
+func TestScanPrioritySLA(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "b.py"), "x = eval(other_input)\n")
+
+	before := time.Now().UTC().Truncate(time.Second)
+	resp := invokeScanWithInput(t, testClient(t), map[string]any{
+		"workspace_root": root,
+		"priority_sla":   map[string]any{"immediate": "2d"},
+	})
+	after := time.Now().UTC()
+
+	var due []string
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-001") {
+		if f.GetMetadata()["priority"] != "immediate" {
+			continue
+		}
+		due = append(due, f.GetMetadata()["due_date"])
+	}
+	if len(due) < 2 {
+		t.Fatalf("expected immediate findings in both files, got %d", len(due))
+	}
+	for _, d := range due {
+		if d != due[0] {
+			t.Errorf("due dates differ within a run: %v", due)
+		}
+	}
+	got, err := time.Parse(time.RFC3339, due[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Before(before.Add(48*time.Hour)) || got.After(after.Add(48*time.Hour)) {
+		t.Errorf("due_date %s not two days after the scan", got)
+	}
+}
+
+func TestScanPrioritySLAInvalid(t *testing.T) {
+	input, err := structpb.NewStruct(map[string]any{
+		"workspace_root": t.TempDir(),
+		"priority_sla":   map[string]any{"p0": "1d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testClient(t).InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+	if got := status.Code(err); got != codes.InvalidArgument || !strings.Contains(err.Error(), "priority_sla") {
+		t.Errorf("expected an invalid argument error, got %v", err)
+	}
+}
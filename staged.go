@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/sdk"
+)
+
+// Values of the source input: the files a scan reads.
+const (
+	sourceWorktree = "worktree"
+	sourceStaged   = "staged"
+)
+
+// stagedPaths lists the files with staged changes in the git repository
+// holding root, relative to root: added, copied, modified, and renamed
+// files, since a staged deletion leaves nothing to scan. ok is false when
+// root is not inside a git work tree.
+func stagedPaths(ctx context.Context, root string) (paths []string, ok bool, err error) {
+	if err := exec.CommandContext(ctx, "git", "-C", root, "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return nil, false, nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "diff", "--cached", "--name-only", "--relative", "--diff-filter=ACMR", "-z")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, true, fmt.Errorf("git diff --cached: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, filepath.FromSlash(p))
+		}
+	}
+	return paths, true, nil
+}
+
+// scanStaged scans the index version of each listed file, as read with
+// git show, so partially staged files are scanned as they will be
+// committed. Findings are reported at the file's working tree path. Files
+// in an unsupported language are skipped; errors go to skip as in walkRoot.
+func scanStaged(ctx context.Context, resp *sdk.ResponseBuilder, root scanRoot, paths []string, opts *scanOptions, skip func(display string, err error) error) error {
+	rootOpts := *opts
+	rootOpts.WorkspaceRoot = root.Path
+	rootOpts.Frameworks = projectFrameworks(root.Path)
+	rootOpts.Projects = newProjectFinder(root.Path, root.Name)
+
+	for _, rel := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		abs := filepath.Join(root.Path, rel)
+		ext := opts.fileLanguage(rel, abs)
+		if ext == "" {
+			continue
+		}
+		if err := scanStagedFile(ctx, resp, root.Path, rel, ext, &rootOpts); err != nil {
+			if err := skip(root.displayPath(abs), err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanStagedFile scans the index version of rel, a path relative to root.
+func scanStagedFile(ctx context.Context, resp *sdk.ResponseBuilder, root, rel, ext string, opts *scanOptions) error {
+	// ":./path" names the index entry relative to the -C directory.
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "show", ":./"+filepath.ToSlash(rel))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git show: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	src, err := decodeSource(bytes.NewReader(data), opts.Encoding)
+	if err != nil {
+		return err
+	}
+	return scanSource(resp, src, filepath.Join(root, rel), rel, ext, opts)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestScanStaged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Dev", "-c", "user.email=dev@example.com", "-C", root}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	writeFile(t, filepath.Join(root, "committed.py"), "eval(old)\n")
+	writeFile(t, filepath.Join(root, "app.py"), "x = 1\n")
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "Initial import")
+
+	// app.py is staged with an eval on line 2 that is then removed from
+	// the working tree; new.py is staged as added; untracked.py is not
+	// staged at all.
+	writeFile(t, filepath.Join(root, "app.py"), "x = 1\neval(staged)\n")
+	writeFile(t, filepath.Join(root, "new.py"), "eval(added)\n")
+	git("add", "app.py", "new.py")
+	writeFile(t, filepath.Join(root, "app.py"), "x = 1\n")
+	writeFile(t, filepath.Join(root, "untracked.py"), "eval(untracked)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"source":         "staged",
+	})
+
+	var got []string
+	for _, f := range findByRule(resp.GetFindings(), "TRIAGE-001") {
+		rel, err := filepath.Rel(root, f.GetLocation().GetFilePath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s:%d", filepath.ToSlash(rel), f.GetLocation().GetStartLine()))
+	}
+	slices.Sort(got)
+	if want := []string{"app.py:2", "new.py:1"}; !slices.Equal(got, want) {
+		t.Errorf("expected the staged versions only, got %v, want %v", got, want)
+	}
+}
+
+func TestScanStagedOutsideGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(root))
+	writeFile(t, filepath.Join(root, "app.py"), "eval(x)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root": root,
+		"source":         "staged",
+	})
+
+	if got := len(findByRule(resp.GetFindings(), "TRIAGE-001")); got != 1 {
+		t.Errorf("expected the working tree scanned, got %d TRIAGE-001 findings", got)
+	}
+	var warned bool
+	for _, d := range resp.GetDiagnostics() {
+		warned = warned || d.GetSeverity() == pluginv1.DiagnosticSeverity_DIAGNOSTIC_SEVERITY_WARNING &&
+			strings.HasPrefix(d.GetMessage(), "source staged: ")
+	}
+	if !warned {
+		t.Errorf("expected a fallback warning, got %v", resp.GetDiagnostics())
+	}
+}
+
+func TestScanSourceInvalid(t *testing.T) {
+	client := testClient(t)
+	for _, extra := range []map[string]any{
+		{"source": "head"},
+		{"source": "staged", "diff_base": "HEAD"},
+		{"source": "staged", "base_ref": "HEAD~1"},
+	} {
+		fields := map[string]any{"workspace_root": t.TempDir()}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		input, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.InvokeTool(context.Background(), &pluginv1.InvokeToolRequest{ToolName: "scan", Input: input})
+		if got := status.Code(err); got != codes.InvalidArgument || !strings.Contains(err.Error(), "source") {
+			t.Errorf("%v: expected an invalid argument error, got %v", extra, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+// streamingProvider is implemented by providers that can deliver a completion
+// incrementally. onDelta receives each chunk of message content as it
+// arrives; the returned response holds the complete message.
+type streamingProvider interface {
+	plannerllm.Provider
+	CompleteStream(ctx context.Context, req plannerllm.CompletionRequest, onDelta func(string)) (plannerllm.CompletionResponse, error)
+}
+
+// streamBatch triages a batch over a streaming completion, applying each
+// adjustment as soon as its JSON array element is complete. If the stream
+// fails part-way, adjustments already applied are kept and only the rest of
+// the batch is marked with ai_triage_error. Since an adjustment is applied
+// before later ones arrive, the first suggestion for a finding wins; later
+// differing ones are only recorded in ai_triage_conflict. Like triageBatch,
+// it returns the number of adjustments that matched no finding. File tokens
+// are translated back through aliases as each adjustment arrives.
+func streamBatch(ctx context.Context, provider streamingProvider, req plannerllm.CompletionRequest, findings []*pluginv1.Finding, cfg *triageConfig, aliases *pathAliases) int {
+	adjusted := make(map[*pluginv1.Finding]bool)
+	applied := make(map[adjustmentKey][]triageAdjustment)
+	unmatched := 0
+	var content strings.Builder
+	stream := &adjustmentStream{apply: func(adj triageAdjustment) {
+		adj = aliases.restoreOne(adj)
+		prev, ok := applied[adj.key()]
+		if !ok {
+			applied[adj.key()] = []triageAdjustment{adj}
+			matched := applyAdjustments(findings, []triageAdjustment{adj}, cfg)
+			for _, f := range matched {
+				adjusted[f] = true
+			}
+			if len(matched) == 0 {
+				unmatched++
+			}
+			return
+		}
+		if slices.ContainsFunc(prev, adj.sameAs) {
+			return
+		}
+		applied[adj.key()] = append(prev, adj)
+		markConflict(findings, adj.key(), applied[adj.key()])
+	}}
+
+	resp, err := provider.CompleteStream(ctx, req, func(delta string) {
+		content.WriteString(delta)
+		stream.write(delta)
+	})
+	full := resp.Message.Content
+	if full == "" {
+		full = content.String()
+	}
+	cfg.Audit.record(provider.Name(), req, full, err)
+	if err != nil {
+		recordSpanError(ctx, err)
+		log.Printf("ai_triage: streaming LLM call failed after %d adjustment(s): %v", stream.count, err)
+		markTriageError(untriaged(findings, adjusted), fmt.Sprintf("LLM call failed: %v", err))
+		return unmatched
+	}
+	if stream.count > 0 {
+		if stream.err != nil {
+			markTriageError(untriaged(findings, adjusted), fmt.Sprintf("failed to parse LLM response: %v", stream.err))
+		}
+		return unmatched
+	}
+
+	// Nothing parsed incrementally: fall back to parsing the whole message,
+	// which also handles responses the stream parser could not follow.
+	adjustments, err := parseTriageResponse(full)
+	if err != nil {
+		recordSpanError(ctx, err)
+		log.Printf("ai_triage: failed to parse LLM response: %v", err)
+		markTriageError(findings, fmt.Sprintf("failed to parse LLM response: %v", err))
+		return 0
+	}
+	aliases.restore(adjustments)
+	applyAdjustments(findings, adjustments, cfg)
+	return unmatchedAdjustments(findings, adjustments)
+}
+
+// markConflict records the differing suggestions for the finding at key in
+// ai_triage_conflict.
+func markConflict(findings []*pluginv1.Finding, key adjustmentKey, suggestions []triageAdjustment) {
+	parts := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		parts[i] = s.suggestion()
+	}
+	for _, f := range findings {
+		file, line := findingLocation(f)
+		if f.GetRuleId() == key.ruleID && file == key.file && line == key.line && f.Metadata != nil {
+			f.Metadata["ai_triage_conflict"] = strings.Join(parts, "; ")
+		}
+	}
+}
+
+// untriaged returns the findings not in adjusted.
+func untriaged(findings []*pluginv1.Finding, adjusted map[*pluginv1.Finding]bool) []*pluginv1.Finding {
+	var rest []*pluginv1.Finding
+	for _, f := range findings {
+		if !adjusted[f] {
+			rest = append(rest, f)
+		}
+	}
+	return rest
+}
+
+// adjustmentStream incrementally decodes a JSON array of triage adjustments
+// from streamed text, calling apply for each element once it is complete.
+// Text before the opening bracket, such as a code fence, is skipped. After a
+// syntax error, or once the response breaks the limits parseTriageResponse
+// applies, it ignores further input.
+type adjustmentStream struct {
+	apply func(triageAdjustment)
+	count int
+
+	buf     []byte
+	pos     int
+	started bool
+	done    bool
+	err     error
+}
+
+// write appends a chunk of streamed text and applies every adjustment that
+// is now complete.
+func (s *adjustmentStream) write(chunk string) {
+	if s.done || s.err != nil {
+		return
+	}
+	s.buf = append(s.buf, chunk...)
+	if len(s.buf) > maxTriageResponseBytes {
+		s.err = fmt.Errorf("response exceeds %d bytes", maxTriageResponseBytes)
+		return
+	}
+	for {
+		if !s.started {
+			i := bytes.IndexByte(s.buf[s.pos:], '[')
+			if i < 0 {
+				s.pos = len(s.buf)
+				return
+			}
+			s.pos += i + 1
+			s.started = true
+		}
+		for s.pos < len(s.buf) && strings.IndexByte(" \t\r\n,", s.buf[s.pos]) >= 0 {
+			s.pos++
+		}
+		if s.pos == len(s.buf) {
+			return
+		}
+		if s.buf[s.pos] == ']' {
+			s.done = true
+			return
+		}
+
+		// The array itself is the first level.
+		if err := checkJSONDepth(s.buf[s.pos:], maxTriageJSONDepth-1); err != nil {
+			s.err = err
+			return
+		}
+		dec := json.NewDecoder(bytes.NewReader(s.buf[s.pos:]))
+		var adj triageAdjustment
+		if err := dec.Decode(&adj); err != nil {
+			if !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+				s.err = err
+			}
+			return
+		}
+		s.pos += int(dec.InputOffset())
+		s.count++
+		s.apply(adj)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+// streamProvider implements streamingProvider by delivering chunks in order.
+// onChunk, if set, runs after each chunk and may stop the stream by
+// returning an error.
+type streamProvider struct {
+	mockProvider
+	chunks  []string
+	onChunk func(i int) error
+}
+
+func (p *streamProvider) CompleteStream(_ context.Context, _ plannerllm.CompletionRequest, onDelta func(string)) (plannerllm.CompletionResponse, error) {
+	var content string
+	for i, c := range p.chunks {
+		onDelta(c)
+		content += c
+		if p.onChunk != nil {
+			if err := p.onChunk(i); err != nil {
+				return plannerllm.CompletionResponse{}, err
+			}
+		}
+	}
+	return plannerllm.CompletionResponse{Message: plannerllm.Message{Content: content}}, nil
+}
+
+func streamFindings(n int) []*pluginv1.Finding {
+	findings := make([]*pluginv1.Finding, n)
+	for i := range findings {
+		findings[i] = &pluginv1.Finding{
+			RuleId:   "TRIAGE-002",
+			Severity: sdk.SeverityMedium,
+			Location: &pluginv1.Location{FilePath: "api.py", StartLine: int32(i + 1)},
+			Metadata: map[string]string{"priority": "scheduled"},
+		}
+	}
+	return findings
+}
+
+func lowAdjustment(line int) string {
+	data, _ := json.Marshal(triageAdjustment{
+		RuleID:           "TRIAGE-002",
+		File:             "api.py",
+		Line:             line,
+		AdjustedSeverity: "low",
+		Classification:   "false_positive",
+		Reason:           "validated upstream",
+	})
+	return string(data)
+}
+
+func TestAITriageStreamsAdjustments(t *testing.T) {
+	findings := streamFindings(2)
+	first, second := lowAdjustment(1), lowAdjustment(2)
+	provider := &streamProvider{
+		chunks: []string{"```json\n[", first[:10], first[10:] + ",\n", second, "]\n```"},
+	}
+	provider.onChunk = func(i int) error {
+		// The first element is complete after chunk 2 and must already be
+		// applied before the second arrives.
+		if i == 2 && findings[0].GetSeverity() != sdk.SeverityLow {
+			t.Error("expected the first adjustment to be applied before the stream finished")
+		}
+		return nil
+	}
+
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{Stream: true})
+
+	for _, f := range findings {
+		if f.GetSeverity() != sdk.SeverityLow || f.Metadata["ai_triaged"] != "true" {
+			t.Errorf("line %d not triaged: %v", f.GetLocation().GetStartLine(), f.Metadata)
+		}
+	}
+}
+
+func TestAITriageStreamCancelledKeepsPartial(t *testing.T) {
+	findings := streamFindings(2)
+	provider := &streamProvider{chunks: []string{"[" + lowAdjustment(1) + ",", lowAdjustment(2)[:5]}}
+	provider.onChunk = func(i int) error {
+		if i == 0 {
+			return context.Canceled
+		}
+		return nil
+	}
+
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{Stream: true})
+
+	if findings[0].GetSeverity() != sdk.SeverityLow {
+		t.Error("expected the adjustment received before cancellation to be kept")
+	}
+	if findings[0].Metadata["ai_triage_error"] != "" {
+		t.Error("adjusted finding should not carry ai_triage_error")
+	}
+	if findings[1].Metadata["ai_triage_error"] == "" || findings[1].GetSeverity() != sdk.SeverityMedium {
+		t.Errorf("expected the unfinished finding to be marked, got %v", findings[1].Metadata)
+	}
+}
+
+func TestAITriageStreamFallsBackWithoutSupport(t *testing.T) {
+	findings := streamFindings(1)
+	provider := &mockProvider{response: "[" + lowAdjustment(1) + "]"}
+
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{Stream: true})
+
+	if findings[0].GetSeverity() != sdk.SeverityLow {
+		t.Error("expected the blocking fallback to apply the adjustment")
+	}
+}
+
+func TestAITriageStreamDuplicateKeepsFirst(t *testing.T) {
+	findings := streamFindings(1)
+	high := strings.Replace(lowAdjustment(1), `"low"`, `"high"`, 1)
+	provider := &streamProvider{chunks: []string{"[" + lowAdjustment(1) + ",", lowAdjustment(1) + ",", high + "]"}}
+
+	aiTriageFindings(context.Background(), provider, "mock-model", findings, &triageConfig{Stream: true})
+
+	f := findings[0]
+	if f.GetSeverity() != sdk.SeverityLow {
+		t.Errorf("expected the first streamed adjustment to win, got %v", f.GetSeverity())
+	}
+	if f.Metadata["ai_original_severity"] != "SEVERITY_MEDIUM" {
+		t.Errorf("duplicate must not be applied twice, ai_original_severity=%q", f.Metadata["ai_original_severity"])
+	}
+	if f.Metadata["ai_triage_conflict"] != "low//false_positive; high//false_positive" {
+		t.Errorf("unexpected ai_triage_conflict %q", f.Metadata["ai_triage_conflict"])
+	}
+}
+
+func TestAdjustmentStreamRejectsDeepNesting(t *testing.T) {
+	stream := &adjustmentStream{apply: func(triageAdjustment) { t.Error("nothing should be applied") }}
+	stream.write(`[{"rule_id":"TRIAGE-001","x":` + strings.Repeat("[", 20))
+	if stream.err == nil {
+		t.Error("expected a depth error")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the plugin's spans.
+const tracerName = "github.com/nox-hq/nox-plugin-triage-agent"
+
+// tracingShutdownTimeout bounds how long exiting waits to flush spans.
+const tracingShutdownTimeout = 5 * time.Second
+
+// setupTracing exports spans over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT
+// or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set; the exporter reads its other
+// OTEL_EXPORTER_OTLP_* settings from the environment itself. Otherwise the
+// global tracer provider stays a no-op and spans cost next to nothing. The
+// returned function flushes and stops the exporter.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return noop, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return noop, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("nox-plugin-triage-agent"),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return noop, err
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// startSpan starts a span as a child of any span in ctx.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan marks span failed if err is set and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordSpanError marks the span in ctx failed without ending it.
+func recordSpanError(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// traced wraps a tool handler in a span named after the tool that records
+// the findings and diagnostics returned, or the error.
+func traced(tool string, handler sdk.ToolHandler) sdk.ToolHandler {
+	return func(ctx context.Context, req sdk.ToolRequest) (*pluginv1.InvokeToolResponse, error) {
+		ctx, span := startSpan(ctx, tool)
+		resp, err := handler(ctx, req)
+		span.SetAttributes(
+			attribute.Int("findings", len(resp.GetFindings())),
+			attribute.Int("diagnostics", len(resp.GetDiagnostics())),
+		)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			span.SetAttributes(attribute.Bool("cancelled", true))
+		}
+		endSpan(span, err)
+		return resp, err
+	}
+}
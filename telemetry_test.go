@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in
+// memory for the rest of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+// spanAttrs returns the attributes of the first ended span named name.
+func spanAttrs(rec *tracetest.SpanRecorder, name string) (sdktrace.ReadOnlySpan, map[attribute.Key]attribute.Value) {
+	for _, s := range rec.Ended() {
+		if s.Name() != name {
+			continue
+		}
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range s.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		return s, attrs
+	}
+	return nil, nil
+}
+
+func TestSetupTracingNoopWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	prev := otel.GetTracerProvider()
+
+	shutdown, err := setupTracing(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otel.GetTracerProvider() != prev {
+		t.Error("expected the tracer provider left alone without an endpoint")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
+
+func TestScanSpans(t *testing.T) {
+	rec := recordSpans(t)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\n")
+	writeFile(t, filepath.Join(root, "util.py"), "x = 1\n")
+
+	client := testClient(t)
+	resp := invokeScan(t, client, root)
+
+	scan, attrs := spanAttrs(rec, "scan")
+	if scan == nil {
+		t.Fatalf("expected a scan span, got %d span(s)", len(rec.Ended()))
+	}
+	if got := attrs["findings"].AsInt64(); got != int64(len(resp.GetFindings())) {
+		t.Errorf("scan span: findings = %d, want %d", got, len(resp.GetFindings()))
+	}
+	walk, attrs := spanAttrs(rec, "scan.walk")
+	if walk == nil || attrs["files_scanned"].AsInt64() != 2 {
+		t.Fatalf("expected a walk span with files_scanned=2, got %v", attrs)
+	}
+	if walk.Parent().SpanID() != scan.SpanContext().SpanID() {
+		t.Error("expected the walk span to be a child of the scan span")
+	}
+	if adjust, _ := spanAttrs(rec, "scan.adjust"); adjust == nil {
+		t.Error("expected an adjust span")
+	}
+}
+
+func TestTriageBatchSpan(t *testing.T) {
+	rec := recordSpans(t)
+	provider := funcProvider(func(context.Context, plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+		return plannerllm.CompletionResponse{}, errors.New("rate limited")
+	})
+	findings := []*pluginv1.Finding{{
+		RuleId:   "TRIAGE-001",
+		Location: &pluginv1.Location{FilePath: "app.py", StartLine: 1},
+		Metadata: map[string]string{},
+	}}
+
+	triageBatch(context.Background(), provider, "gpt-4o", findings, &triageConfig{})
+
+	span, attrs := spanAttrs(rec, "llm.call")
+	if span == nil {
+		t.Fatal("expected an llm.call span")
+	}
+	if attrs["llm.model"].AsString() != "gpt-4o" || attrs["llm.provider"].AsString() != "func" || attrs["findings"].AsInt64() != 1 {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+	if _, ok := attrs["llm.latency_ms"]; !ok {
+		t.Error("expected the call latency")
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("expected the failed call marked as an error, got %v", span.Status())
+	}
+}
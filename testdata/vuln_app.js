@@ -26,3 +26,45 @@ function legacyEndpoint() {
 // TRIAGE-004: Security-relevant code
 const token = jwt.sign({ id: 1 }, 'secret');
 const hash = crypto.createHash('sha256');
+
+// TRIAGE-018: Prototype pollution vectors
+function deepMerge(target, source) {
+    for (const key in source) {
+        target[key] = source[key];
+    }
+    return target;
+}
+function updateSettings(req, settings) {
+    _.merge(settings, req.body);
+    settings[req.query.key] = req.query.value;
+}
+
+// TRIAGE-019: Insecure cookie configuration
+function setSession(res, sid) {
+    res.cookie('sid', sid, { httpOnly: false });
+    res.cookie('theme', 'dark', { secure: true, httpOnly: true, sameSite: 'strict' });
+}
+
+// TRIAGE-020: Authentication endpoints without rate limiting
+app.post('/api/login', (req, res) => {
+    res.json(checkCredentials(res.locals.credentials));
+});
+app.post('/api/token', tokenLimiter, issueToken);
+
+// TRIAGE-021: Hardcoded internal addresses
+const ledger = `https://10.1.2.3/ledger`;
+
+// TRIAGE-022: Insecure temporary files
+fs.writeFile('/tmp/export.json', JSON.stringify(report), done);
+
+// TRIAGE-023: Records fetched by a request-supplied ID
+const order = await Order.findById(req.params.orderId);
+const mine = await Order.findOne({ _id: req.params.orderId, owner: req.user.id });
+
+// TRIAGE-024: Cleartext HTTP endpoints
+const telemetry = fetch("http://telemetry.vendor.io/collect", { method: "POST" });
+const svgNS = "http://www.w3.org/2000/svg";
+
+// TRIAGE-025: Regexes prone to catastrophic backtracking
+const slugRe = /^(\w|\d)+$/;
+const safeSlugRe = /^[\w-]+$/;
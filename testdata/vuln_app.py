@@ -33,3 +33,37 @@ from cryptography.fernet import Fernet
 import jwt
 import bcrypt
 import passlib
+
+# TRIAGE-019: Insecure cookie and session configuration
+SESSION_COOKIE_SECURE = False
+
+@app.route("/login")
+def login():
+    resp = app.make_response("ok")
+    resp.set_cookie("session", "abc")
+    resp.set_cookie("pref", "dark", secure=True, httponly=True)
+    return resp
+
+# TRIAGE-021: Hardcoded internal addresses
+INVENTORY_URL = "http://inventory.svc.internal:8080"
+REPLICA_HOST = "172.20.1.7"
+DOCS_HOST = "api.example.internal"
+PUBLIC_DNS = "8.8.8.8"
+
+# TRIAGE-022: Insecure temporary files
+SCRATCH = tempfile.mktemp()
+LOCK_PATH = "/tmp/app.lock"
+fd, SAFE_PATH = tempfile.mkstemp(dir="/tmp")
+
+# TRIAGE-023: Records fetched by a request-supplied ID
+invoice = Invoice.objects.get(id=request.args["invoice_id"])
+own_invoice = Invoice.objects.get(id=request.args["invoice_id"], owner=request.user)
+
+# TRIAGE-024: Cleartext HTTP endpoints
+PAYMENTS_URL = "http://payments.partner-api.net/v2/charge"
+HEALTH_URL = "http://localhost:8080/healthz"
+XSI_NS = "http://www.w3.org/2001/XMLSchema-instance"
+
+# TRIAGE-025: Regexes prone to catastrophic backtracking
+EMAIL_RE = re.compile(r"^([a-zA-Z0-9_.-]+)+@mail\.net$")
+SAFE_EMAIL_RE = re.compile(r"^[a-zA-Z0-9_.-]+@mail\.net$")
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	plannerllm "go.klarlabs.de/agent/contrib/planner-llm"
+)
+
+// providerRoute is the HTTP configuration of the configured provider that
+// its client takes no option for: the NOX_AI_HEADERS headers and the
+// NOX_AI_BASE_URL host they are limited to, the JSON mode added to
+// completion requests, and the transport enforcing NOX_AI_HTTP_TIMEOUT.
+type providerRoute struct {
+	header   http.Header
+	host     string
+	jsonMode jsonMode
+	timed    *http.Transport
+}
+
+// newProviderRoute builds the route of provider requests from s. It returns
+// nil when there is nothing to apply. Headers need NOX_AI_BASE_URL, so they
+// can only ever reach the host it names.
+func newProviderRoute(s settings, baseURL string) (*providerRoute, error) {
+	route := &providerRoute{jsonMode: triageJSONMode(s)}
+	header, err := parseHeaders(s.get("NOX_AI_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		u, err := url.Parse(baseURL)
+		if baseURL == "" || err != nil || u.Host == "" {
+			return nil, fmt.Errorf("NOX_AI_HEADERS requires NOX_AI_BASE_URL to name the host the headers are sent to")
+		}
+		log.Printf("ai_triage: applying custom headers %s", redactHeaders(header))
+		route.header, route.host = header, u.Host
+	}
+	if timeout := s.getDuration("NOX_AI_HTTP_TIMEOUT"); timeout > 0 {
+		route.timed = timedTransport(timeout)
+	}
+	if route.header == nil && route.jsonMode == jsonModeOff && route.timed == nil {
+		return nil, nil
+	}
+	return route, nil
+}
+
+// providerRouteKey is the context key under which a completion call carries
+// its provider's route to providerTransport.
+type providerRouteKey struct{}
+
+// routedProvider is a provider whose completion calls carry its route in
+// their context, so providerTransport changes the requests made for the
+// call and no others.
+type routedProvider struct {
+	plannerllm.Provider
+	route *providerRoute
+}
+
+func (p *routedProvider) Complete(ctx context.Context, req plannerllm.CompletionRequest) (plannerllm.CompletionResponse, error) {
+	return p.Provider.Complete(context.WithValue(ctx, providerRouteKey{}, p.route), req)
+}
+
+// routedStreamingProvider is a routedProvider for a provider that streams.
+type routedStreamingProvider struct {
+	*routedProvider
+	stream streamingProvider
+}
+
+func (p *routedStreamingProvider) CompleteStream(ctx context.Context, req plannerllm.CompletionRequest, onDelta func(string)) (plannerllm.CompletionResponse, error) {
+	return p.stream.CompleteStream(context.WithValue(ctx, providerRouteKey{}, p.route), req, onDelta)
+}
+
+// routeProvider returns p with its calls sent along route, or p itself if
+// route is nil.
+func routeProvider(p plannerllm.Provider, route *providerRoute) plannerllm.Provider {
+	if route == nil {
+		return p
+	}
+	installProviderTransport()
+	routed := &routedProvider{Provider: p, route: route}
+	if sp, ok := p.(streamingProvider); ok {
+		return &routedStreamingProvider{routedProvider: routed, stream: sp}
+	}
+	return routed
+}
+
+var installTransportOnce sync.Once
+
+// installProviderTransport wraps http.DefaultTransport in a
+// providerTransport. The provider clients take no http.Client or transport
+// of their own, so their requests can only be reached there; requests made
+// outside a routed completion call, such as webhook and OTLP exports, pass
+// through it unchanged.
+func installProviderTransport() {
+	installTransportOnce.Do(func() {
+		http.DefaultTransport = &providerTransport{base: http.DefaultTransport}
+	})
+}
+
+// providerTransport applies the route carried by a request's context.
+type providerTransport struct {
+	base http.RoundTripper
+}
+
+func (t *providerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route, _ := req.Context().Value(providerRouteKey{}).(*providerRoute)
+	if route == nil {
+		return t.base.RoundTrip(req)
+	}
+	if len(route.header) > 0 && strings.EqualFold(req.URL.Host, route.host) {
+		req = req.Clone(req.Context())
+		for name, values := range route.header {
+			req.Header[name] = values
+		}
+	}
+	req, err := withJSONModeBody(req, route.jsonMode)
+	if err != nil {
+		return nil, err
+	}
+	if route.timed != nil {
+		return route.timed.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// cachedVerdictKeys are the AI verdict metadata keys copied from a baseline
+// finding by reuseCachedVerdicts.
+var cachedVerdictKeys = []string{
+	"ai_triaged", "ai_classification", "ai_triage_reason", "ai_triage_conflict",
+	"ai_suggested_severity", "ai_suggested_priority",
+}
+
+// reuseCachedVerdicts applies the AI verdict recorded in a JSON baseline to
+// every finding whose fingerprint matches a baseline finding that was
+// triaged, tagging it ai_triage_cached=true, and returns the number reused.
+// With classifyOnly, a recorded severity or priority change is replayed as a
+// suggestion, as applyAdjustments does.
+// The fingerprint covers the rule, path, and source line, so a match means
+// the code the verdict was given for is unchanged. The llmAdjuster skips
+// tagged findings, so only new and changed findings are sent to the LLM.
+func reuseCachedVerdicts(findings []*pluginv1.Finding, baseline map[string]baselineEntry, classifyOnly bool) int {
+	reused := 0
+	for _, f := range findings {
+		if f.GetFingerprint() == "" {
+			continue
+		}
+		cached := baseline[f.GetFingerprint()].Finding
+		if cached.GetMetadata()["ai_triaged"] != "true" {
+			continue
+		}
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		for _, key := range cachedVerdictKeys {
+			if v, ok := cached.GetMetadata()[key]; ok {
+				f.Metadata[key] = v
+			}
+		}
+		f.Metadata["ai_triage_cached"] = "true"
+		reused++
+
+		// The verdict's severity and priority changes are replayed the way
+		// applyAdjustments makes them, on top of this run's values, so the
+		// same metadata contract holds.
+		_, severityChanged := cached.GetMetadata()["ai_original_severity"]
+		_, priorityChanged := cached.GetMetadata()["ai_original_priority"]
+		if classifyOnly {
+			if severityChanged {
+				f.Metadata["ai_suggested_severity"] = severityLabel(cached)
+			}
+			if priorityChanged {
+				f.Metadata["ai_suggested_priority"] = cached.GetMetadata()["priority"]
+			}
+			continue
+		}
+		if severityChanged {
+			f.Metadata["ai_original_severity"] = severityLabel(f)
+			f.Severity = cached.GetSeverity()
+			setCustomSeverity(f, severityLabel(cached))
+		}
+		if priorityChanged {
+			f.Metadata["ai_original_priority"] = f.Metadata["priority"]
+			f.Metadata["priority"] = cached.GetMetadata()["priority"]
+		}
+	}
+	return reused
+}
+
+// uncachedFindings returns the findings without a reused verdict.
+func uncachedFindings(findings []*pluginv1.Finding) []*pluginv1.Finding {
+	var out []*pluginv1.Finding
+	for _, f := range findings {
+		if f.GetMetadata()["ai_triage_cached"] != "true" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
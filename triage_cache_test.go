@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+func TestReuseCachedVerdicts(t *testing.T) {
+	baseline := map[string]baselineEntry{
+		"fp-triaged": {Fingerprint: "fp-triaged", Finding: &pluginv1.Finding{
+			Fingerprint: "fp-triaged",
+			Severity:    sdk.SeverityLow,
+			Metadata: map[string]string{
+				"ai_triaged":           "true",
+				"ai_classification":    "false_positive",
+				"ai_triage_reason":     "test fixture",
+				"ai_original_severity": "SEVERITY_HIGH",
+				"ai_original_priority": "immediate",
+				"priority":             "backlog",
+			},
+		}},
+		"fp-untriaged": {Fingerprint: "fp-untriaged", Finding: &pluginv1.Finding{Fingerprint: "fp-untriaged"}},
+		"fp-text":      {Fingerprint: "fp-text"},
+	}
+	finding := func(fp string) *pluginv1.Finding {
+		return &pluginv1.Finding{
+			Fingerprint: fp,
+			Severity:    sdk.SeverityHigh,
+			Metadata:    map[string]string{"priority": "immediate"},
+		}
+	}
+	findings := []*pluginv1.Finding{finding("fp-triaged"), finding("fp-untriaged"), finding("fp-text"), finding("fp-new")}
+
+	if n := reuseCachedVerdicts(findings, baseline, false); n != 1 {
+		t.Fatalf("reused %d verdict(s), want 1", n)
+	}
+	got := findings[0]
+	if got.GetSeverity() != sdk.SeverityLow || got.GetMetadata()["priority"] != "backlog" {
+		t.Errorf("cached verdict not applied: %v %v", got.GetSeverity(), got.GetMetadata())
+	}
+	if got.GetMetadata()["ai_original_severity"] != "SEVERITY_HIGH" || got.GetMetadata()["ai_original_priority"] != "immediate" {
+		t.Errorf("original values not recorded: %v", got.GetMetadata())
+	}
+	if got.GetMetadata()["ai_classification"] != "false_positive" || got.GetMetadata()["ai_triage_cached"] != "true" {
+		t.Errorf("verdict metadata not copied: %v", got.GetMetadata())
+	}
+
+	pending := uncachedFindings(findings)
+	if len(pending) != 3 || pending[0] != findings[1] {
+		t.Errorf("expected the 3 findings without a cached verdict to remain, got %d", len(pending))
+	}
+}
+
+func TestLLMAdjusterSkipsCached(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1},
+			Metadata: map[string]string{"ai_triaged": "true", "ai_triage_cached": "true"}},
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 2}},
+	}
+	a := &llmAdjuster{provider: &mockProvider{err: context.DeadlineExceeded}, model: "mock-model"}
+	if err := a.Adjust(context.Background(), findings); err != nil {
+		t.Fatal(err)
+	}
+	if findings[0].GetMetadata()["ai_triage_error"] != "" {
+		t.Error("a finding with a cached verdict should not be sent to the provider")
+	}
+	if findings[1].GetMetadata()["ai_triage_error"] == "" {
+		t.Error("expected the uncached finding to be sent to the provider")
+	}
+}
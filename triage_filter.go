@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+// triageFilter selects the findings sent to the LLM. Findings it rejects
+// pass through AI triage untouched. The zero value accepts every finding.
+type triageFilter struct {
+	// MinSeverity and MinConfidence are the least severe and least certain
+	// levels eligible; unspecified disables the check.
+	MinSeverity   pluginv1.Severity
+	MinConfidence pluginv1.Confidence
+	// Rules limits triage to these rule IDs; empty allows every rule.
+	Rules []string
+}
+
+// parseTriageFilter reads the triage_min_severity, triage_min_confidence,
+// and triage_rules inputs.
+func parseTriageFilter(input map[string]any) (*triageFilter, error) {
+	filter := &triageFilter{Rules: inputStrings(input, "triage_rules")}
+	if s := inputString(input, "triage_min_severity"); s != "" {
+		if filter.MinSeverity = parseSeverity(s); filter.MinSeverity == pluginv1.Severity(0) {
+			return nil, fmt.Errorf("unknown triage_min_severity %q", s)
+		}
+	}
+	if s := inputString(input, "triage_min_confidence"); s != "" {
+		if filter.MinConfidence = parseConfidence(s); filter.MinConfidence == pluginv1.Confidence(0) {
+			return nil, fmt.Errorf("unknown triage_min_confidence %q", s)
+		}
+	}
+	return filter, nil
+}
+
+// eligible reports whether f should be sent to the LLM. Lower enum values
+// are more severe and more certain.
+func (t *triageFilter) eligible(f *pluginv1.Finding) bool {
+	if t.MinSeverity != pluginv1.Severity(0) && f.GetSeverity() > t.MinSeverity {
+		return false
+	}
+	if t.MinConfidence != pluginv1.Confidence(0) && f.GetConfidence() > t.MinConfidence {
+		return false
+	}
+	if len(t.Rules) == 0 {
+		return true
+	}
+	for _, id := range t.Rules {
+		if strings.EqualFold(id, f.GetRuleId()) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the findings eligible for triage. A nil filter returns
+// findings unchanged.
+func (t *triageFilter) apply(findings []*pluginv1.Finding) []*pluginv1.Finding {
+	if t == nil {
+		return findings
+	}
+	var out []*pluginv1.Finding
+	for _, f := range findings {
+		if t.eligible(f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// parseConfidence converts a confidence string to the protobuf enum value.
+func parseConfidence(s string) pluginv1.Confidence {
+	switch strings.ToLower(s) {
+	case "high":
+		return sdk.ConfidenceHigh
+	case "medium":
+		return sdk.ConfidenceMedium
+	case "low":
+		return sdk.ConfidenceLow
+	default:
+		return pluginv1.Confidence(0)
+	}
+}
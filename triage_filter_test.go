@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+func TestTriageFilterEligible(t *testing.T) {
+	filter, err := parseTriageFilter(map[string]any{
+		"triage_min_severity":   "medium",
+		"triage_min_confidence": "high",
+		"triage_rules":          []any{"triage-001", "TRIAGE-002"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		f    *pluginv1.Finding
+		want bool
+	}{
+		{"eligible", &pluginv1.Finding{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Confidence: sdk.ConfidenceHigh}, true},
+		{"at threshold", &pluginv1.Finding{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Confidence: sdk.ConfidenceHigh}, true},
+		{"low severity", &pluginv1.Finding{RuleId: "TRIAGE-001", Severity: sdk.SeverityLow, Confidence: sdk.ConfidenceHigh}, false},
+		{"medium confidence", &pluginv1.Finding{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Confidence: sdk.ConfidenceMedium}, false},
+		{"other rule", &pluginv1.Finding{RuleId: "TRIAGE-004", Severity: sdk.SeverityHigh, Confidence: sdk.ConfidenceHigh}, false},
+	}
+	for _, tt := range tests {
+		if got := filter.eligible(tt.f); got != tt.want {
+			t.Errorf("%s: eligible = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTriageFilterInvalid(t *testing.T) {
+	for _, input := range []map[string]any{
+		{"triage_min_severity": "urgent"},
+		{"triage_min_confidence": "certain"},
+	} {
+		if _, err := parseTriageFilter(input); err == nil {
+			t.Errorf("expected an error for %v", input)
+		}
+	}
+}
+
+func TestLLMAdjusterSkipsIneligible(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1}},
+		{RuleId: "TRIAGE-004", Severity: sdk.SeverityInfo, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 2}},
+	}
+	a := &llmAdjuster{
+		provider: &mockProvider{err: context.DeadlineExceeded},
+		model:    "mock-model",
+		filter:   &triageFilter{MinSeverity: sdk.SeverityMedium},
+	}
+	if err := a.Adjust(context.Background(), findings); err != nil {
+		t.Fatal(err)
+	}
+
+	if findings[0].GetMetadata()["ai_triage_error"] == "" {
+		t.Error("expected the eligible finding to be sent to the provider")
+	}
+	if findings[1].GetMetadata() != nil {
+		t.Errorf("ineligible finding should pass through untouched, got %v", findings[1].GetMetadata())
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+// triageStats summarizes one AI triage run. A sudden shift in these numbers
+// between runs, such as many more unmatched adjustments, is an early sign
+// that a model is misbehaving.
+type triageStats struct {
+	// Findings is the number of findings sent for triage and Triaged the
+	// number the model returned an adjustment for.
+	Findings int
+	Triaged  int
+	// Errors counts findings left with ai_triage_error.
+	Errors int
+	// Raised, Lowered, and Kept count triaged findings by how their
+	// severity changed.
+	Raised  int
+	Lowered int
+	Kept    int
+	// Classifications counts triaged findings per ai_classification.
+	Classifications map[string]int
+	// Unmatched counts adjustments that referred to no finding.
+	Unmatched int
+}
+
+// summarizeTriage computes the statistics for findings after triage. before
+// holds each finding's severity from before the run, by index.
+func summarizeTriage(findings []*pluginv1.Finding, before []pluginv1.Severity, unmatched int) triageStats {
+	stats := triageStats{
+		Findings:        len(findings),
+		Classifications: make(map[string]int),
+		Unmatched:       unmatched,
+	}
+	for i, f := range findings {
+		md := f.GetMetadata()
+		if md["ai_triage_error"] != "" {
+			stats.Errors++
+		}
+		if md["ai_triaged"] != "true" {
+			continue
+		}
+		stats.Triaged++
+		if c := md["ai_classification"]; c != "" {
+			stats.Classifications[c]++
+		}
+		switch {
+		case severityMoreSevere(f.GetSeverity(), before[i]):
+			stats.Raised++
+		case severityMoreSevere(before[i], f.GetSeverity()):
+			stats.Lowered++
+		default:
+			stats.Kept++
+		}
+	}
+	return stats
+}
+
+// String renders the statistics as a single line for logs and diagnostics.
+func (s triageStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ai_triage: %d of %d finding(s) triaged: %d raised, %d lowered, %d kept",
+		s.Triaged, s.Findings, s.Raised, s.Lowered, s.Kept)
+	if len(s.Classifications) > 0 {
+		names := make([]string, 0, len(s.Classifications))
+		for name := range s.Classifications {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			names[i] = fmt.Sprintf("%s=%d", name, s.Classifications[name])
+		}
+		fmt.Fprintf(&b, "; %s", strings.Join(names, ", "))
+	}
+	if s.Unmatched > 0 {
+		fmt.Fprintf(&b, "; %d unmatched adjustment(s)", s.Unmatched)
+	}
+	if s.Errors > 0 {
+		fmt.Fprintf(&b, "; %d error(s)", s.Errors)
+	}
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"github.com/nox-hq/nox/sdk"
+)
+
+func TestAITriageStats(t *testing.T) {
+	findings := []*pluginv1.Finding{
+		{RuleId: "TRIAGE-001", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 1}},
+		{RuleId: "TRIAGE-002", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 2}},
+		{RuleId: "TRIAGE-003", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 3}},
+		{RuleId: "TRIAGE-004", Severity: sdk.SeverityMedium, Location: &pluginv1.Location{FilePath: "a.py", StartLine: 4}},
+	}
+	respJSON, _ := json.Marshal([]triageAdjustment{
+		{RuleID: "TRIAGE-001", File: "a.py", Line: 1, AdjustedSeverity: "high", Classification: "true_positive"},
+		{RuleID: "TRIAGE-002", File: "a.py", Line: 2, AdjustedSeverity: "low", Classification: "false_positive"},
+		{RuleID: "TRIAGE-003", File: "a.py", Line: 3, AdjustedSeverity: "medium", Classification: "true_positive"},
+		{RuleID: "TRIAGE-001", File: "b.py", Line: 9, AdjustedSeverity: "high", Classification: "true_positive"},
+	})
+
+	stats := aiTriageFindings(context.Background(), &mockProvider{response: string(respJSON)}, "mock-model", findings, nil)
+
+	want := "ai_triage: 3 of 4 finding(s) triaged: 1 raised, 1 lowered, 1 kept; false_positive=1, true_positive=2; 1 unmatched adjustment(s)"
+	if got := stats.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestAITriageStatsCountsErrors(t *testing.T) {
+	findings := []*pluginv1.Finding{{RuleId: "TRIAGE-001", Severity: sdk.SeverityHigh}}
+	stats := aiTriageFindings(context.Background(), &mockProvider{response: "not json"}, "mock-model", findings, nil)
+	if stats.Errors != 1 || stats.Triaged != 0 {
+		t.Errorf("expected 1 error and nothing triaged, got %+v", stats)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// defaultWebhookRetries is the number of retries after a failed delivery
+// when webhook_retries is not set.
+const defaultWebhookRetries = 3
+
+// webhookTimeout bounds each delivery attempt.
+const webhookTimeout = 10 * time.Second
+
+// webhookBackoff is the delay before the first retry; it doubles for each
+// further attempt. Tests shorten it.
+var webhookBackoff = 500 * time.Millisecond
+
+// webhookConfig describes where and how findings are posted.
+type webhookConfig struct {
+	URL string
+	// Auth is sent as the Authorization header when set.
+	Auth    string
+	Retries int
+}
+
+// webhookPayload is the JSON body posted to the webhook.
+type webhookPayload struct {
+	Plugin   string            `json:"plugin"`
+	Version  string            `json:"version"`
+	Count    int               `json:"count"`
+	Findings []json.RawMessage `json:"findings"`
+}
+
+// parseWebhookConfig reads the webhook_url, webhook_auth, and
+// webhook_retries inputs. webhook_auth falls back to NOX_WEBHOOK_AUTH so the
+// credential can stay out of tool input. The URL and auth come from the
+// caller's own input, never the configuration file merged into input, so
+// the environment's credential is only sent to a URL the caller chose. A nil
+// config means no webhook.
+func parseWebhookConfig(explicit, input map[string]any) (*webhookConfig, error) {
+	raw := inputString(explicit, "webhook_url")
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook_url must be an http or https URL")
+	}
+	cfg := &webhookConfig{
+		URL:     raw,
+		Auth:    inputString(explicit, "webhook_auth"),
+		Retries: inputInt(input, "webhook_retries", defaultWebhookRetries),
+	}
+	if cfg.Auth == "" {
+		cfg.Auth = os.Getenv("NOX_WEBHOOK_AUTH")
+	}
+	if cfg.Retries < 0 {
+		return nil, fmt.Errorf("webhook_retries must not be negative")
+	}
+	return cfg, nil
+}
+
+// webhookHost returns the host of a webhook URL for messages, leaving out
+// the path and query, which may carry tokens.
+func webhookHost(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return u.Host
+	}
+	return "webhook"
+}
+
+// postFindings sends findings to the webhook as one JSON document, retrying
+// network errors, 429, and 5xx responses with exponential backoff. It
+// returns the number of attempts made and the last error.
+func postFindings(ctx context.Context, cfg *webhookConfig, findings []*pluginv1.Finding) (int, error) {
+	payload := webhookPayload{
+		Plugin:   "nox/triage-agent",
+		Version:  version,
+		Count:    len(findings),
+		Findings: make([]json.RawMessage, 0, len(findings)),
+	}
+	for _, f := range findings {
+		data, err := protojson.Marshal(f)
+		if err != nil {
+			return 0, err
+		}
+		payload.Findings = append(payload.Findings, data)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	delay := webhookBackoff
+	attempts := 0
+	for {
+		attempts++
+		retry, err := postOnce(ctx, client, cfg, body)
+		if err == nil || !retry || attempts > cfg.Retries {
+			return attempts, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// postOnce makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func postOnce(ctx context.Context, client *http.Client, cfg *webhookConfig, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Auth != "" {
+		req.Header.Set("Authorization", cfg.Auth)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The url.Error wrapper repeats the full URL; keep it out of logs
+		// and diagnostics.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return ctx.Err() == nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
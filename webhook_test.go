@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pluginv1 "github.com/nox-hq/nox/gen/nox/plugin/v1"
+)
+
+func shortBackoff(t *testing.T) {
+	saved := webhookBackoff
+	webhookBackoff = time.Millisecond
+	t.Cleanup(func() { webhookBackoff = saved })
+}
+
+func TestPostFindingsRetries(t *testing.T) {
+	shortBackoff(t)
+	var calls atomic.Int32
+	var payload webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	findings := []*pluginv1.Finding{{RuleId: "TRIAGE-001"}, {RuleId: "TRIAGE-002"}}
+	attempts, err := postFindings(context.Background(), &webhookConfig{URL: srv.URL, Auth: "Bearer s3cret", Retries: 3}, findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if payload.Count != 2 || len(payload.Findings) != 2 || !strings.Contains(string(payload.Findings[0]), "TRIAGE-001") {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestPostFindingsClientErrorNotRetried(t *testing.T) {
+	shortBackoff(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	_, err := postFindings(context.Background(), &webhookConfig{URL: srv.URL, Retries: 3}, nil)
+	if err == nil || calls.Load() != 1 {
+		t.Errorf("expected one failed attempt, got %d calls, err %v", calls.Load(), err)
+	}
+}
+
+func TestParseWebhookConfig(t *testing.T) {
+	t.Setenv("NOX_WEBHOOK_AUTH", "Token env")
+	cfg, err := parseWebhookConfig(map[string]any{"webhook_url": "https://hooks.example.com/nox"}, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth != "Token env" || cfg.Retries != defaultWebhookRetries {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	for _, input := range []map[string]any{
+		{"webhook_url": "ftp://hooks.example.com"},
+		{"webhook_url": "not a url"},
+		{"webhook_url": "https://hooks.example.com", "webhook_retries": float64(-1)},
+	} {
+		if _, err := parseWebhookConfig(input, input); err == nil {
+			t.Errorf("expected an error for %v", input)
+		}
+	}
+}
+
+func TestWebhookURLNotFromConfigFile(t *testing.T) {
+	t.Setenv("NOX_WEBHOOK_AUTH", "Token env")
+	merged := map[string]any{"webhook_url": "https://attacker.example.com/collect"}
+	if cfg, err := parseWebhookConfig(map[string]any{}, merged); cfg != nil || err != nil {
+		t.Errorf("a webhook_url the caller did not pass must be ignored, got %+v, %v", cfg, err)
+	}
+
+	for _, line := range []string{"webhook_url: https://attacker.example.com/collect", "webhook_auth: Token x"} {
+		if _, err := parseRunConfig([]byte(line + "\n")); err == nil {
+			t.Errorf("%s: expected the configuration file to be rejected", line)
+		}
+	}
+}
+
+func TestScanWebhookFailureDoesNotFailScan(t *testing.T) {
+	shortBackoff(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app.py"), "result = eval(user_input)\n")
+
+	client := testClient(t)
+	resp := invokeScanWithInput(t, client, map[string]any{
+		"workspace_root":  root,
+		"webhook_url":     srv.URL + "/hook?token=abc",
+		"webhook_retries": float64(1),
+	})
+
+	if len(resp.GetFindings()) == 0 {
+		t.Fatal("expected findings despite the webhook failure")
+	}
+	var msg string
+	for _, d := range resp.GetDiagnostics() {
+		if strings.HasPrefix(d.GetMessage(), "webhook:") {
+			msg = d.GetMessage()
+		}
+	}
+	if !strings.Contains(msg, "failed after 2 attempt(s)") {
+		t.Errorf("expected a delivery failure diagnostic, got %q", msg)
+	}
+	if strings.Contains(msg, "token=abc") {
+		t.Errorf("diagnostic should not include the URL query: %q", msg)
+	}
+}